@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/config"
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// imagesWithStatus returns the images in the check results with the status.
+func imagesWithStatus(s string) []string {
+	images := []string{}
+	for _, r := range checkResults {
+		if r.Status == s {
+			images = append(images, r.Image)
+		}
+	}
+	return images
+}
+
+// partialImages returns the images that only some of the platforms have changed,
+// whether they are reported as updated or wait for the other platforms.
+func partialImages() []string {
+	images := []string{}
+	for _, r := range checkResults {
+		if r.Partial {
+			images = append(images, r.Image)
+		}
+	}
+	return images
+}
+
+// sizeWarningImages returns the images that grow beyond the size threshold.
+func sizeWarningImages() []string {
+	images := []string{}
+	for _, r := range checkResults {
+		if len(r.SizeWarnings) > 0 {
+			images = append(images, r.Image)
+		}
+	}
+	return images
+}
+
+// staleImages returns the images that haven't changed for their stale periods.
+func staleImages() []string {
+	images := []string{}
+	for _, r := range checkResults {
+		if r.Stale {
+			images = append(images, r.Image)
+		}
+	}
+	return images
+}
+
+// matrixFile is the path to the file that the build matrix of the updated images is written into.
+var matrixFile string
+
+// matrixEntry is an updated image in the build matrix.
+type matrixEntry struct {
+	// Ref is the image as written in the config, e.g. "alpine:3.15".
+	Ref string `json:"ref"`
+
+	// Image is the image without the tag and the digest, e.g. "alpine".
+	Image string `json:"image"`
+
+	Tag    string `json:"tag,omitempty"`
+	Digest string `json:"digest,omitempty"`
+}
+
+// buildMatrix returns the updated images as the matrix of GitHub Actions, e.g. {"include":[{"image":"alpine","tag":"3.15"}]}.
+func buildMatrix() ([]byte, error) {
+	include := []*matrixEntry{}
+	for _, image := range imagesWithStatus(checkUpdated) {
+		e := &matrixEntry{Ref: image}
+		name := image
+		if idx := strings.IndexRune(name, '@'); idx >= 0 {
+			e.Digest = name[idx+1:]
+			name = name[:idx]
+		}
+		if idx := strings.LastIndex(name, ":"); idx > strings.LastIndex(name, "/") {
+			e.Tag = name[idx+1:]
+			name = name[:idx]
+		} else if e.Digest == "" {
+			e.Tag = "latest"
+		}
+		e.Image = name
+		include = append(include, e)
+	}
+	return json.Marshal(struct {
+		Include []*matrixEntry `json:"include"`
+	}{include})
+}
+
+// writeMatrixFile writes the build matrix into matrixFile. "-" means stdout.
+func writeMatrixFile() error {
+	matrix, err := buildMatrix()
+	if err != nil {
+		return err
+	}
+	matrix = append(matrix, '\n')
+	if matrixFile == "-" {
+		_, err := os.Stdout.Write(matrix)
+		return err
+	}
+	return os.WriteFile(matrixFile, matrix, 0644)
+}
+
+// writeGitHubOutput writes the outputs of the step into $GITHUB_OUTPUT, if it runs on GitHub Actions.
+func writeGitHubOutput() error {
+	path := os.Getenv("GITHUB_OUTPUT")
+	if path == "" {
+		return nil
+	}
+
+	updatedImages, err := json.Marshal(imagesWithStatus(checkUpdated))
+	if err != nil {
+		return err
+	}
+	failedImages, err := json.Marshal(imagesWithStatus(checkFailed))
+	if err != nil {
+		return err
+	}
+	driftedImages, err := json.Marshal(imagesWithStatus(checkDrifted))
+	if err != nil {
+		return err
+	}
+	removedImages, err := json.Marshal(imagesWithStatus(checkRemoved))
+	if err != nil {
+		return err
+	}
+	partial, err := json.Marshal(partialImages())
+	if err != nil {
+		return err
+	}
+	sizeWarning, err := json.Marshal(sizeWarningImages())
+	if err != nil {
+		return err
+	}
+	stale, err := json.Marshal(staleImages())
+	if err != nil {
+		return err
+	}
+	mutated, err := json.Marshal(imagesWithStatus(checkMutated))
+	if err != nil {
+		return err
+	}
+	aliases, err := json.Marshal(aliasGroups())
+	if err != nil {
+		return err
+	}
+	matrix, err := buildMatrix()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(f, "updated=%t\n", len(updated) > 0)
+	fmt.Fprintf(f, "updated-images=%s\n", updatedImages)
+	fmt.Fprintf(f, "failed-images=%s\n", failedImages)
+	fmt.Fprintf(f, "drifted-images=%s\n", driftedImages)
+	fmt.Fprintf(f, "removed-images=%s\n", removedImages)
+	fmt.Fprintf(f, "partial-images=%s\n", partial)
+	fmt.Fprintf(f, "size-warning-images=%s\n", sizeWarning)
+	fmt.Fprintf(f, "stale-images=%s\n", stale)
+	fmt.Fprintf(f, "mutated-images=%s\n", mutated)
+	fmt.Fprintf(f, "aliases=%s\n", aliases)
+	fmt.Fprintf(f, "matrix=%s\n", matrix)
+	fmt.Fprintf(f, "tag=%s\n", releaseTag)
+	return f.Close()
+}
+
+// writeStepSummary writes the check results as Markdown into $GITHUB_STEP_SUMMARY, if it runs on GitHub Actions.
+func writeStepSummary() error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	printMarkdownSummary(&buf)
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func printMarkdownSummary(w io.Writer) {
+	title := "Image update check"
+	if dryRun {
+		title += " (dry run)"
+	}
+	fmt.Fprintf(w, "## %s\n\n", title)
+	fmt.Fprintf(w, "%d images checked, %d updated, %d failed, %d skipped.\n\n",
+		len(checkResults), len(imagesWithStatus(checkUpdated)), len(imagesWithStatus(checkFailed)), len(imagesWithStatus(checkSkipped)))
+	if len(checkResults) == 0 {
+		return
+	}
+
+	if mutated := imagesWithStatus(checkMutated); len(mutated) > 0 {
+		fmt.Fprintln(w, "> [!CAUTION]")
+		fmt.Fprintf(w, "> The immutable tags have changed: `%s`. It may indicate a supply-chain problem.\n\n", strings.Join(mutated, "`, `"))
+	}
+
+	fmt.Fprintln(w, "| image | status | digest |")
+	fmt.Fprintln(w, "| ----- | ------ | ------ |")
+	for _, r := range checkResults {
+		digest := "`" + r.Digest + "`"
+		if r.Digest == "" {
+			digest = markdownEscape(r.Error)
+		}
+		fmt.Fprintf(w, "| `%s` | %s | %s |\n", r.Image, r.Status, digest)
+	}
+
+	if images := sizeWarningImages(); len(images) > 0 {
+		fmt.Fprint(w, "\n### Size warnings\n\n")
+		for _, r := range checkResults {
+			for _, warning := range r.SizeWarnings {
+				fmt.Fprintf(w, "- `%s`: %s\n", r.Image, markdownEscape(warning))
+			}
+		}
+	}
+
+	if images := staleImages(); len(images) > 0 {
+		fmt.Fprint(w, "\n### Stale images\n\n")
+		fmt.Fprintln(w, "These images haven't been updated upstream for a long time. Consider migrating away from them.")
+		fmt.Fprintln(w)
+		for _, image := range images {
+			fmt.Fprintf(w, "- `%s`\n", image)
+		}
+	}
+
+	groups := aliasGroups()
+	if len(groups) > 0 {
+		digests := make([]string, 0, len(groups))
+		for digest := range groups {
+			digests = append(digests, digest)
+		}
+		sort.Strings(digests)
+		fmt.Fprint(w, "\n### Aliases\n\n")
+		for _, digest := range digests {
+			fmt.Fprintf(w, "- `%s`: `%s`\n", digest, strings.Join(groups[digest], "`, `"))
+		}
+	}
+
+	for _, r := range checkResults {
+		if r.Changes == nil {
+			continue
+		}
+		fmt.Fprintf(w, "\n### `%s`\n\n", r.Image)
+		printMarkdownMetadata(w, r.Metadata)
+		if r.Changes.OldMediaType != r.Changes.NewMediaType && r.Changes.OldMediaType != "" {
+			fmt.Fprintf(w, "media type: `%s` → `%s`\n\n", r.Changes.OldMediaType, r.Changes.NewMediaType)
+		}
+		fmt.Fprintln(w, "| platform | change | old digest | new digest |")
+		fmt.Fprintln(w, "| -------- | ------ | ---------- | ---------- |")
+		printPlatformRows(w, "added", r.Changes.Added)
+		printPlatformRows(w, "removed", r.Changes.Removed)
+		printPlatformRows(w, "changed", r.Changes.Changed)
+	}
+}
+
+// printMarkdownMetadata writes the metadata of the image, so that the right people see the update.
+func printMarkdownMetadata(w io.Writer, md *config.Metadata) {
+	if md == nil {
+		return
+	}
+	if md.Description != "" {
+		fmt.Fprintf(w, "%s\n\n", markdownEscape(md.Description))
+	}
+	var items []string
+	if md.Owner != "" {
+		items = append(items, "owner: "+markdownEscape(md.Owner))
+	}
+	if md.Channel != "" {
+		items = append(items, "channel: "+markdownEscape(md.Channel))
+	}
+	if md.ReleaseNotes != "" {
+		items = append(items, "[release notes]("+md.ReleaseNotes+")")
+	}
+	if len(items) > 0 {
+		fmt.Fprintf(w, "%s\n\n", strings.Join(items, " · "))
+	}
+}
+
+func printPlatformRows(w io.Writer, change string, diffs []*registry.PlatformDiff) {
+	for _, p := range diffs {
+		c := change
+		if p.OldOSVersion != "" && p.NewOSVersion != "" && p.OldOSVersion != p.NewOSVersion {
+			// e.g. the monthly patch of the Windows images
+			c += " (os.version " + markdownEscape(p.OldOSVersion) + " → " + markdownEscape(p.NewOSVersion) + ")"
+		}
+		fmt.Fprintf(w, "| %s | %s | %s | %s |\n", markdownEscape(p.Platform), c, markdownCode(p.OldDigest), markdownCode(p.NewDigest))
+	}
+}
+
+func markdownCode(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return "`" + s + "`"
+}
+
+// markdownEscape escapes the characters that break the Markdown tables.
+func markdownEscape(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/config"
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+func TestWriteGitHubOutput(t *testing.T) {
+	t.Cleanup(func() {
+		checkResults, updated, releaseTag = nil, nil, ""
+	})
+	checkResults = []*checkResult{
+		{Image: "alpine:3.17", Status: checkUpdated, Digest: "sha256:aaaa", Partial: true},
+		{Image: "alpine:edge", Status: checkUnchanged, Digest: "sha256:aaaa", Aliases: []string{"alpine:3.17"}, Stale: true},
+		{Image: "busybox:latest", Status: checkFailed, Error: "not found"},
+		{Image: "debian:12", Status: checkDrifted, SizeWarnings: []string{"linux/amd64 grows by 20%"}},
+		{Image: "ubuntu:22.04", Status: checkRemoved},
+		{Image: "nginx:1.25.3", Status: checkMutated},
+	}
+	updated = map[string]struct{}{"alpine:3.17": {}}
+	releaseTag = "images/20230102-150405"
+
+	path := filepath.Join(t.TempDir(), "output")
+	// the outputs of the previous steps are kept.
+	if err := os.WriteFile(path, []byte("previous=true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_OUTPUT", path)
+	if err := writeGitHubOutput(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `previous=true
+updated=true
+updated-images=["alpine:3.17"]
+failed-images=["busybox:latest"]
+drifted-images=["debian:12"]
+removed-images=["ubuntu:22.04"]
+partial-images=["alpine:3.17"]
+size-warning-images=["debian:12"]
+stale-images=["alpine:edge"]
+mutated-images=["nginx:1.25.3"]
+aliases={"sha256:aaaa":["alpine:edge"]}
+matrix={"include":[{"ref":"alpine:3.17","image":"alpine","tag":"3.17"}]}
+tag=images/20230102-150405
+`
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteStepSummary(t *testing.T) {
+	t.Cleanup(func() {
+		checkResults = nil
+	})
+	checkResults = []*checkResult{
+		{
+			Image:    "alpine:3.17",
+			Status:   checkUpdated,
+			Digest:   "sha256:aaaa",
+			Metadata: &config.Metadata{Owner: "@platform-team", ReleaseNotes: "https://alpinelinux.org/releases/"},
+			Changes: &registry.ManifestsDiff{
+				Added:   []*registry.PlatformDiff{{Platform: "linux/riscv64", NewDigest: "sha256:1111"}},
+				Changed: []*registry.PlatformDiff{{Platform: "linux/amd64", OldDigest: "sha256:2222", NewDigest: "sha256:3333"}},
+			},
+		},
+		{Image: "alpine:edge", Status: checkUnchanged, Digest: "sha256:aaaa", Aliases: []string{"alpine:3.17"}, Stale: true},
+		{Image: "busybox:latest", Status: checkFailed, Error: "unexpected status | 500\ninternal error"},
+		{Image: "debian:12", Status: checkUnchanged, Digest: "sha256:bbbb", SizeWarnings: []string{"linux/amd64 grows by 20%"}},
+		{Image: "nginx:1.25.3", Status: checkMutated, Digest: "sha256:cccc"},
+	}
+
+	path := filepath.Join(t.TempDir(), "summary.md")
+	// the summaries of the previous steps are kept.
+	if err := os.WriteFile(path, []byte("previous step\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_STEP_SUMMARY", path)
+	if err := writeStepSummary(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "previous step\n\n" +
+		"## Image update check\n\n" +
+		"5 images checked, 1 updated, 1 failed, 0 skipped.\n\n" +
+		"> [!CAUTION]\n" +
+		"> The immutable tags have changed: `nginx:1.25.3`. It may indicate a supply-chain problem.\n\n" +
+		"| image | status | digest |\n" +
+		"| ----- | ------ | ------ |\n" +
+		"| `alpine:3.17` | updated | `sha256:aaaa` |\n" +
+		"| `alpine:edge` | unchanged | `sha256:aaaa` |\n" +
+		"| `busybox:latest` | failed | unexpected status \\| 500 internal error |\n" +
+		"| `debian:12` | unchanged | `sha256:bbbb` |\n" +
+		"| `nginx:1.25.3` | mutated | `sha256:cccc` |\n" +
+		"\n### Size warnings\n\n" +
+		"- `debian:12`: linux/amd64 grows by 20%\n" +
+		"\n### Stale images\n\n" +
+		"These images haven't been updated upstream for a long time. Consider migrating away from them.\n\n" +
+		"- `alpine:edge`\n" +
+		"\n### Aliases\n\n" +
+		"- `sha256:aaaa`: `alpine:edge`\n" +
+		"\n### `alpine:3.17`\n\n" +
+		"owner: @platform-team · [release notes](https://alpinelinux.org/releases/)\n\n" +
+		"| platform | change | old digest | new digest |\n" +
+		"| -------- | ------ | ---------- | ---------- |\n" +
+		"| linux/riscv64 | added | - | `sha256:1111` |\n" +
+		"| linux/amd64 | changed | `sha256:2222` | `sha256:3333` |\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPrintMarkdownSummaryDryRun(t *testing.T) {
+	oldDryRun := dryRun
+	t.Cleanup(func() {
+		dryRun = oldDryRun
+		checkResults = nil
+	})
+	dryRun = true
+	checkResults = nil
+
+	var buf bytes.Buffer
+	printMarkdownSummary(&buf)
+	want := "## Image update check (dry run)\n\n0 images checked, 0 updated, 0 failed, 0 skipped.\n\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWriteMatrixFile(t *testing.T) {
+	oldMatrixFile := matrixFile
+	t.Cleanup(func() {
+		matrixFile = oldMatrixFile
+		checkResults = nil
+	})
+
+	tests := []struct {
+		name    string
+		results []*checkResult
+		want    string
+	}{
+		{
+			name:    "no updates",
+			results: []*checkResult{{Image: "alpine:3.17", Status: checkUnchanged}},
+			want:    `{"include":[]}`,
+		},
+		{
+			name: "tags and digests",
+			results: []*checkResult{
+				{Image: "alpine:3.17", Status: checkUpdated},
+				{Image: "busybox", Status: checkUpdated},
+				{Image: "localhost:5000/foo", Status: checkUpdated},
+				{Image: "ghcr.io/owner/bar:1.0@sha256:aaaa", Status: checkUpdated},
+				{Image: "debian@sha256:bbbb", Status: checkUpdated},
+				{Image: "ubuntu:22.04", Status: checkFailed},
+			},
+			want: `{"include":[` +
+				`{"ref":"alpine:3.17","image":"alpine","tag":"3.17"},` +
+				`{"ref":"busybox","image":"busybox","tag":"latest"},` +
+				`{"ref":"localhost:5000/foo","image":"localhost:5000/foo","tag":"latest"},` +
+				`{"ref":"ghcr.io/owner/bar:1.0@sha256:aaaa","image":"ghcr.io/owner/bar","tag":"1.0","digest":"sha256:aaaa"},` +
+				`{"ref":"debian@sha256:bbbb","image":"debian","digest":"sha256:bbbb"}` +
+				`]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkResults = tt.results
+			matrixFile = filepath.Join(t.TempDir(), "matrix.json")
+			if err := writeMatrixFile(); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(matrixFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want+"\n" {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"log"
+	"sort"
+)
+
+// findAliases sets the aliases of the results that resolve to the same digest, e.g. alpine:3.19 and alpine:latest.
+// Downstream consumers may deduplicate the rebuilds of the aliased images.
+func findAliases() {
+	images := map[string][]string{}
+	for _, r := range checkResults {
+		if r.Digest == "" {
+			continue
+		}
+		images[r.Digest] = append(images[r.Digest], r.Image)
+	}
+	for _, r := range checkResults {
+		if r.Digest == "" {
+			continue
+		}
+		r.Aliases = nil
+		for _, image := range images[r.Digest] {
+			if image != r.Image {
+				r.Aliases = append(r.Aliases, image)
+			}
+		}
+		if len(r.Aliases) > 0 {
+			log.Printf("%s is an alias of %v", r.Image, r.Aliases)
+		}
+	}
+}
+
+// aliasGroups returns the images grouped by the digest, only for the digests shared by multiple images.
+func aliasGroups() map[string][]string {
+	groups := map[string][]string{}
+	for _, r := range checkResults {
+		if len(r.Aliases) > 0 {
+			groups[r.Digest] = append(groups[r.Digest], r.Image)
+		}
+	}
+	for _, images := range groups {
+		sort.Strings(images)
+	}
+	return groups
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// auditLogFile is the append-only log of the detected changes, in JSON Lines. It is not written if it is empty.
+var auditLogFile string
+
+// auditLogged reports whether any lines are appended to auditLogFile in this run.
+var auditLogged bool
+
+// runID identifies the check run in the audit log and the summary.
+var runID string
+
+// newRunID returns the ID of the run: the ID of the workflow run and the attempt on GitHub Actions,
+// or the time when the run started and the random suffix.
+func newRunID(startedAt time.Time) string {
+	if id := os.Getenv("GITHUB_RUN_ID"); id != "" {
+		if attempt := os.Getenv("GITHUB_RUN_ATTEMPT"); attempt != "" {
+			return id + "-" + attempt
+		}
+		return id
+	}
+	var b [4]byte
+	rand.Read(b[:])
+	return startedAt.UTC().Format(historyTimeFormat) + "-" + hex.EncodeToString(b[:])
+}
+
+// auditEntry is a line of the audit log.
+type auditEntry struct {
+	Time   time.Time `json:"time"`
+	RunID  string    `json:"runId"`
+	Tenant string    `json:"tenant,omitempty"`
+	Image  string    `json:"image"`
+
+	// Status is "updated", or "mutated" if the immutable tag has changed.
+	Status string `json:"status"`
+
+	OldDigest string `json:"oldDigest,omitempty"`
+	NewDigest string `json:"newDigest"`
+
+	// Platforms are the changes of the platforms.
+	Platforms *registry.ManifestsDiff `json:"platforms,omitempty"`
+}
+
+// writeAuditLog appends the updated and the mutated images to auditLogFile.
+// The existing lines are never rewritten.
+func writeAuditLog(now time.Time) error {
+	if auditLogFile == "" {
+		return nil
+	}
+	var lines []byte
+	for _, r := range checkResults {
+		if r.Status != checkUpdated && r.Status != checkMutated {
+			continue
+		}
+		old := r.OldDigest
+		if s, ok := index.Images[r.Image]; ok && old == "" {
+			old = s.Digest
+		}
+		data, err := json.Marshal(&auditEntry{
+			Time:      now.UTC().Truncate(time.Second),
+			RunID:     runID,
+			Tenant:    tenantName,
+			Image:     r.Image,
+			Status:    r.Status,
+			OldDigest: old,
+			NewDigest: r.Digest,
+			Platforms: r.Changes,
+		})
+		if err != nil {
+			return err
+		}
+		lines = append(append(lines, data...), '\n')
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(auditLogFile), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(auditLogFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	recordChange(auditLogFile)
+	auditLogged = true
+	if _, err := f.Write(lines); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
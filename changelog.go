@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// changelogFile is the Markdown changelog of the updates. It is not written if it is empty.
+var changelogFile string
+
+// changelogHeader is the title of the changelog. The new entries are inserted after it.
+const changelogHeader = "# Updates\n"
+
+// writeChangelog prepends the entry of the updates of the run to changelogFile, if any images are updated.
+func writeChangelog(now time.Time) error {
+	if changelogFile == "" || len(updated) == 0 {
+		return nil
+	}
+	old, err := os.ReadFile(changelogFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var buf strings.Builder
+	buf.WriteString(changelogHeader)
+	buf.WriteString("\n")
+	writeChangelogEntry(&buf, now)
+	if rest := strings.TrimLeft(strings.TrimPrefix(string(old), changelogHeader), "\n"); rest != "" {
+		buf.WriteString("\n")
+		buf.WriteString(rest)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(changelogFile), 0755); err != nil {
+		return err
+	}
+	recordChange(changelogFile)
+	return os.WriteFile(changelogFile, []byte(buf.String()), 0644)
+}
+
+// writeChangelogEntry writes the entry of the updated images, e.g.
+//
+//	## 2024-01-02 03:04 UTC
+//
+//	- `alpine:3.17`: `sha256:4c1d2e3f4a5b` → `sha256:8a4b5c6d7e8f` (changed: linux/amd64)
+func writeChangelogEntry(buf *strings.Builder, now time.Time) {
+	fmt.Fprintf(buf, "## %s\n\n", now.UTC().Format("2006-01-02 15:04 MST"))
+	for _, r := range checkResults {
+		if r.Status != checkUpdated {
+			continue
+		}
+		old := "(unknown)"
+		if r.OldDigest != "" {
+			old = "`" + shortDigest(r.OldDigest) + "`"
+		}
+		fmt.Fprintf(buf, "- `%s`: %s → `%s`", r.Image, old, shortDigest(r.Digest))
+		if platforms := changedPlatforms(r.Changes); platforms != "" {
+			fmt.Fprintf(buf, " (%s)", platforms)
+		}
+		if r.Metadata != nil && r.Metadata.ReleaseNotes != "" {
+			fmt.Fprintf(buf, " [release notes](%s)", r.Metadata.ReleaseNotes)
+		}
+		buf.WriteString("\n")
+	}
+}
+
+// changedPlatforms returns the summary of the changed platforms, e.g. "added: linux/riscv64; changed: linux/amd64".
+func changedPlatforms(changes *registry.ManifestsDiff) string {
+	if changes == nil {
+		return ""
+	}
+	var parts []string
+	for _, c := range []struct {
+		name  string
+		diffs []*registry.PlatformDiff
+	}{
+		{"added", changes.Added},
+		{"removed", changes.Removed},
+		{"changed", changes.Changed},
+	} {
+		if len(c.diffs) == 0 {
+			continue
+		}
+		platforms := make([]string, 0, len(c.diffs))
+		for _, d := range c.diffs {
+			platforms = append(platforms, d.Platform)
+		}
+		parts = append(parts, c.name+": "+strings.Join(platforms, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// shortDigest returns the digest with the first 12 characters of the hex, e.g. "sha256:4c1d2e3f4a5b".
+func shortDigest(digest string) string {
+	if i := strings.IndexByte(digest, ':'); i >= 0 && len(digest) > i+1+12 {
+		return digest[:i+1+12]
+	}
+	return digest
+}
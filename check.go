@@ -0,0 +1,673 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/config"
+	"github.com/shogo82148/docker-image-update-checker/internal/format"
+	"github.com/shogo82148/docker-image-update-checker/internal/storage"
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+var checkCommand = &command{
+	name:    "check",
+	usage:   "check [options]",
+	summary: "check the updates of the images, and commit the new manifests",
+	run:     runCheck,
+}
+
+// catalogHosts are private registries whose repositories are all tracked.
+var catalogHosts []string
+
+// hubFastPath enables checking the Docker Hub API before the registry API.
+var hubFastPath bool
+
+// addCatalogFlags adds the flags about the catalogs.
+func addCatalogFlags(fs *flag.FlagSet) {
+	fs.Func("catalog", "track the latest tag of all repositories in the registry `host`", func(host string) error {
+		catalogHosts = append(catalogHosts, host)
+		return nil
+	})
+}
+
+// loadCatalogs enumerates the repositories in catalogHosts, and adds them to the targets.
+func loadCatalogs(c *registry.Client) error {
+	if len(catalogHosts) > 0 && (len(selectedGroups) > 0 || tenant != nil) {
+		// the images in the catalogs don't belong to any groups.
+		log.Printf("skip the catalogs because the groups or the tenant are selected")
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	for _, host := range catalogHosts {
+		repos, err := c.Catalog(ctx, host)
+		if err != nil {
+			return fmt.Errorf("failed to get the catalog of %s: %w", host, err)
+		}
+		for _, repo := range repos {
+			image := host + "/" + repo
+			if _, err := registry.ParseReference(image); err != nil {
+				log.Printf("skip the repository in the catalog: %v", err)
+				continue
+			}
+			targets = append(targets, image)
+		}
+	}
+	return nil
+}
+
+// dryRun reports the updates without writing the status files and committing them.
+var dryRun bool
+
+// detailedExitCode makes check exit with exitFailed if any images failed, and exitUpdated if any images are updated.
+// It is opt-in, because the scheduled workflows treat non-zero exit codes as failures.
+var detailedExitCode bool
+
+// the exit codes of check with -detailed-exitcode or -fail-on-error.
+const (
+	exitFailed  exitStatus = 1
+	exitUpdated exitStatus = 2
+
+	// exitMutated is the exit code if any immutable tags have changed.
+	// It is returned regardless of the flags, because it indicates a supply-chain problem.
+	exitMutated exitStatus = 3
+)
+
+// checkConcurrency is the number of images checked concurrently.
+var checkConcurrency int
+
+// the statuses of the images in the check results.
+const (
+	checkUpdated   = "updated"
+	checkUnchanged = "unchanged"
+	checkFailed    = "failed"
+	checkSkipped   = "skipped"
+
+	// checkRemoved is the status of the tags that no longer exist,
+	// the tags that are not found, or the tags that match the tag patterns but are not listed.
+	checkRemoved = "removed"
+
+	// checkDrifted is the status of the pinned images whose tags point to other digests.
+	checkDrifted = "drifted"
+
+	// checkMutated is the status of the immutable tags that have changed.
+	// The stored manifests are kept, so that it is reported until the change is investigated.
+	checkMutated = "mutated"
+
+	// checkPartial is the status of the images that some of the platforms have changed, but the others have not yet.
+	// It is reported only if the image waits for all platforms; otherwise the image is updated and marked as partial.
+	checkPartial = "partial"
+)
+
+// checkResult is the result of checking an image.
+type checkResult struct {
+	Image  string `json:"image"`
+	Status string `json:"status"`
+
+	// Groups are the groups of the image in the config.
+	Groups []string `json:"groups,omitempty"`
+
+	// Metadata is the metadata of the image in the config, e.g. the owner and the release notes.
+	Metadata *config.Metadata `json:"metadata,omitempty"`
+
+	// Digest is the digest of the manifests served by the registry.
+	Digest string `json:"digest,omitempty"`
+
+	// OldDigest is the digest of the stored manifests before the update, if it is known.
+	OldDigest string `json:"oldDigest,omitempty"`
+
+	// PinnedDigest is the digest pinned in the config, if the image is pinned with the expected tag.
+	PinnedDigest string `json:"pinnedDigest,omitempty"`
+
+	// Partial reports whether only some of the platforms have changed, e.g. during staggered rebuilds.
+	Partial bool `json:"partial,omitempty"`
+
+	// Sizes are the total compressed sizes of the layers per platform, if the image is updated.
+	Sizes map[string]int64 `json:"sizes,omitempty"`
+
+	// SizeWarnings are the platforms that grow beyond the threshold.
+	SizeWarnings []string `json:"sizeWarnings,omitempty"`
+
+	// Stale reports whether the image hasn't changed for the stale period.
+	Stale bool `json:"stale,omitempty"`
+
+	// Aliases are the other targets that resolve to the same digest.
+	Aliases []string `json:"aliases,omitempty"`
+
+	// Changes are the changes of the platforms, if the image is updated.
+	Changes *registry.ManifestsDiff `json:"changes,omitempty"`
+
+	Error string `json:"error,omitempty"`
+
+	// Duration is the time taken to get the manifests in seconds.
+	Duration float64 `json:"duration"`
+}
+
+// checkResults are the results of the check run, in the order of the targets.
+var checkResults []*checkResult
+
+func checkUpdates(c *registry.Client) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results := make(map[string]*checkResult, len(targets))
+	images := make([]string, 0, len(targets))
+	for i, ok := range prechecks(ctx, c) {
+		image := targets[i]
+		if ok {
+			images = append(images, image)
+		} else {
+			results[image] = &checkResult{Image: image, Status: checkUnchanged, Groups: targetGroups(image)}
+		}
+	}
+
+	ctx, cancel = context.WithTimeout(ctx, runTimeout(images))
+	defer cancel()
+
+	var manifests []*registry.BatchResult
+	if window := spreadWindow(); window > 0 && len(images) > 0 {
+		log.Printf("getting manifests: %d images in %s", len(images), window)
+		manifests = getManifestsSpread(ctx, c, images, window)
+	} else {
+		log.Printf("getting manifests: %d images", len(images))
+		manifests = getManifests(ctx, c, images)
+	}
+
+	var skipped []string
+	for _, r := range manifests {
+		result := newCheckResult(r)
+		results[r.Image] = result
+		if result.Status == checkSkipped {
+			skipped = append(skipped, r.Image)
+		}
+	}
+	if len(skipped) > 0 {
+		log.Printf("skipped %d images because their registries are unavailable: %s", len(skipped), strings.Join(skipped, ", "))
+	}
+
+	checkResults = make([]*checkResult, 0, len(targets)+len(removedTags))
+	for _, image := range targets {
+		if r, ok := results[image]; ok {
+			checkResults = append(checkResults, r)
+		}
+	}
+	for _, image := range removedTags {
+		checkResults = append(checkResults, &checkResult{Image: image, Status: checkRemoved, Groups: targetGroups(image)})
+	}
+	for _, r := range checkResults {
+		r.Metadata = targetMetadata(r.Image)
+	}
+}
+
+// newCheckResult classifies the manifests of the image got from the registry,
+// and stores them if they are updated.
+func newCheckResult(r *registry.BatchResult) *checkResult {
+	result := &checkResult{Image: r.Image, Groups: targetGroups(r.Image), Duration: r.Duration.Seconds()}
+	if errors.Is(r.Err, registry.ErrCircuitOpen) {
+		result.Status = checkSkipped
+		result.Error = r.Err.Error()
+		return result
+	}
+	if errors.Is(r.Err, registry.ErrNotFound) {
+		// keep the stored manifests as the last known state.
+		log.Printf("WARNING: %s is not found; the tag may be removed", r.Image)
+		result.Status = checkRemoved
+		result.Error = r.Err.Error()
+		return result
+	}
+	if r.Err != nil {
+		log.Printf("failed to get %s: %v", r.Image, r.Err)
+		result.Status = checkFailed
+		result.Error = r.Err.Error()
+		return result
+	}
+	result.Status = checkUnchanged
+	result.Digest = r.Digest
+	if pinned := pinnedDigest(r.Image); pinned != "" {
+		result.PinnedDigest = pinned
+		if r.Digest != pinned {
+			// keep the stored manifests of the pinned digest.
+			log.Printf("WARNING: %s has drifted: the tag points to %s", r.Image, r.Digest)
+			result.Status = checkDrifted
+			return result
+		}
+	}
+	platforms := targetPlatforms(r.Image)
+	old := registry.FilterPlatforms(status[r.Image], platforms)
+	m := registry.FilterPlatforms(r.Manifests, platforms)
+	changes := registry.DiffManifests(old, m)
+	if img := targetConfigs[r.Image]; img != nil && img.Immutable && old != nil && !changes.Empty() {
+		log.Printf("ALERT: the immutable tag %s has changed to %s; it may indicate a supply-chain problem", r.Image, r.Digest)
+		result.Status = checkMutated
+		result.Error = "the immutable tag has changed"
+		result.Changes = changes
+		return result
+	}
+	if unchanged := registry.UnchangedPlatforms(old, m); len(changes.Changed) > 0 && len(unchanged) > 0 {
+		log.Printf("WARNING: %s is partially updated: %d platforms changed, but %s not yet", r.Image, len(changes.Changed), strings.Join(unchanged, ", "))
+		result.Partial = true
+		if img := targetConfigs[r.Image]; img != nil && img.WaitAllPlatforms {
+			// keep the stored manifests until all platforms change.
+			result.Status = checkPartial
+			result.Changes = changes
+			return result
+		}
+	}
+	if checkUpdate(r.Image, r.Manifests) {
+		fetched[r.Image] = fetchedDocument(r.Digest, r.MediaType, r.Raw, r.FetchedAt)
+		result.Status = checkUpdated
+		result.Changes = changes
+	}
+	return result
+}
+
+// printCheckResults prints the check results as a table.
+func printCheckResults(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "IMAGE\tSTATUS\tERROR")
+	for _, r := range checkResults {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Image, r.Status, r.Error)
+	}
+	return tw.Flush()
+}
+
+// requestGroup is the key to group the images that have the same request options.
+type requestGroup struct {
+	accept  string
+	timeout time.Duration
+}
+
+// groupRequests groups the images by the request options in the config, because GetManifestsBatch applies the same options to all images.
+// The groups are in the order of their first images.
+func groupRequests(images []string) ([]requestGroup, map[requestGroup][]string) {
+	var keys []requestGroup
+	groups := map[requestGroup][]string{}
+	for _, image := range images {
+		key := requestGroup{timeout: checkTimeout(image)}
+		if img := targetConfigs[image]; img != nil {
+			key.accept = strings.Join(img.Accept, ", ")
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], image)
+	}
+	return keys, groups
+}
+
+// options returns the request options of the group.
+func (key requestGroup) options() []registry.RequestOption {
+	opts := []registry.RequestOption{registry.WithRequestTimeout(key.timeout)}
+	if key.accept != "" {
+		opts = append(opts, registry.WithRequestAccept(key.accept))
+	}
+	return opts
+}
+
+// getManifests gets the manifests of the images.
+func getManifests(ctx context.Context, c *registry.Client, images []string) []*registry.BatchResult {
+	keys, groups := groupRequests(images)
+	var results []*registry.BatchResult
+	for _, key := range keys {
+		images := groups[key]
+		batch := c.GetManifestsBatch(ctx, fetchNames(images), checkConcurrency, key.options()...)
+		for i, r := range batch {
+			r.Image = images[i]
+		}
+		results = append(results, batch...)
+	}
+	return results
+}
+
+// logStats logs the statistics of the requests per registry, to find the registries that slow down the run.
+func logStats(c *registry.Client) {
+	stats := c.Stats()
+	hosts := make([]string, 0, len(stats))
+	for host := range stats {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		s := stats[host]
+		log.Printf("stats: %s: %d requests, error rate %.1f%%, mean latency %s, max latency %s",
+			host, s.Requests, s.ErrorRate()*100, s.MeanLatency().Round(time.Millisecond), s.MaxLatency.Round(time.Millisecond))
+	}
+}
+
+// prechecks runs precheck for the targets concurrently.
+func prechecks(ctx context.Context, c *registry.Client) []bool {
+	ret := make([]bool, len(targets))
+	sem := make(chan struct{}, checkConcurrency)
+	var wg sync.WaitGroup
+	for i, image := range targets {
+		i, image := i, image
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			ret[i] = precheck(ctx, c, image)
+		}()
+	}
+	wg.Wait()
+	return ret
+}
+
+// precheck checks the metadata APIs of the registry services,
+// and reports whether the manifests of the image need to be fetched.
+func precheck(ctx context.Context, c *registry.Client, image string) bool {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout(image))
+	defer cancel()
+
+	if hubFastPath && status[image] != nil && pinnedDigest(image) == "" {
+		if host, _, _ := registry.GetRepository(image); host == "registry-1.docker.io" {
+			tag, err := c.GetHubTag(ctx, image)
+			if err != nil {
+				log.Printf("failed to get the tag from Docker Hub API %s: %v", image, err)
+			} else if tag.Matches(storedDigest(image), status[image]) {
+				log.Printf("not changed since %s: %s", tag.LastUpdated.Format(time.RFC3339), image)
+				return false
+			}
+		}
+	}
+
+	if host, _, _ := registry.GetRepository(image); host == "quay.io" {
+		tag, err := c.GetQuayTag(ctx, image)
+		if err != nil {
+			log.Printf("failed to get the tag from Quay API %s: %v", image, err)
+			return true
+		}
+		if !tag.Expiration.IsZero() {
+			log.Printf("WARNING: %s is scheduled to expire at %s", image, tag.Expiration.Format(time.RFC3339))
+		}
+		// the Quay API is already called for the expiration, so its digest is compared without the flag unlike Docker Hub.
+		if status[image] != nil && pinnedDigest(image) == "" && tag.ManifestDigest != "" && tag.ManifestDigest == storedDigest(image) {
+			log.Printf("not changed since %s: %s", tag.LastModified.Format(time.RFC3339), image)
+			return false
+		}
+	}
+	return true
+}
+
+// checkUpdate stores the manifests of the image, and reports whether they are updated.
+// The changes of the platforms that are not tracked are ignored, and the stored manifests are kept,
+// not to trigger the downstream builds.
+func checkUpdate(image string, m *registry.Manifests) bool {
+	if sameManifests(image, status[image], m) {
+		return false
+	}
+	if md := targetMetadata(image); md != nil && md.ReleaseNotes != "" {
+		log.Printf("updated: %s, release notes: %s", image, md.ReleaseNotes)
+	} else {
+		log.Printf("updated: %s", image)
+	}
+	updated[image] = struct{}{}
+	status[image] = m
+	return true
+}
+
+// disableAfter is the number of the consecutive checks finding the tag removed, after which the image is disabled.
+var disableAfter int
+
+// disabledImages are the images disabled in this run.
+var disabledImages []string
+
+// recordMisses counts the consecutive misses of the removed tags,
+// and disables the images in the config after disableAfter misses.
+func recordMisses() {
+	for _, r := range checkResults {
+		if r.Status != checkRemoved || contains(removedTags, r.Image) {
+			// the tags removed from the patterns are not in the config.
+			continue
+		}
+		n := markMissed(r.Image)
+		if disableAfter <= 0 || n < disableAfter {
+			continue
+		}
+		if i := cfg.Find(r.Image); i >= 0 && !cfg.Images[i].Disabled {
+			log.Printf("disable %s after %d consecutive misses", r.Image, n)
+			cfg.Images[i].Disabled = true
+			disabledImages = append(disabledImages, r.Image)
+		}
+	}
+}
+
+// willCommit reports whether the run makes a commit.
+// The last checked times are committed only if they are used by the check intervals,
+// not to make a commit in every run.
+func willCommit() bool {
+	return len(updated) > 0 || len(disabledImages) > 0 || len(prunedImages) > 0 ||
+		indexMustCommit || auditLogged || (indexChanged && hasCheckIntervals())
+}
+
+func commitUpdates() error {
+	if !willCommit() {
+		return nil
+	}
+	msg, err := newRunCommitData().message()
+	if err != nil {
+		return err
+	}
+	return store.Commit(msg)
+}
+
+// updatedImages returns the updated images in the sorted order.
+func updatedImages() []string {
+	updates := make([]string, 0, len(updated))
+	for image := range updated {
+		updates = append(updates, image)
+	}
+	sort.Strings(updates)
+	return updates
+}
+
+func runCheck(cmd *command, args []string) error {
+	startedAt := time.Now()
+	log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds)
+	fs := newFlagSet(cmd)
+	addConfigFlags(fs)
+	addGroupFlags(fs)
+	addTenantFlags(fs)
+	addGitFlags(fs)
+	addStoreFlags(fs)
+	addClientFlags(fs)
+	addCatalogFlags(fs)
+	addFormatFlags(fs, "")
+	fs.StringVar(&matrixFile, "matrix-file", "", "write the updated images as the build matrix of GitHub Actions into the `file` (\"-\" for stdout)")
+	fs.StringVar(&summaryFile, "summary-file", "", "write the summary of the run into the JSON `file`")
+	fs.BoolVar(&dryRun, "dry-run", false, "fetch the manifests and report the updates, without writing the state or calling git; the reports and the outputs of GitHub Actions are still written")
+	fs.BoolVar(&failOnError, "fail-on-error", true, "exit with 1 if any images failed, after saving the updates of the other images")
+	fs.StringVar(&failureReportFile, "failure-report", "", "write the errors of the failed images into the `file`")
+	fs.BoolVar(&detailedExitCode, "detailed-exitcode", false, "exit with 1 if any images failed, 2 if any images are updated, and 0 otherwise")
+	fs.DurationVar(&globalRunTimeout, "run-timeout", 0, "the timeout for checking all images (default the sum of the timeouts of the images, or the runTimeout in the config)")
+	fs.DurationVar(&globalSpread, "spread", 0, "spread the checks across the `window` with jitter, instead of firing them back-to-back")
+	fs.IntVar(&checkConcurrency, "concurrency", 8, "check at most `n` images concurrently")
+	fs.BoolVar(&discoverNewTags, "discover", false, "add the new release tags of the tracked images to the config file, and check them")
+	fs.Float64Var(&sizeThreshold, "size-threshold", 0, "warn if the size of an updated image grows by more than `percent` (0 to disable)")
+	fs.IntVar(&disableAfter, "disable-after", 0, "disable the image in the config after its tag is not found in `n` consecutive checks (0 to never disable)")
+	fs.BoolVar(&hubFastPath, "hub-fast-path", false, "check the Docker Hub API before the registry API, to save the pull rate limit")
+	addReleaseFlags(fs)
+	fs.BoolVar(&pruneOrphans, "prune", false, "delete the stored manifests of the images that are no longer tracked, in the commit of the run")
+	fs.DurationVar(&watchInterval, "watch", 0, "keep running and check every `interval`, reloading the config on SIGHUP or when the file changes (0 to check once)")
+	fs.Parse(args)
+	if checkConcurrency <= 0 {
+		checkConcurrency = 1
+	}
+
+	if err := loadConfig(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if watchInterval > 0 {
+		return watchChecks()
+	}
+	return checkOnce(startedAt)
+}
+
+// checkOnce checks the targets of the loaded config, and saves the updates.
+func checkOnce(startedAt time.Time) error {
+	runID = newRunID(startedAt)
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	if err := login(c); err != nil {
+		return err
+	}
+	if err := loadCatalogs(c); err != nil {
+		return err
+	}
+	if discoverNewTags {
+		if err := discoverAndTrack(c); err != nil {
+			return fmt.Errorf("failed to discover the new tags: %w", err)
+		}
+	}
+	expandPatterns(c)
+
+	dedupeTargets()
+
+	updated = map[string]struct{}{}
+	fetched = map[string]*storage.Document{}
+	if !dryRun {
+		if err := migrateStatusFiles(); err != nil {
+			return fmt.Errorf("failed to migrate status: %w", err)
+		}
+	}
+	if err := loadStatus(); err != nil {
+		return fmt.Errorf("failed to load status: %w", err)
+	}
+	if err := loadStatusIndex(); err != nil {
+		return fmt.Errorf("failed to load status index: %w", err)
+	}
+	if pruneOrphans {
+		// before the targets are narrowed down to the due ones.
+		if err := pruneOrphanedStatus(); err != nil {
+			return fmt.Errorf("failed to prune status: %w", err)
+		}
+	}
+	if notDue := dueTargets(startedAt); len(notDue) > 0 {
+		log.Printf("skipped %d images whose check intervals haven't elapsed", len(notDue))
+	}
+
+	checkUpdates(c)
+	for _, r := range checkResults {
+		if r.Status == checkUpdated || r.Status == checkUnchanged || r.Status == checkDrifted || r.Status == checkPartial {
+			markChecked(r.Image, startedAt)
+		}
+		if r.Status == checkUpdated {
+			r.OldDigest = imageStatusOf(r.Image).Digest
+			markChanged(r.Image, startedAt, r.Digest)
+		} else if r.Status == checkUnchanged {
+			markDigest(r.Image, r.Digest)
+		}
+		if r.Status == checkFailed || r.Status == checkSkipped {
+			markFailed(r.Image, r.Error)
+		}
+	}
+	findStale(startedAt)
+	recordMisses()
+	recordSizes(c)
+	findAliases()
+	for _, r := range checkResults {
+		if r.Digest != "" {
+			markAliases(r.Image, r.Aliases)
+		}
+	}
+	logStats(c)
+
+	if dryRun {
+		log.Printf("dry run: %d images would be updated", len(updated))
+	} else {
+		if err := saveStatus(); err != nil {
+			return fmt.Errorf("failed to save status: %w", err)
+		}
+		if err := saveChildren(c); err != nil {
+			return fmt.Errorf("failed to save the child manifests: %w", err)
+		}
+		if err := writeChangelog(startedAt); err != nil {
+			return fmt.Errorf("failed to write the changelog: %w", err)
+		}
+		if err := writeAuditLog(startedAt); err != nil {
+			return fmt.Errorf("failed to write the audit log: %w", err)
+		}
+		if len(disabledImages) > 0 {
+			if err := saveConfig(); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+		}
+		if willCommit() {
+			if err := saveStatusIndex(); err != nil {
+				return fmt.Errorf("failed to save status index: %w", err)
+			}
+		}
+		if err := commitUpdates(); err != nil {
+			return fmt.Errorf("failed to commit: %w", err)
+		}
+		if err := releaseUpdates(); err != nil {
+			// the updates are already committed, so the failure of the release doesn't fail the run.
+			log.Printf("WARNING: failed to release the updates: %v", err)
+		}
+		if err := notify(startedAt); err != nil {
+			// the updates are already committed, so the failure of the notification doesn't fail the run.
+			log.Printf("WARNING: failed to notify: %v", err)
+		}
+	}
+
+	// the results are written only if they are requested, because check has written only the logs.
+	if outputFormat != "" {
+		if err := format.Write(os.Stdout, outputFormat, checkResults, printCheckResults); err != nil {
+			return fmt.Errorf("failed to write the results: %w", err)
+		}
+	}
+
+	if summaryFile != "" {
+		if err := writeSummary(summaryFile, startedAt); err != nil {
+			return fmt.Errorf("failed to write the summary: %w", err)
+		}
+	}
+
+	if matrixFile != "" {
+		if err := writeMatrixFile(); err != nil {
+			return fmt.Errorf("failed to write the matrix: %w", err)
+		}
+	}
+	if err := writeGitHubOutput(); err != nil {
+		return fmt.Errorf("failed to write the outputs: %w", err)
+	}
+	if err := writeStepSummary(); err != nil {
+		return fmt.Errorf("failed to write the job summary: %w", err)
+	}
+
+	if err := reportFailures(); err != nil {
+		return fmt.Errorf("failed to write the failure report: %w", err)
+	}
+
+	return exitError()
+}
+
+// exitError returns the exitStatus of the check results, or nil if check exits successfully.
+func exitError() error {
+	if mutated := imagesWithStatus(checkMutated); len(mutated) > 0 {
+		log.Printf("ALERT: the immutable tags have changed: %s", strings.Join(mutated, ", "))
+		return exitMutated
+	}
+	failed := len(failedResults()) > 0
+	if failed && (failOnError || detailedExitCode) {
+		return exitFailed
+	}
+	if detailedExitCode && (len(updated) > 0 || len(imagesWithStatus(checkDrifted)) > 0) {
+		return exitUpdated
+	}
+	return nil
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/config"
+	"github.com/shogo82148/docker-image-update-checker/internal/storage"
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// testIndex returns the index of linux/amd64 and linux/arm64 with the digests of their manifests.
+func testIndex(amd64, arm64 string) *registry.Manifests {
+	return &registry.Manifests{
+		SchemaVersion: 2,
+		MediaType:     "application/vnd.oci.image.index.v1+json",
+		Manifests: []*registry.Manifest{
+			{
+				MediaType: "application/vnd.oci.image.manifest.v1+json",
+				Digest:    amd64,
+				Size:      1024,
+				Platform:  &registry.Platform{OS: "linux", Architecture: "amd64"},
+			},
+			{
+				MediaType: "application/vnd.oci.image.manifest.v1+json",
+				Digest:    arm64,
+				Size:      1024,
+				Platform:  &registry.Platform{OS: "linux", Architecture: "arm64"},
+			},
+		},
+	}
+}
+
+func TestNewCheckResult(t *testing.T) {
+	const (
+		pinned = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+		moved  = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	)
+	tests := []struct {
+		name    string
+		image   *config.Image
+		stored  *registry.Manifests
+		result  *registry.BatchResult
+		status  string
+		partial bool
+		updated bool
+	}{
+		{
+			name:   "first check",
+			image:  &config.Image{Image: "alpine:3.17"},
+			result: &registry.BatchResult{Manifests: testIndex("sha256:a1", "sha256:b1")},
+			status: checkUpdated, updated: true,
+		},
+		{
+			name:   "unchanged",
+			image:  &config.Image{Image: "alpine:3.17"},
+			stored: testIndex("sha256:a1", "sha256:b1"),
+			result: &registry.BatchResult{Manifests: testIndex("sha256:a1", "sha256:b1")},
+			status: checkUnchanged,
+		},
+		{
+			name:   "updated",
+			image:  &config.Image{Image: "alpine:3.17"},
+			stored: testIndex("sha256:a1", "sha256:b1"),
+			result: &registry.BatchResult{Manifests: testIndex("sha256:a2", "sha256:b2")},
+			status: checkUpdated, updated: true,
+		},
+		{
+			name:   "untracked platform changed",
+			image:  &config.Image{Image: "alpine:3.17", Platforms: []string{"linux/amd64"}},
+			stored: testIndex("sha256:a1", "sha256:b1"),
+			result: &registry.BatchResult{Manifests: testIndex("sha256:a1", "sha256:b2")},
+			status: checkUnchanged,
+		},
+		{
+			name:   "partially updated",
+			image:  &config.Image{Image: "alpine:3.17"},
+			stored: testIndex("sha256:a1", "sha256:b1"),
+			result: &registry.BatchResult{Manifests: testIndex("sha256:a2", "sha256:b1")},
+			status: checkUpdated, partial: true, updated: true,
+		},
+		{
+			name:   "waiting for all platforms",
+			image:  &config.Image{Image: "alpine:3.17", WaitAllPlatforms: true},
+			stored: testIndex("sha256:a1", "sha256:b1"),
+			result: &registry.BatchResult{Manifests: testIndex("sha256:a2", "sha256:b1")},
+			status: checkPartial, partial: true,
+		},
+		{
+			name:   "immutable tag changed",
+			image:  &config.Image{Image: "alpine:3.17.0", Immutable: true},
+			stored: testIndex("sha256:a1", "sha256:b1"),
+			result: &registry.BatchResult{Manifests: testIndex("sha256:a2", "sha256:b2")},
+			status: checkMutated,
+		},
+		{
+			name:   "immutable tag first check",
+			image:  &config.Image{Image: "alpine:3.17.0", Immutable: true},
+			result: &registry.BatchResult{Manifests: testIndex("sha256:a1", "sha256:b1")},
+			status: checkUpdated, updated: true,
+		},
+		{
+			name:   "pinned",
+			image:  &config.Image{Image: "alpine:3.17@" + pinned},
+			stored: testIndex("sha256:a1", "sha256:b1"),
+			result: &registry.BatchResult{Digest: pinned, Manifests: testIndex("sha256:a1", "sha256:b1")},
+			status: checkUnchanged,
+		},
+		{
+			name:   "pinned drifted",
+			image:  &config.Image{Image: "alpine:3.17@" + pinned},
+			stored: testIndex("sha256:a1", "sha256:b1"),
+			result: &registry.BatchResult{Digest: moved, Manifests: testIndex("sha256:a2", "sha256:b2")},
+			status: checkDrifted,
+		},
+		{
+			name:   "not found",
+			image:  &config.Image{Image: "alpine:3.17"},
+			stored: testIndex("sha256:a1", "sha256:b1"),
+			result: &registry.BatchResult{Err: fmt.Errorf("alpine:3.17: %w", registry.ErrNotFound)},
+			status: checkRemoved,
+		},
+		{
+			name:   "failed",
+			image:  &config.Image{Image: "alpine:3.17"},
+			result: &registry.BatchResult{Err: errors.New("unexpected status code: 500")},
+			status: checkFailed,
+		},
+		{
+			name:   "registry unavailable",
+			image:  &config.Image{Image: "alpine:3.17"},
+			result: &registry.BatchResult{Err: fmt.Errorf("registry-1.docker.io: %w", registry.ErrCircuitOpen)},
+			status: checkSkipped,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			image := tt.image.Image
+			targetConfigs = map[string]*config.Image{image: tt.image}
+			status = map[string]*registry.Manifests{}
+			if tt.stored != nil {
+				status[image] = tt.stored
+			}
+			updated = map[string]struct{}{}
+			fetched = map[string]*storage.Document{}
+			t.Cleanup(func() { targetConfigs, status, updated, fetched = nil, nil, nil, nil })
+
+			tt.result.Image = image
+			r := newCheckResult(tt.result)
+			if r.Status != tt.status {
+				t.Errorf("status = %q, want %q", r.Status, tt.status)
+			}
+			if r.Partial != tt.partial {
+				t.Errorf("partial = %v, want %v", r.Partial, tt.partial)
+			}
+			if _, ok := updated[image]; ok != tt.updated {
+				t.Errorf("updated = %v, want %v", ok, tt.updated)
+			}
+			// the stored manifests are replaced only by the updates.
+			want := tt.stored
+			if tt.updated {
+				want = tt.result.Manifests
+			}
+			if status[image] != want {
+				t.Error("the stored manifests are not the expected ones")
+			}
+			if tt.result.Err != nil && r.Error == "" {
+				t.Error("the error is not reported")
+			}
+		})
+	}
+}
+
+// newTestClient returns the registry client that sends all the requests to the handler.
+func newTestClient(t *testing.T, handler http.Handler) *registry.Client {
+	t.Helper()
+	useTestTransport(t, handler)
+	return registry.New()
+}
+
+// useTestTransport makes the registry clients created during the test send all the requests to the handler.
+func useTestTransport(t *testing.T, handler http.Handler) {
+	t.Helper()
+	ts := httptest.NewTLSServer(handler)
+	t.Cleanup(ts.Close)
+
+	transport := ts.Client().Transport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, network, ts.Listener.Addr().String())
+	}
+	// the certificate of the test server is valid for example.com.
+	transport.TLSClientConfig.ServerName = "example.com"
+
+	// the clients clone the default transport.
+	old := http.DefaultTransport
+	http.DefaultTransport = transport
+	t.Cleanup(func() { http.DefaultTransport = old })
+}
+
+func TestPrecheckQuay(t *testing.T) {
+	const (
+		image  = "quay.io/foo/bar:v1"
+		stored = "sha256:f71a5f071694a785e064f05fed657bf8277f1b2113a8ed70c90ad486d6ee54dc"
+		moved  = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	)
+	tests := []struct {
+		name   string
+		stored string
+		tag    string
+		want   bool
+	}{
+		{
+			name:   "unchanged",
+			stored: stored,
+			tag:    stored,
+			want:   false,
+		},
+		{
+			name:   "updated",
+			stored: stored,
+			tag:    moved,
+			want:   true,
+		},
+		{
+			name:   "digest not recorded",
+			stored: "",
+			tag:    stored,
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/repository/foo/bar/tag/" || r.URL.Query().Get("specificTag") != "v1" {
+					// the manifests must not be fetched in precheck.
+					t.Errorf("unexpected request: %s", r.URL)
+					http.NotFound(w, r)
+					return
+				}
+				fmt.Fprintf(w, `{"tags": [{"name": "v1", "manifest_digest": %q, "last_modified": "Mon, 02 Jan 2023 15:04:05 -0000"}]}`, tt.tag)
+			}))
+			useConfig(t, &config.Config{Images: []*config.Image{{Image: image}}})
+			status = map[string]*registry.Manifests{image: testIndex("sha256:a1", "sha256:b1")}
+			index = &statusIndex{Images: map[string]*imageStatus{image: {Digest: tt.stored}}}
+			t.Cleanup(func() { status, index = nil, nil })
+
+			if got := precheck(context.Background(), c, image); got != tt.want {
+				t.Errorf("precheck = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// snapshotFiles returns the contents of the files in the working directory, except for the ones of git.
+func snapshotFiles(t *testing.T) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[path] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestCheckOnceDryRun(t *testing.T) {
+	initRepo(t)
+	useTestTransport(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/foo/manifests/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+		w.Write([]byte(`{
+			"schemaVersion": 2,
+			"mediaType": "application/vnd.oci.image.manifest.v1+json",
+			"config": {"mediaType": "application/vnd.oci.image.config.v1+json", "size": 2, "digest": "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"},
+			"layers": []
+		}`))
+	}))
+	useConfig(t, &config.Config{
+		State:  &config.State{Dir: "images", Changelog: "CHANGELOG.md", AuditLog: "audit.jsonl"},
+		Images: []*config.Image{{Image: "registry.example.com/foo:latest"}},
+	})
+	oldDryRun, oldPrune, oldOutput, oldConcurrency := dryRun, pruneOrphans, outputFormat, checkConcurrency
+	t.Cleanup(func() {
+		dryRun, pruneOrphans, outputFormat, checkConcurrency = oldDryRun, oldPrune, oldOutput, oldConcurrency
+		resetRun()
+		status, index, updated, fetched = nil, nil, nil, nil
+	})
+	dryRun, pruneOrphans, outputFormat, checkConcurrency = true, true, "", 1
+	t.Setenv("GITHUB_OUTPUT", "")
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+
+	// the stored manifests of foo are updated, and the ones of bar are pruned in a real run.
+	writeStatusFiles(t, "registry.example.com/foo:latest", "registry.example.com/bar:latest")
+	writeFile(t, statusIndexFile, `{"images": {}}`+"\n")
+	runGit(t, "add", "--all")
+	runGit(t, "commit", "-q", "-m", "add the status files")
+	head := runGit(t, "rev-parse", "HEAD")
+	before := snapshotFiles(t)
+
+	if err := checkOnce(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := updated["registry.example.com/foo:latest"]; !ok {
+		t.Error("want registry.example.com/foo:latest updated")
+	}
+	if !reflect.DeepEqual(prunedImages, []string{"registry.example.com/bar:latest"}) {
+		t.Errorf("want registry.example.com/bar:latest pruned, got %v", prunedImages)
+	}
+
+	if after := snapshotFiles(t); !reflect.DeepEqual(after, before) {
+		t.Errorf("the files are changed:\nbefore: %v\nafter: %v", before, after)
+	}
+	if got := runGit(t, "rev-parse", "HEAD"); got != head {
+		t.Errorf("HEAD = %s, want %s", got, head)
+	}
+}
+
+func TestExitError(t *testing.T) {
+	tests := []struct {
+		name     string
+		results  []*checkResult
+		updated  []string
+		failOn   bool
+		detailed bool
+		want     error
+	}{
+		{
+			name:    "no flags",
+			results: []*checkResult{{Image: "a", Status: checkFailed}, {Image: "b", Status: checkUpdated}},
+			updated: []string{"b"},
+			want:    nil,
+		},
+		{
+			name:    "fail-on-error with failures",
+			results: []*checkResult{{Image: "a", Status: checkFailed}},
+			failOn:  true,
+			want:    exitFailed,
+		},
+		{
+			name:    "fail-on-error with skipped images",
+			results: []*checkResult{{Image: "a", Status: checkSkipped}},
+			failOn:  true,
+			want:    exitFailed,
+		},
+		{
+			name:    "fail-on-error without failures",
+			results: []*checkResult{{Image: "a", Status: checkUpdated}},
+			updated: []string{"a"},
+			failOn:  true,
+			want:    nil,
+		},
+		{
+			name:     "detailed-exitcode with failures and updates",
+			results:  []*checkResult{{Image: "a", Status: checkFailed}, {Image: "b", Status: checkUpdated}},
+			updated:  []string{"b"},
+			detailed: true,
+			want:     exitFailed,
+		},
+		{
+			name:     "detailed-exitcode with updates",
+			results:  []*checkResult{{Image: "a", Status: checkUnchanged}, {Image: "b", Status: checkUpdated}},
+			updated:  []string{"b"},
+			detailed: true,
+			want:     exitUpdated,
+		},
+		{
+			name:     "detailed-exitcode with drifted images",
+			results:  []*checkResult{{Image: "a", Status: checkDrifted}},
+			detailed: true,
+			want:     exitUpdated,
+		},
+		{
+			name:     "detailed-exitcode without changes",
+			results:  []*checkResult{{Image: "a", Status: checkUnchanged}},
+			detailed: true,
+			want:     nil,
+		},
+		{
+			name:    "mutated without flags",
+			results: []*checkResult{{Image: "a", Status: checkMutated}},
+			want:    exitMutated,
+		},
+		{
+			name:     "mutated wins over the other statuses",
+			results:  []*checkResult{{Image: "a", Status: checkFailed}, {Image: "b", Status: checkUpdated}, {Image: "c", Status: checkMutated}},
+			updated:  []string{"b"},
+			failOn:   true,
+			detailed: true,
+			want:     exitMutated,
+		},
+	}
+	oldFailOn, oldDetailed := failOnError, detailedExitCode
+	t.Cleanup(func() {
+		failOnError, detailedExitCode = oldFailOn, oldDetailed
+		checkResults, updated = nil, nil
+	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failOnError, detailedExitCode = tt.failOn, tt.detailed
+			checkResults = tt.results
+			updated = map[string]struct{}{}
+			for _, image := range tt.updated {
+				updated[image] = struct{}{}
+			}
+			if got := exitError(); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
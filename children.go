@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/storage"
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// childrenSuffix is the suffix of the directory of the child manifests and the image configs,
+// e.g. "alpine/3.17+platforms/linux-amd64.json".
+const childrenSuffix = "+platforms"
+
+// childrenDir returns the directory of the child manifests and the image configs of the status file.
+func childrenDir(path string) string {
+	return sidecarDir(path, childrenSuffix)
+}
+
+// storesChildren reports whether the child manifests or the image configs are stored.
+func storesChildren() bool {
+	return cfg != nil && cfg.State != nil && (cfg.State.ChildManifests || cfg.State.ImageConfigs)
+}
+
+// saveChildren fetches the child manifests and the image configs of the updated images,
+// and stores them next to their status files.
+// The failures of the registries are logged, and the old files are kept.
+func saveChildren(c *registry.Client) error {
+	if !storesChildren() || len(updated) == 0 {
+		return nil
+	}
+	if _, ok := store.(fileStore); !ok {
+		log.Printf("WARNING: the child manifests and the image configs are stored only in the files in the repository")
+		return nil
+	}
+
+	images := make([]string, 0, len(updated))
+	for image := range updated {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	for _, image := range images {
+		files, err := fetchChildren(c, image)
+		if err != nil {
+			log.Printf("failed to get the child manifests of %s: %v", image, err)
+			continue
+		}
+		if err := writeChildren(image, files); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchChildren returns the contents of the files of the child manifests and the image configs, keyed by the file names.
+func fetchChildren(c *registry.Client, image string) (map[string][]byte, error) {
+	ref, err := registry.ParseReference(fetchName(image))
+	if err != nil {
+		return nil, err
+	}
+	m := registry.FilterPlatforms(status[image], targetPlatforms(image))
+	if m == nil {
+		return nil, nil
+	}
+
+	files := map[string][]byte{}
+	children := map[string]*registry.Manifests{}
+	if len(m.Manifests) == 0 {
+		// the single platform image is the child of itself.
+		children["image"] = m
+	}
+	for _, manifest := range m.Manifests {
+		if manifest.IsAttestation() {
+			continue
+		}
+		name := platformFileName(manifest.Platform)
+		if _, ok := children[name]; ok {
+			// the same platform appears twice.
+			encoded := manifest.Digest[strings.IndexByte(manifest.Digest, ':')+1:]
+			if len(encoded) > 12 {
+				encoded = encoded[:12]
+			}
+			name += "-" + encoded
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), checkTimeout(image))
+		resp, err := c.GetManifestsResponse(ctx, ref.Host+"/"+ref.Repository+"@"+manifest.Digest)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		children[name] = resp.Manifests
+		if cfg.State.ChildManifests {
+			// no fetchedAt, because the child manifests are immutable and are fetched again in every update.
+			doc := &storage.Document{Digest: resp.Digest, MediaType: resp.MediaType, Manifest: resp.Manifests}
+			data, err := doc.MarshalIndent("", "    ")
+			if err != nil {
+				return nil, err
+			}
+			files[name+".json"] = data
+		}
+	}
+
+	if cfg.State.ImageConfigs {
+		for name, child := range children {
+			if child.Config == nil {
+				// e.g. the manifests of the schema version 1 have no configs.
+				continue
+			}
+			data, err := fetchBlob(c, image, child.Config.Digest)
+			if err != nil {
+				return nil, err
+			}
+			files[name+".config.json"] = data
+		}
+	}
+	return files, nil
+}
+
+// fetchBlob downloads the blob of the image.
+func fetchBlob(c *registry.Client, image, digest string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout(image))
+	defer cancel()
+	blob, err := c.GetBlob(ctx, fetchName(image), digest)
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Close()
+	return io.ReadAll(blob)
+}
+
+// platformFileName returns the name of the files of the platform, e.g. "linux-arm64-v8" or "windows-amd64-10.0.20348.2113".
+func platformFileName(p *registry.Platform) string {
+	if p == nil {
+		return "unknown"
+	}
+	parts := []string{p.OS, p.Architecture}
+	if p.Variant != "" {
+		parts = append(parts, p.Variant)
+	}
+	if p.OSVersion != "" {
+		parts = append(parts, p.OSVersion)
+	}
+	return strings.Map(func(r rune) rune {
+		if 'a' <= r && r <= 'z' || '0' <= r && r <= '9' || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(strings.Join(parts, "-")))
+}
+
+// writeChildren replaces the files of the child manifests and the image configs of the image.
+func writeChildren(image string, files map[string][]byte) error {
+	path, err := statusFile(image)
+	if err != nil {
+		return err
+	}
+	old, err := sidecarFiles(path, childrenSuffix)
+	if err != nil {
+		return err
+	}
+	dir := childrenDir(path)
+	for _, name := range old {
+		if _, ok := files[filepath.Base(name)]; ok {
+			continue
+		}
+		// e.g. the platform is removed from the image.
+		recordChange(name)
+		if err := os.Remove(name); err != nil {
+			return err
+		}
+	}
+	if len(files) == 0 {
+		removeEmptyDirs(dir)
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for name, data := range files {
+		p := filepath.Join(dir, name)
+		recordChange(p)
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", p, err)
+		}
+	}
+	return nil
+}
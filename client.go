@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// acceptMediaTypes overrides the Accept header of the manifest requests.
+var acceptMediaTypes []string
+
+// circuitBreakerThreshold is the number of consecutive failures that stops checking the rest of the images on the registry.
+var circuitBreakerThreshold int
+
+// mirrors are the mirrors of the registries, e.g. {"docker.io": ["mirror.gcr.io"]}.
+var mirrors = map[string][]string{}
+
+// hostConcurrency is the maximum number of concurrent requests to each registry.
+var hostConcurrency int
+
+// debugRequests enables logging the requests to the registries.
+var debugRequests bool
+
+// tokenCachePath is the path to the file that persists the bearer tokens across runs.
+var tokenCachePath string
+
+// newClient returns a new registry client with the credential providers.
+func newClient() (*registry.Client, error) {
+	opts := []registry.Option{
+		registry.WithUserAgent(userAgent()),
+
+		// e.g. DIUC_AUTH_GHCR_IO_USERNAME and DIUC_AUTH_GHCR_IO_PASSWORD for ghcr.io
+		registry.WithCredentialProvider(registry.NewEnvCredentials("DIUC_AUTH_")),
+	}
+
+	// credentials stored by "diuc login"
+	stored, err := loadAuthFile()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, registry.WithCredentialProvider(stored))
+
+	// credentials in the format of Kubernetes imagePullSecrets (.dockerconfigjson)
+	if data := os.Getenv("DIUC_DOCKERCONFIGJSON"); data != "" {
+		config, err := registry.ParseDockerConfig([]byte(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse DIUC_DOCKERCONFIGJSON: %w", err)
+		}
+		opts = append(opts, registry.WithCredentialProvider(config))
+	}
+	if path := os.Getenv("DIUC_DOCKERCONFIGJSON_FILE"); path != "" {
+		config, err := registry.LoadDockerConfigFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load DIUC_DOCKERCONFIGJSON_FILE: %w", err)
+		}
+		opts = append(opts, registry.WithCredentialProvider(config))
+	}
+
+	// credentials of podman, skopeo and buildah
+	auth, err := registry.LoadContainersAuth()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, registry.WithCredentialProvider(auth))
+
+	opts = append(opts, registry.WithCredentialProvider(registry.NewECRCredentialProvider()))
+
+	// GITHUB_TOKEN for ghcr.io, if none of the above has the credentials for it
+	opts = append(opts, registry.WithCredentialProvider(registry.NewGitHubTokenCredentials(os.Getenv("GITHUB_TOKEN"))))
+
+	opts = append(opts, registry.WithCircuitBreaker(circuitBreakerThreshold))
+	opts = append(opts, registry.WithHostConcurrency(hostConcurrency))
+	if debugRequests {
+		opts = append(opts, registry.WithLogger(log.Default()), registry.WithLogHeaders(true))
+	}
+	for host, hostMirrors := range mirrors {
+		opts = append(opts, registry.WithMirror(host, hostMirrors...))
+	}
+	if len(acceptMediaTypes) > 0 {
+		opts = append(opts, registry.WithAccept(acceptMediaTypes...))
+	}
+	if tokenCachePath != "" {
+		opts = append(opts, registry.WithTokenCache(tokenCachePath))
+	}
+	return registry.New(opts...), nil
+}
+
+// addClientFlags adds the flags about the registry client.
+func addClientFlags(fs *flag.FlagSet) {
+	fs.Func("accept", "accept the `media-type` for the manifest requests, instead of the default ones (repeatable)", func(mediaType string) error {
+		acceptMediaTypes = append(acceptMediaTypes, mediaType)
+		return nil
+	})
+	fs.Func("mirror", "use the mirror for the registry, in the form of `host=mirror` (repeatable)", func(s string) error {
+		idx := strings.IndexRune(s, '=')
+		if idx <= 0 || idx == len(s)-1 {
+			return errors.New("want host=mirror")
+		}
+		host, mirror := s[:idx], s[idx+1:]
+		mirrors[host] = append(mirrors[host], mirror)
+		return nil
+	})
+	fs.IntVar(&circuitBreakerThreshold, "circuit-breaker", 3, "skip the rest of the images on a registry after `n` consecutive failures (0 to disable)")
+	fs.DurationVar(&globalTimeout, "timeout", 0, "the timeout for checking an image (default \""+defaultCheckTimeout.String()+"\" or the timeout in the config)")
+	fs.IntVar(&hostConcurrency, "host-concurrency", 4, "check at most `n` images concurrently on each registry (0 for no limit)")
+	fs.BoolVar(&debugRequests, "debug", false, "log the requests to the registries, with the credentials redacted")
+	fs.StringVar(&tokenCachePath, "token-cache", "", "persist the registry tokens in the `file` to reuse them in the next run")
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/shogo82148/docker-image-update-checker/internal/config"
+	"github.com/shogo82148/docker-image-update-checker/internal/gitsign"
+)
+
+// defaultCommitter is the identity of the commits if it is not configured.
+var defaultCommitter = object.Signature{
+	Name:  "Ichinose Shogo",
+	Email: "shogo82148@gmail.com",
+}
+
+// commitName, commitEmail and commitMessage are given by the flags.
+// They take precedence over the environment variables and the config.
+var (
+	commitName    string
+	commitEmail   string
+	commitMessage string
+)
+
+// addCommitFlags adds the flags about the identity and the message of the commits.
+func addCommitFlags(fs *flag.FlagSet) {
+	fs.StringVar(&commitName, "commit-name", "", "the `name` of the committer (default $DIUC_COMMIT_NAME, the name in the config or \""+defaultCommitter.Name+"\")")
+	fs.StringVar(&commitEmail, "commit-email", "", "the `email` of the committer (default $DIUC_COMMIT_EMAIL, the email in the config or \""+defaultCommitter.Email+"\")")
+	fs.StringVar(&commitMessage, "commit-message", "", "the Go `template` of the commit message, e.g. \"chore: {{.Subject}}\" (default $DIUC_COMMIT_MESSAGE, the message in the config or the subject and the body)")
+}
+
+// commitSetting returns the first non-empty value of the flag, the environment variable and the config.
+func commitSetting(flagValue, env string, fromConfig func(c *config.Commit) string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if v := os.Getenv(env); v != "" {
+		return v
+	}
+	if cfg != nil && cfg.Commit != nil {
+		return fromConfig(cfg.Commit)
+	}
+	return ""
+}
+
+// commitIdentity returns the identity of the committer.
+func commitIdentity() object.Signature {
+	sig := defaultCommitter
+	if name := commitSetting(commitName, "DIUC_COMMIT_NAME", func(c *config.Commit) string { return c.Name }); name != "" {
+		sig.Name = name
+	}
+	if email := commitSetting(commitEmail, "DIUC_COMMIT_EMAIL", func(c *config.Commit) string { return c.Email }); email != "" {
+		sig.Email = email
+	}
+	sig.When = time.Now()
+	return sig
+}
+
+// commitSigningKey returns the signing key of the commits and its passphrase, or the empty strings if no signing keys are configured.
+// They may refer to the environment variables or the secrets as the credentials in the config do.
+func commitSigningKey() (key, passphrase string, err error) {
+	key = commitSetting("", "DIUC_SIGNING_KEY", func(c *config.Commit) string { return c.SigningKey })
+	if key == "" {
+		return "", "", nil
+	}
+	passphrase = commitSetting("", "DIUC_SIGNING_KEY_PASSPHRASE", func(c *config.Commit) string { return c.SigningKeyPassphrase })
+	key, err = config.Resolve(context.Background(), key)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get the signing key: %w", err)
+	}
+	passphrase, err = config.Resolve(context.Background(), passphrase)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get the passphrase of the signing key: %w", err)
+	}
+	return key, passphrase, nil
+}
+
+// commitSigner returns the signer of the commits, or nil if no signing keys are configured.
+func commitSigner() (gitsign.Signer, error) {
+	key, passphrase, err := commitSigningKey()
+	if err != nil || key == "" {
+		return nil, err
+	}
+	return gitsign.New([]byte(key), []byte(passphrase))
+}
+
+// commitData is the data of the template of the commit message.
+type commitData struct {
+	// Kind is the kind of the commit: "update", "disable", "check", "prune" or "migrate".
+	// A run that updates, disables and prunes the images at once has the first kind of them.
+	Kind string
+
+	// Subject is the first line of the default message, e.g. "update: alpine:3.17, golang:1.21".
+	Subject string
+
+	// Body is the rest of the default message: the old and new digests and the changed platforms of each updated image.
+	Body string
+
+	// Images are the images updated, disabled or pruned.
+	Images []string
+
+	// Results are the results of the checks of the images, with the digests and the changes of the platforms.
+	Results []*checkResult
+
+	Time time.Time
+}
+
+// newCommitData returns the data of the commit of the images.
+// The subject is "kind: images", or the summary if no images are given.
+func newCommitData(kind string, images []string, summary string) *commitData {
+	data := &commitData{
+		Kind:    kind,
+		Subject: kind + ": " + strings.Join(images, ", "),
+		Images:  images,
+		Time:    time.Now().UTC(),
+	}
+	if len(images) == 0 {
+		data.Subject = kind + ": " + summary
+	}
+	for _, r := range checkResults {
+		if contains(images, r.Image) {
+			data.Results = append(data.Results, r)
+		}
+	}
+	data.Body = commitBody(data.Results)
+	return data
+}
+
+// newRunCommitData returns the data of the commit of a check run, covering every change of the run:
+// the subject is e.g. "update: alpine:3.17; disable: golang:1.19; prune: debian:10",
+// and the body lists the updated images, then the disabled and pruned ones.
+func newRunCommitData() *commitData {
+	changes := []struct {
+		kind   string
+		images []string
+		reason string
+	}{
+		{"update", updatedImages(), ""},
+		{"disable", disabledImages, fmt.Sprintf("the tag is not found in %d consecutive checks", disableAfter)},
+		{"prune", prunedImages, "the image is no longer tracked"},
+	}
+
+	var data *commitData
+	var subjects, notes []string
+	for _, c := range changes {
+		if len(c.images) == 0 {
+			continue
+		}
+		d := newCommitData(c.kind, c.images, "")
+		if data == nil {
+			data = d
+			data.Images = append([]string(nil), d.Images...)
+		} else {
+			data.Images = append(data.Images, d.Images...)
+			data.Results = append(data.Results, d.Results...)
+		}
+		subjects = append(subjects, d.Subject)
+		if c.reason != "" {
+			for _, image := range c.images {
+				notes = append(notes, fmt.Sprintf("%s %s: %s", c.kind, image, c.reason))
+			}
+		}
+	}
+	if data == nil {
+		return newCommitData("check", nil, "record the status of the checks")
+	}
+	data.Subject = strings.Join(subjects, "; ")
+	if len(notes) > 0 {
+		if data.Body != "" {
+			data.Body += "\n\n"
+		}
+		data.Body += strings.Join(notes, "\n")
+	}
+	return data
+}
+
+// commitBody returns the old and new digests and the changed platforms of the updated images.
+func commitBody(results []*checkResult) string {
+	var buf strings.Builder
+	for _, r := range results {
+		if r.Status != checkUpdated {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		old := r.OldDigest
+		if old == "" {
+			old = "(unknown)"
+		}
+		fmt.Fprintf(&buf, "%s\n  digest: %s -> %s\n", r.Image, old, r.Digest)
+		if r.Changes == nil {
+			continue
+		}
+		for _, p := range r.Changes.Added {
+			fmt.Fprintf(&buf, "  + %s %s\n", p.Platform, p.NewDigest)
+		}
+		for _, p := range r.Changes.Removed {
+			fmt.Fprintf(&buf, "  - %s %s\n", p.Platform, p.OldDigest)
+		}
+		for _, p := range r.Changes.Changed {
+			fmt.Fprintf(&buf, "  ~ %s %s -> %s\n", p.Platform, p.OldDigest, p.NewDigest)
+		}
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// message renders the commit message with the configured template.
+func (data *commitData) message() (string, error) {
+	text := commitSetting(commitMessage, "DIUC_COMMIT_MESSAGE", func(c *config.Commit) string { return c.Message })
+	if text == "" {
+		if data.Body == "" {
+			return data.Subject, nil
+		}
+		return data.Subject + "\n\n" + data.Body, nil
+	}
+	msg, err := data.render(text)
+	if err != nil {
+		return "", err
+	}
+	if msg == "" {
+		return "", errors.New("the commit message is empty")
+	}
+	return msg, nil
+}
+
+// render executes the template of the commit message, or of the tag, with the data.
+func (data *commitData) render(text string) (string, error) {
+	tmpl, err := config.ParseCommitMessage(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// commitChanges commits the changes of the images to the store.
+func commitChanges(kind string, images []string, summary string) error {
+	msg, err := newCommitData(kind, images, summary).message()
+	if err != nil {
+		return err
+	}
+	return store.Commit(msg)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+func TestCommitBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []*checkResult
+		want    string
+	}{
+		{
+			name: "added",
+			results: []*checkResult{
+				{
+					Image: "alpine:3.17", Status: checkUpdated, OldDigest: "sha256:old", Digest: "sha256:new",
+					Changes: &registry.ManifestsDiff{
+						Added: []*registry.PlatformDiff{{Platform: "linux/riscv64", NewDigest: "sha256:riscv64"}},
+					},
+				},
+			},
+			want: "alpine:3.17\n" +
+				"  digest: sha256:old -> sha256:new\n" +
+				"  + linux/riscv64 sha256:riscv64",
+		},
+		{
+			name: "removed",
+			results: []*checkResult{
+				{
+					Image: "alpine:3.17", Status: checkUpdated, OldDigest: "sha256:old", Digest: "sha256:new",
+					Changes: &registry.ManifestsDiff{
+						Removed: []*registry.PlatformDiff{{Platform: "linux/s390x", OldDigest: "sha256:s390x"}},
+					},
+				},
+			},
+			want: "alpine:3.17\n" +
+				"  digest: sha256:old -> sha256:new\n" +
+				"  - linux/s390x sha256:s390x",
+		},
+		{
+			name: "changed",
+			results: []*checkResult{
+				{
+					Image: "alpine:3.17", Status: checkUpdated, OldDigest: "sha256:old", Digest: "sha256:new",
+					Changes: &registry.ManifestsDiff{
+						Changed: []*registry.PlatformDiff{
+							{Platform: "linux/amd64", OldDigest: "sha256:amd64-old", NewDigest: "sha256:amd64-new"},
+							{Platform: "linux/arm64", OldDigest: "sha256:arm64-old", NewDigest: "sha256:arm64-new"},
+						},
+					},
+				},
+			},
+			want: "alpine:3.17\n" +
+				"  digest: sha256:old -> sha256:new\n" +
+				"  ~ linux/amd64 sha256:amd64-old -> sha256:amd64-new\n" +
+				"  ~ linux/arm64 sha256:arm64-old -> sha256:arm64-new",
+		},
+		{
+			name: "first check",
+			results: []*checkResult{
+				{Image: "alpine:3.17", Status: checkUpdated, Digest: "sha256:new"},
+			},
+			want: "alpine:3.17\n" +
+				"  digest: (unknown) -> sha256:new",
+		},
+		{
+			name: "multiple images",
+			results: []*checkResult{
+				{
+					Image: "alpine:3.17", Status: checkUpdated, OldDigest: "sha256:old", Digest: "sha256:new",
+					Changes: &registry.ManifestsDiff{
+						Added:   []*registry.PlatformDiff{{Platform: "linux/riscv64", NewDigest: "sha256:riscv64"}},
+						Removed: []*registry.PlatformDiff{{Platform: "linux/s390x", OldDigest: "sha256:s390x"}},
+					},
+				},
+				{Image: "ubuntu:22.04", Status: checkUnchanged, Digest: "sha256:ubuntu"},
+				{Image: "golang:1.22", Status: checkUpdated, OldDigest: "sha256:go-old", Digest: "sha256:go-new"},
+			},
+			want: "alpine:3.17\n" +
+				"  digest: sha256:old -> sha256:new\n" +
+				"  + linux/riscv64 sha256:riscv64\n" +
+				"  - linux/s390x sha256:s390x\n" +
+				"\n" +
+				"golang:1.22\n" +
+				"  digest: sha256:go-old -> sha256:go-new",
+		},
+		{
+			name: "no updates",
+			results: []*checkResult{
+				{Image: "alpine:3.17", Status: checkFailed, Error: "unexpected status code: 500"},
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commitBody(tt.results); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRunCommitData(t *testing.T) {
+	tests := []struct {
+		name     string
+		results  []*checkResult
+		updated  []string
+		disabled []string
+		pruned   []string
+		kind     string
+		subject  string
+		body     string
+		images   []string
+	}{
+		{
+			name:    "no changes",
+			results: []*checkResult{{Image: "alpine:3.17", Status: checkUnchanged}},
+			kind:    "check",
+			subject: "check: record the status of the checks",
+		},
+		{
+			name:    "updated",
+			results: []*checkResult{{Image: "alpine:3.17", Status: checkUpdated, OldDigest: "sha256:old", Digest: "sha256:new"}},
+			updated: []string{"alpine:3.17"},
+			kind:    "update",
+			subject: "update: alpine:3.17",
+			body:    "alpine:3.17\n  digest: sha256:old -> sha256:new",
+			images:  []string{"alpine:3.17"},
+		},
+		{
+			name:     "disabled and pruned",
+			results:  []*checkResult{{Image: "golang:1.19", Status: checkRemoved}},
+			disabled: []string{"golang:1.19"},
+			pruned:   []string{"debian:10", "ubuntu:18.04"},
+			kind:     "disable",
+			subject:  "disable: golang:1.19; prune: debian:10, ubuntu:18.04",
+			body: "disable golang:1.19: the tag is not found in 3 consecutive checks\n" +
+				"prune debian:10: the image is no longer tracked\n" +
+				"prune ubuntu:18.04: the image is no longer tracked",
+			images: []string{"golang:1.19", "debian:10", "ubuntu:18.04"},
+		},
+		{
+			name: "updated, disabled and pruned",
+			results: []*checkResult{
+				{Image: "alpine:3.17", Status: checkUpdated, OldDigest: "sha256:old", Digest: "sha256:new"},
+				{Image: "golang:1.19", Status: checkRemoved},
+			},
+			updated:  []string{"alpine:3.17"},
+			disabled: []string{"golang:1.19"},
+			pruned:   []string{"debian:10"},
+			kind:     "update",
+			subject:  "update: alpine:3.17; disable: golang:1.19; prune: debian:10",
+			body: "alpine:3.17\n  digest: sha256:old -> sha256:new\n\n" +
+				"disable golang:1.19: the tag is not found in 3 consecutive checks\n" +
+				"prune debian:10: the image is no longer tracked",
+			images: []string{"alpine:3.17", "golang:1.19", "debian:10"},
+		},
+	}
+	oldDisableAfter := disableAfter
+	t.Cleanup(func() {
+		disableAfter = oldDisableAfter
+		checkResults, updated, disabledImages, prunedImages = nil, nil, nil, nil
+	})
+	disableAfter = 3
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkResults, disabledImages, prunedImages = tt.results, tt.disabled, tt.pruned
+			updated = map[string]struct{}{}
+			for _, image := range tt.updated {
+				updated[image] = struct{}{}
+			}
+
+			data := newRunCommitData()
+			if data.Kind != tt.kind {
+				t.Errorf("Kind = %q, want %q", data.Kind, tt.kind)
+			}
+			if data.Subject != tt.subject {
+				t.Errorf("Subject = %q, want %q", data.Subject, tt.subject)
+			}
+			if data.Body != tt.body {
+				t.Errorf("Body:\n%s\nwant:\n%s", data.Body, tt.body)
+			}
+			if !reflect.DeepEqual(data.Images, tt.images) {
+				t.Errorf("Images = %v, want %v", data.Images, tt.images)
+			}
+		})
+	}
+}
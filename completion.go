@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+var completionCommand = &command{
+	name:    "completion",
+	usage:   "completion bash|zsh|fish",
+	summary: "generate the shell completion script",
+	run:     runCompletion,
+}
+
+// completeCommand is the hidden command called by the completion scripts.
+// It prints the candidates for the last argument, one per line.
+var completeCommand = &command{
+	name:   "__complete",
+	usage:  "__complete [arguments...] current",
+	hidden: true,
+	run:    runComplete,
+}
+
+const bashCompletion = `# bash completion for diuc
+# source <(diuc completion bash)
+_diuc() {
+	local cur="${COMP_WORDS[COMP_CWORD]}"
+	local IFS=$'\n'
+	COMPREPLY=($(diuc __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+	# the image names contain colons, which are word breaks of bash.
+	if declare -F __ltrim_colon_completions >/dev/null; then
+		__ltrim_colon_completions "$cur"
+	fi
+}
+complete -o default -F _diuc diuc
+`
+
+const zshCompletion = `#compdef diuc
+# source <(diuc completion zsh)
+_diuc() {
+	local -a candidates
+	candidates=("${(@f)$(diuc __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	compadd -a candidates
+}
+compdef _diuc diuc
+`
+
+const fishCompletion = `# fish completion for diuc
+# diuc completion fish | source
+complete -c diuc -f -a '(diuc __complete (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)'
+`
+
+func runCompletion(cmd *command, args []string) error {
+	fs := newFlagSet(cmd)
+	fs.Parse(args)
+	switch fs.Arg(0) {
+	case "bash":
+		fmt.Print(bashCompletion)
+	case "zsh":
+		fmt.Print(zshCompletion)
+	case "fish":
+		fmt.Print(fishCompletion)
+	default:
+		fs.Usage()
+		return errors.New("unknown shell")
+	}
+	return nil
+}
+
+func runComplete(cmd *command, args []string) error {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	current := args[len(args)-1]
+	for _, candidate := range completions(args[:len(args)-1], current) {
+		if strings.HasPrefix(candidate, current) {
+			fmt.Println(candidate)
+		}
+	}
+	return nil
+}
+
+// completions returns the candidates for the current word.
+func completions(args []string, current string) []string {
+	if len(args) == 0 {
+		var names []string
+		for _, c := range commands {
+			if !c.hidden {
+				names = append(names, c.name)
+			}
+		}
+		return names
+	}
+
+	name := args[0]
+	if strings.HasPrefix(current, "-") {
+		return commandFlags(name)
+	}
+	switch name {
+	case "completion":
+		return []string{"bash", "zsh", "fish"}
+	case "config":
+		return []string{"validate"}
+	case "diff", "history", "remove", "pause":
+		// the tracked images in the default config file.
+		if err := loadConfig(); err != nil {
+			return nil
+		}
+		return targets
+	case "resume":
+		if err := loadConfig(); err != nil {
+			return nil
+		}
+		return inactiveImages
+	}
+	return nil
+}
+
+// commandFlags returns the flags of the command, by parsing its usage.
+// It runs the command with -h in a subprocess, because the flags are defined when the command runs.
+func commandFlags(name string) []string {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil
+	}
+	var stderr bytes.Buffer
+	c := exec.Command(exe, name, "-h")
+	c.Stderr = &stderr
+	c.Run()
+
+	var flags []string
+	scanner := bufio.NewScanner(&stderr)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "  -") {
+			continue
+		}
+		f := strings.Fields(line)[0]
+		flags = append(flags, f)
+	}
+	return flags
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/config"
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// configPath is the path to the config file.
+var configPath string
+
+// cfg is the loaded config.
+var cfg *config.Config
+
+// targets are the images to check.
+var targets []string
+
+// targetConfigs are the configs of the targets. The images from the catalogs don't have configs.
+var targetConfigs map[string]*config.Image
+
+// inactiveImages are the images in the config that are paused or disabled. Their stored manifests are kept.
+var inactiveImages []string
+
+// selectedGroups are the groups given by the flags. If it is not empty, only the images in the groups are targets.
+var selectedGroups []string
+
+// addConfigFlags adds the flags about the config file.
+func addConfigFlags(fs *flag.FlagSet) {
+	fs.StringVar(&configPath, "config", "", "load the images to track from the config `file` (default \"images.json\", \"images.yaml\" or \"images.yml\" if it exists)")
+}
+
+// addGroupFlags adds the flag to select the groups of the images.
+func addGroupFlags(fs *flag.FlagSet) {
+	fs.Func("group", "only the images in the group `name` in the config (repeatable)", func(name string) error {
+		selectedGroups = append(selectedGroups, name)
+		return nil
+	})
+}
+
+// loadConfig loads the config file.
+// If the path is not given and the default config file doesn't exist, defaultTargets are tracked.
+func loadConfig() error {
+	c, err := readConfig()
+	if err != nil {
+		return err
+	}
+	return applyConfig(c)
+}
+
+// readConfig reads and validates the config file, without applying it.
+func readConfig() (*config.Config, error) {
+	c, err := config.Load(configFile())
+	if os.IsNotExist(err) && configPath == "" {
+		c = config.Default(defaultTargets)
+		err = c.Validate()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// configFile returns the path to the config file.
+func configFile() string {
+	if configPath != "" {
+		return configPath
+	}
+	return config.FindDefault()
+}
+
+// applyConfig makes c the config of the run, and selects the targets from it.
+func applyConfig(c *config.Config) error {
+	cfg = c
+	if err := selectTenant(); err != nil {
+		return err
+	}
+	groups := cfg.Groups()
+	for _, name := range selectedGroups {
+		if !contains(groups, name) {
+			return fmt.Errorf("unknown group: %s", name)
+		}
+	}
+
+	targets = make([]string, 0, len(cfg.Images))
+	targetConfigs = make(map[string]*config.Image, len(cfg.Images))
+	patterns = nil
+	patternConfigs = map[string]*config.Image{}
+	inactiveImages = nil
+	for _, img := range cfg.Images {
+		if img.Disabled || img.Paused {
+			inactiveImages = append(inactiveImages, img.Image)
+			continue
+		}
+		if !inSelectedGroups(img) || cfg.TenantOf(img) != tenant {
+			continue
+		}
+		if registry.IsTagPattern(img.Image) {
+			patterns = append(patterns, img.Image)
+			patternConfigs[img.Image] = img
+			continue
+		}
+		targets = append(targets, img.Image)
+		targetConfigs[img.Image] = img
+	}
+	return expandStoredPatterns()
+}
+
+// targetGroups returns the groups of the image in the config.
+func targetGroups(image string) []string {
+	if img := targetConfigs[image]; img != nil {
+		return img.Groups
+	}
+	return nil
+}
+
+// targetMetadata returns the metadata of the image in the config.
+func targetMetadata(image string) *config.Metadata {
+	if img := targetConfigs[image]; img != nil {
+		return img.Metadata
+	}
+	return nil
+}
+
+// targetPlatforms returns the platforms of the image to track. nil means all platforms.
+// The platforms are validated when the config is loaded.
+func targetPlatforms(image string) []*registry.Platform {
+	img := targetConfigs[image]
+	if img == nil {
+		return nil
+	}
+	filters, _ := img.PlatformFilters()
+	return filters
+}
+
+// sameManifests reports whether the manifests of the image are the same on the tracked platforms.
+// The order of the manifests in the list doesn't matter.
+func sameManifests(image string, a, b *registry.Manifests) bool {
+	platforms := targetPlatforms(image)
+	return reflect.DeepEqual(
+		registry.Canonical(registry.FilterPlatforms(a, platforms)),
+		registry.Canonical(registry.FilterPlatforms(b, platforms)),
+	)
+}
+
+// inSelectedGroups reports whether the image belongs to any of selectedGroups.
+// All images are selected if no groups are given.
+func inSelectedGroups(img *config.Image) bool {
+	if len(selectedGroups) == 0 {
+		return true
+	}
+	for _, name := range selectedGroups {
+		if img.InGroup(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// saveConfig writes the config into the config file.
+func saveConfig() error {
+	path := configFile()
+	recordChange(path)
+	return cfg.Save(path)
+}
+
+// targetRequestOptions returns the options of the manifest request for the image from the config.
+func targetRequestOptions(image string) []registry.RequestOption {
+	opts := []registry.RequestOption{registry.WithRequestTimeout(checkTimeout(image))}
+	if img := targetConfigs[image]; img != nil && len(img.Accept) > 0 {
+		opts = append(opts, registry.WithRequestAccept(img.Accept...))
+	}
+	return opts
+}
+
+// defaultCheckTimeout is the timeout for checking an image if it is not configured.
+const defaultCheckTimeout = 10 * time.Second
+
+// globalTimeout and globalRunTimeout are given by the flags. They take precedence over the config file.
+var (
+	globalTimeout    time.Duration
+	globalRunTimeout time.Duration
+)
+
+// checkTimeout returns the timeout for checking the image.
+// The timeout of the image in the config takes precedence over the flag and the global one in the config.
+func checkTimeout(image string) time.Duration {
+	img := targetConfigs[image]
+	if img == nil {
+		img = patternConfigs[image]
+	}
+	if img != nil && img.Timeout > 0 {
+		return time.Duration(img.Timeout)
+	}
+	if globalTimeout > 0 {
+		return globalTimeout
+	}
+	if cfg != nil && cfg.Timeout > 0 {
+		return time.Duration(cfg.Timeout)
+	}
+	return defaultCheckTimeout
+}
+
+// runTimeout returns the timeout for checking all the images.
+// If it is not configured, it is the sum of the timeouts of the images and the spread window.
+func runTimeout(images []string) time.Duration {
+	if globalRunTimeout > 0 {
+		return globalRunTimeout
+	}
+	if cfg != nil && cfg.RunTimeout > 0 {
+		return time.Duration(cfg.RunTimeout)
+	}
+	d := spreadWindow()
+	for _, image := range images {
+		d += checkTimeout(image)
+	}
+	return d
+}
+
+// login logins to the registries with the credentials in the config.
+func login(c *registry.Client) error {
+	for _, img := range cfg.Images {
+		if img.Auth == nil {
+			continue
+		}
+		host, _, _ := registry.GetRepository(img.Image)
+		username, password, err := img.Auth.Credentials(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to get the credentials for %s: %w", img.Image, err)
+		}
+		if err := c.Login(context.Background(), host, username, password); err != nil {
+			return fmt.Errorf("failed to login to %s: %w", host, err)
+		}
+	}
+	return nil
+}
+
+// dedupeTargets removes the targets that refer to the same image, e.g. "alpine" and "docker.io/library/alpine".
+func dedupeTargets() {
+	seen := make(map[string]struct{}, len(targets))
+	images := targets[:0]
+	for _, image := range targets {
+		key, err := statusFile(image)
+		if err != nil {
+			log.Printf("%s is invalid, skipped: %v", image, err)
+			continue
+		}
+		if _, ok := seen[key]; ok {
+			log.Printf("%s is tracked twice, skipped", image)
+			continue
+		}
+		seen[key] = struct{}{}
+		images = append(images, image)
+	}
+	targets = images
+}
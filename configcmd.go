@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+var configCommand = &command{
+	name:    "config",
+	usage:   "config validate [options]",
+	summary: "validate the config file",
+	run:     runConfig,
+}
+
+func runConfig(cmd *command, args []string) error {
+	if len(args) == 0 || args[0] != "validate" {
+		fs := newFlagSet(cmd)
+		fs.Usage()
+		return errors.New("unknown subcommand")
+	}
+	args = args[1:]
+
+	fs := newFlagSet(cmd)
+	addConfigFlags(fs)
+	addClientFlags(fs)
+	network := fs.Bool("network", false, "also check that the images exist in the registries")
+	fs.Parse(args)
+
+	if err := loadConfig(); err != nil {
+		return err
+	}
+	if !*network {
+		log.Printf("ok: %d images", len(targets))
+		return nil
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	if err := login(c); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), runTimeout(targets))
+	defer cancel()
+
+	var failed int
+	for _, r := range getManifests(ctx, c, targets) {
+		if r.Err != nil {
+			log.Printf("%s: %v", r.Image, r.Err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d images are not available", failed, len(targets))
+	}
+	log.Printf("ok: %d images", len(targets))
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/format"
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+var diffCommand = &command{
+	name:    "diff",
+	usage:   "diff [options] image...",
+	summary: "compare the stored manifests with the live ones, without modifying any files",
+	run:     runDiff,
+}
+
+// diffResult is an image in the output of the diff command.
+type diffResult struct {
+	Image   string                  `json:"image"`
+	Checked bool                    `json:"checked"`
+	Diff    *registry.ManifestsDiff `json:"diff"`
+
+	stored *registry.Manifests
+}
+
+func runDiff(cmd *command, args []string) error {
+	fs := newFlagSet(cmd)
+	addConfigFlags(fs)
+	addTenantFlags(fs)
+	addStoreFlags(fs)
+	addClientFlags(fs)
+	addFormatFlags(fs, "table")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("no images given")
+	}
+
+	if err := loadConfig(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	if err := login(c); err != nil {
+		return err
+	}
+
+	var results []*diffResult
+	for _, image := range fs.Args() {
+		stored, err := store.Load(image)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), checkTimeout(image))
+		live, err := c.GetManifests(ctx, image, targetRequestOptions(image)...)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to get %s: %w", image, err)
+		}
+
+		results = append(results, &diffResult{
+			Image:   image,
+			Checked: stored != nil,
+			Diff:    registry.DiffManifests(stored, live),
+			stored:  stored,
+		})
+	}
+	return format.Write(os.Stdout, outputFormat, results, func(w io.Writer) error {
+		for _, r := range results {
+			printDiff(w, r.Image, r.stored, r.Diff)
+		}
+		return nil
+	})
+}
+
+func printDiff(w io.Writer, image string, stored *registry.Manifests, d *registry.ManifestsDiff) {
+	fmt.Fprintln(w, image)
+	if stored == nil {
+		fmt.Fprintln(w, "  (not checked yet)")
+	}
+	if d.Empty() {
+		fmt.Fprintln(w, "  no changes")
+		return
+	}
+	if d.OldMediaType != d.NewMediaType && stored != nil {
+		fmt.Fprintf(w, "  media type: %s -> %s\n", d.OldMediaType, d.NewMediaType)
+	}
+	for _, p := range d.Added {
+		fmt.Fprintf(w, "  + %s %s (size %d)\n", p.Platform, p.NewDigest, p.NewSize)
+	}
+	for _, p := range d.Removed {
+		fmt.Fprintf(w, "  - %s %s (size %d)\n", p.Platform, p.OldDigest, p.OldSize)
+	}
+	for _, p := range d.Changed {
+		fmt.Fprintf(w, "  ~ %s %s -> %s (size %d -> %d, %+d)\n", p.Platform, p.OldDigest, p.NewDigest, p.OldSize, p.NewSize, p.NewSize-p.OldSize)
+		if p.OldOSVersion != p.NewOSVersion {
+			fmt.Fprintf(w, "    os.version %s -> %s\n", p.OldOSVersion, p.NewOSVersion)
+		}
+	}
+}
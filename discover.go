@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/config"
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+var discoverCommand = &command{
+	name:    "discover",
+	usage:   "discover [options]",
+	summary: "find the new release tags of the tracked images, and optionally add them to the config file",
+	run:     runDiscover,
+}
+
+// discoverNewTags enables adding the new release tags to the config in check.
+var discoverNewTags bool
+
+// versionSeries is the tracked versions of a repository with the same form,
+// e.g. "3.14" and "3.15" of alpine, or "1.21.0-alpine" and "1.22.0-alpine" of golang.
+type versionSeries struct {
+	// name is the name part of the image in the config, e.g. "alpine".
+	name string
+
+	// latest is the config of the latest tracked version. The new tags inherit its options.
+	latest        *config.Image
+	latestVersion *registry.Version
+	latestTag     string
+}
+
+// seriesKey returns the key of the series that the tag belongs to.
+// The tags are in the same series if they have the same number of segments, the same suffix, and the same "v" prefix.
+func seriesKey(repo, tag string, v *registry.Version) string {
+	return fmt.Sprintf("%s:%t:%d:%s", repo, strings.HasPrefix(tag, "v"), len(v.Segments), v.Suffix)
+}
+
+// discover returns the configs of the new release tags of the tracked images.
+// A tag is new if it is in the same series as the tracked tags, and it is newer than all of them.
+// e.g. alpine:3.16 is new if alpine:3.14 and alpine:3.15 are tracked.
+func discover(c *registry.Client) ([]*config.Image, error) {
+	var keys []string
+	series := map[string]*versionSeries{}
+	repos := map[string]string{}
+	for _, img := range cfg.Images {
+		if !inSelectedGroups(img) || registry.IsTagPattern(img.Image) {
+			continue
+		}
+		ref, err := registry.ParseReference(img.Image)
+		if err != nil || ref.Tag == "" || ref.Digest != "" {
+			continue
+		}
+		v, err := registry.ParseVersion(ref.Tag)
+		if err != nil {
+			continue
+		}
+		repo := ref.Host + "/" + ref.Repository
+		key := seriesKey(repo, ref.Tag, v)
+		s, ok := series[key]
+		if !ok {
+			s = &versionSeries{name: img.Image[:strings.LastIndex(img.Image, ":")]}
+			series[key] = s
+			keys = append(keys, key)
+			repos[key] = repo
+		}
+		if s.latest == nil || v.Compare(s.latestVersion) > 0 {
+			s.latest, s.latestVersion, s.latestTag = img, v, ref.Tag
+		}
+	}
+
+	tagsCache := map[string][]string{}
+	var found []*config.Image
+	for _, key := range keys {
+		s := series[key]
+		tags, ok := tagsCache[repos[key]]
+		if !ok {
+			ctx, cancel := context.WithTimeout(context.Background(), checkTimeout(s.latest.Image))
+			var err error
+			tags, err = c.ListTags(ctx, s.latest.Image)
+			cancel()
+			if err != nil {
+				return nil, fmt.Errorf("failed to list the tags of %s: %w", s.name, err)
+			}
+			tagsCache[repos[key]] = tags
+		}
+
+		var newTags []string
+		for _, tag := range tags {
+			v, err := registry.ParseVersion(tag)
+			if err != nil || seriesKey(repos[key], tag, v) != key || v.Compare(s.latestVersion) <= 0 {
+				continue
+			}
+			newTags = append(newTags, tag)
+		}
+		registry.SortTags(newTags)
+		for _, tag := range newTags {
+			image := s.name + ":" + tag
+			if cfg.Find(image) >= 0 {
+				continue
+			}
+			img := *s.latest
+			img.Image = image
+			found = append(found, &img)
+		}
+	}
+	return found, nil
+}
+
+// discoverAndTrack adds the new release tags to the config and the targets.
+func discoverAndTrack(c *registry.Client) error {
+	found, err := discover(c)
+	if err != nil {
+		return err
+	}
+	for _, img := range found {
+		if err := cfg.Add(img); err != nil {
+			return err
+		}
+		log.Printf("discovered: %s", img.Image)
+		targets = append(targets, img.Image)
+		targetConfigs[img.Image] = img
+	}
+	if len(found) == 0 || dryRun {
+		return nil
+	}
+	return saveConfig()
+}
+
+func runDiscover(cmd *command, args []string) error {
+	fs := newFlagSet(cmd)
+	addConfigFlags(fs)
+	addGroupFlags(fs)
+	addClientFlags(fs)
+	write := fs.Bool("write", false, "add the new tags to the config file")
+	fs.Parse(args)
+
+	if err := loadConfig(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	c, err := newClient()
+	if err != nil {
+		return err
+	}
+	if err := login(c); err != nil {
+		return err
+	}
+
+	found, err := discover(c)
+	if err != nil {
+		return err
+	}
+	for _, img := range found {
+		fmt.Println(img.Image)
+		if err := cfg.Add(img); err != nil {
+			return err
+		}
+	}
+	if !*write || len(found) == 0 {
+		return nil
+	}
+	if err := saveConfig(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	log.Printf("added %d images to the config", len(found))
+	return nil
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/config"
+	"github.com/shogo82148/docker-image-update-checker/internal/storage"
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+var addCommand = &command{
+	name:    "add",
+	usage:   "add [options] image...",
+	summary: "add the images to the config file",
+	run:     runAdd,
+}
+
+var removeCommand = &command{
+	name:    "remove",
+	usage:   "remove [options] image...",
+	summary: "remove the images from the config file",
+	run:     runRemove,
+}
+
+func runAdd(cmd *command, args []string) error {
+	fs := newFlagSet(cmd)
+	addConfigFlags(fs)
+	addClientFlags(fs)
+	noVerify := fs.Bool("no-verify", false, "don't check that the images exist in the registries")
+	fetch := fs.Bool("fetch", false, "fetch the initial manifests and store them")
+	var platforms []string
+	fs.Func("platform", "track only the `platform` of the images in the form of os/arch[/variant], e.g. linux/arm/v7 (repeatable)", func(s string) error {
+		if _, err := registry.ParsePlatform(s); err != nil {
+			return err
+		}
+		platforms = append(platforms, s)
+		return nil
+	})
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("no images given")
+	}
+
+	if err := loadConfig(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	var c *registry.Client
+	if !*noVerify || *fetch {
+		var err error
+		c, err = newClient()
+		if err != nil {
+			return err
+		}
+		if err := login(c); err != nil {
+			return err
+		}
+	}
+
+	status = map[string]*registry.Manifests{}
+	updated = map[string]struct{}{}
+	fetched = map[string]*storage.Document{}
+	for _, image := range fs.Args() {
+		if _, err := registry.ParseReferencePattern(image); err != nil {
+			return err
+		}
+		if i := cfg.Find(image); i >= 0 {
+			return fmt.Errorf("%s is already tracked as %s", image, cfg.Images[i].Image)
+		}
+
+		if c != nil && registry.IsTagPattern(image) {
+			log.Printf("%s is a tag pattern; the tags are checked in the next run", image)
+		} else if c != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), checkTimeout(image))
+			resp, err := c.GetManifestsResponse(ctx, image)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("failed to get %s: %w", image, err)
+			}
+			m := resp.Manifests
+			for _, s := range platforms {
+				p, _ := registry.ParsePlatform(s)
+				if !registry.HasPlatform(m, p) {
+					return fmt.Errorf("%s has no manifest for %s", image, s)
+				}
+			}
+			if *fetch {
+				status[image] = m
+				updated[image] = struct{}{}
+				fetched[image] = fetchedDocument(resp.Digest, resp.MediaType, resp.Raw, time.Now())
+			}
+		}
+
+		if err := cfg.Add(&config.Image{Image: image, Platforms: platforms}); err != nil {
+			return err
+		}
+		log.Printf("added: %s", image)
+	}
+
+	if err := saveConfig(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	if err := saveStatus(); err != nil {
+		return fmt.Errorf("failed to save status: %w", err)
+	}
+	return nil
+}
+
+var pauseCommand = &command{
+	name:    "pause",
+	usage:   "pause [options] image...",
+	summary: "exclude the images from the checks temporarily, keeping their stored manifests",
+	run:     runPause,
+}
+
+var resumeCommand = &command{
+	name:    "resume",
+	usage:   "resume [options] image...",
+	summary: "resume checking the paused or disabled images",
+	run:     runResume,
+}
+
+func runPause(cmd *command, args []string) error {
+	return setPaused(cmd, args, true)
+}
+
+func runResume(cmd *command, args []string) error {
+	return setPaused(cmd, args, false)
+}
+
+// setPaused pauses or resumes the images in the config.
+// Resuming also enables the images disabled because their tags were removed.
+func setPaused(cmd *command, args []string, paused bool) error {
+	fs := newFlagSet(cmd)
+	addConfigFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("no images given")
+	}
+
+	if err := loadConfig(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	for _, image := range fs.Args() {
+		i := cfg.Find(image)
+		if i < 0 {
+			return fmt.Errorf("%s is not tracked", image)
+		}
+		img := cfg.Images[i]
+		img.Paused = paused
+		if paused {
+			log.Printf("paused: %s", img.Image)
+		} else {
+			img.Disabled = false
+			log.Printf("resumed: %s", img.Image)
+		}
+	}
+
+	if err := saveConfig(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	return nil
+}
+
+func runRemove(cmd *command, args []string) error {
+	fs := newFlagSet(cmd)
+	addConfigFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("no images given")
+	}
+
+	if err := loadConfig(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	for _, image := range fs.Args() {
+		img, err := cfg.Remove(image)
+		if err != nil {
+			return err
+		}
+		log.Printf("removed: %s", img.Image)
+	}
+
+	if err := saveConfig(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+var exportCommand = &command{
+	name:    "export",
+	usage:   "export [options]",
+	summary: "export all stored manifests into a single JSON document, or an OCI image layout",
+	run:     runExport,
+}
+
+var importCommand = &command{
+	name:    "import",
+	usage:   "import [options] [file]",
+	summary: "import the stored manifests from a JSON document made by export",
+	run:     runImport,
+}
+
+// snapshotVersion is the version of the format of the snapshot.
+const snapshotVersion = 1
+
+// snapshot is the document made by export.
+type snapshot struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exportedAt"`
+
+	// Images are the stored manifests keyed by the image references, e.g. "registry-1.docker.io/library/alpine:3.17".
+	Images map[string]*registry.Manifests `json:"images"`
+}
+
+func runExport(cmd *command, args []string) error {
+	fs := newFlagSet(cmd)
+	output := fs.String("o", "-", "write the snapshot into the `file` (\"-\" for stdout)")
+	ociDir := fs.String("oci", "", "write the manifests into the OCI image layout in the `dir`, instead of the snapshot")
+	addConfigFlags(fs)
+	addTenantFlags(fs)
+	addStoreFlags(fs)
+	fs.Parse(args)
+	if err := loadConfig(); err != nil {
+		return err
+	}
+
+	if *ociDir != "" {
+		images, err := store.List()
+		if err != nil {
+			return err
+		}
+		n, err := writeOCILayout(*ociDir, images)
+		if err != nil {
+			return err
+		}
+		log.Printf("exported %d images into %s", n, *ociDir)
+		return nil
+	}
+
+	snap := &snapshot{
+		Version:    snapshotVersion,
+		ExportedAt: time.Now().UTC(),
+		Images:     map[string]*registry.Manifests{},
+	}
+	images, err := store.List()
+	if err != nil {
+		return err
+	}
+	for _, image := range images {
+		m, err := store.Load(image)
+		if err != nil {
+			return err
+		}
+		snap.Images[image] = registry.Canonical(m)
+	}
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if *output == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		return err
+	}
+	log.Printf("exported %d images into %s", len(snap.Images), *output)
+	return nil
+}
+
+func runImport(cmd *command, args []string) error {
+	fs := newFlagSet(cmd)
+	addConfigFlags(fs)
+	addTenantFlags(fs)
+	addStoreFlags(fs)
+	fs.Parse(args)
+	if err := loadConfig(); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errors.New("too many arguments")
+	}
+
+	var r io.Reader = os.Stdin
+	if name := fs.Arg(0); name != "" && name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var snap snapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return fmt.Errorf("failed to parse the snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version: %d", snap.Version)
+	}
+
+	images := make([]string, 0, len(snap.Images))
+	for image, m := range snap.Images {
+		if m == nil {
+			return fmt.Errorf("%s: empty manifests", image)
+		}
+		// validate all images before writing anything.
+		if _, err := statusFile(image); err != nil {
+			return err
+		}
+		images = append(images, image)
+	}
+	sort.Strings(images)
+
+	status = snap.Images
+	updated = make(map[string]struct{}, len(images))
+	for _, image := range images {
+		updated[image] = struct{}{}
+	}
+	if err := saveStatus(); err != nil {
+		return err
+	}
+	log.Printf("imported %d images", len(images))
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+// failOnError makes check exit with exitFailed if any images failed.
+var failOnError bool
+
+// failureReportFile is the path to the file that the failure report is written into.
+var failureReportFile string
+
+// failedResults returns the images that failed, including the ones skipped because their registries are unavailable.
+func failedResults() []*checkResult {
+	var failures []*checkResult
+	for _, r := range checkResults {
+		if r.Status == checkFailed || r.Status == checkSkipped || r.Status == checkMutated {
+			failures = append(failures, r)
+		}
+	}
+	return failures
+}
+
+// printFailureReport writes the errors of the failed images.
+func printFailureReport(w io.Writer, failures []*checkResult) {
+	fmt.Fprintf(w, "%d of %d images failed:\n", len(failures), len(checkResults))
+	for _, r := range failures {
+		fmt.Fprintf(w, "  %s: %s: %s\n", r.Image, r.Status, r.Error)
+	}
+}
+
+// reportFailures logs the failure report, and writes it into failureReportFile.
+// The file is written even if no images failed, not to leave the report of the previous run.
+func reportFailures() error {
+	failures := failedResults()
+	var buf bytes.Buffer
+	if len(failures) > 0 {
+		printFailureReport(&buf, failures)
+		log.Print(buf.String())
+	}
+	if failureReportFile == "" {
+		return nil
+	}
+	return os.WriteFile(failureReportFile, buf.Bytes(), 0644)
+}
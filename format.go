@@ -0,0 +1,11 @@
+package main
+
+import "flag"
+
+// outputFormat is the format of the results written to stdout.
+var outputFormat string
+
+// addFormatFlags adds the flag about the output format.
+func addFormatFlags(fs *flag.FlagSet, def string) {
+	fs.StringVar(&outputFormat, "format", def, "output `format`: table, json, yaml or a Go template, e.g. '{{.Image}}'")
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/storage"
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+var historyCommand = &command{
+	name:    "history",
+	usage:   "history [options] image",
+	summary: "show the timeline of the stored manifests from the git log or the store",
+	run:     runHistory,
+}
+
+// revision is a version of the stored manifests, e.g. a status file in the git history.
+type revision struct {
+	Commit    string
+	Date      time.Time
+	Manifests *registry.Manifests
+}
+
+func runHistory(cmd *command, args []string) error {
+	fs := newFlagSet(cmd)
+	platform := fs.String("platform", "linux/amd64", "show the digest of the `platform`")
+	limit := fs.Int("n", 0, "show only the last `n` revisions (0 for all)")
+	addConfigFlags(fs)
+	addTenantFlags(fs)
+	addStoreFlags(fs)
+	fs.Parse(args)
+	if err := loadConfig(); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("want exactly one image")
+	}
+	image := fs.Arg(0)
+
+	revs, err := statusHistory(image)
+	if err != nil {
+		return err
+	}
+	if len(revs) == 0 {
+		return fmt.Errorf("no history of %s", image)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "DATE\tCOMMIT\t%s\tCHANGES\n", strings.ToUpper(*platform))
+	shown := 0
+	for i := len(revs) - 1; i >= 0; i-- {
+		if *limit > 0 && shown >= *limit {
+			break
+		}
+		shown++
+
+		rev := revs[i]
+		var prev *registry.Manifests
+		if i > 0 {
+			prev = revs[i-1].Manifests
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			rev.Date.UTC().Format(time.RFC3339), rev.Commit[:7], platformDigest(rev.Manifests, *platform), summarizeDiff(registry.DiffManifests(prev, rev.Manifests)))
+	}
+	return w.Flush()
+}
+
+// statusHistory returns the revisions of the stored manifests of the image, from the oldest to the newest.
+func statusHistory(image string) ([]*revision, error) {
+	switch s := store.(type) {
+	case historyStore:
+		history, err := s.History(image)
+		if err != nil {
+			return nil, err
+		}
+		revs := make([]*revision, 0, len(history))
+		for _, h := range history {
+			revs = append(revs, &revision{
+				Commit:    h.ID,
+				Date:      h.ObservedAt,
+				Manifests: h.Manifests,
+			})
+		}
+		return revs, nil
+	case fileStore:
+	default:
+		return nil, errors.New("the store doesn't keep the history")
+	}
+
+	path, err := statusFile(image)
+	if err != nil {
+		return nil, err
+	}
+	path = "./" + filepath.ToSlash(path)
+
+	out, err := gitOutput("log", "--follow", "--format=%H%x1f%cI", "--", path)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	revs := make([]*revision, 0, len(lines))
+	for i := len(lines) - 1; i >= 0; i-- {
+		fields := strings.Split(lines[i], "\x1f")
+		if len(fields) != 2 {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil {
+			return nil, err
+		}
+		rev := &revision{
+			Commit: fields[0],
+			Date:   date,
+		}
+
+		// the file may be renamed or deleted in the commit.
+		data, err := gitOutput("show", rev.Commit+":"+path)
+		if err == nil {
+			if doc, err := storage.DecodeDocument([]byte(data)); err == nil {
+				rev.Manifests = doc.Manifest
+			}
+		}
+		revs = append(revs, rev)
+	}
+	return revs, nil
+}
+
+// platformDigest returns the digest of the platform in the manifests.
+func platformDigest(m *registry.Manifests, platform string) string {
+	if m == nil {
+		return "-"
+	}
+	if len(m.Manifests) == 0 {
+		// single platform images
+		if m.Config != nil {
+			return m.Config.Digest
+		}
+		return "-"
+	}
+	for _, manifest := range m.Manifests {
+		if manifest.Platform.String() == platform {
+			return manifest.Digest
+		}
+	}
+	return "-"
+}
+
+// summarizeDiff returns a short description of the diff.
+func summarizeDiff(d *registry.ManifestsDiff) string {
+	var parts []string
+	if d.OldMediaType != "" && d.OldMediaType != d.NewMediaType {
+		parts = append(parts, "media type changed")
+	}
+	for _, p := range d.Added {
+		parts = append(parts, "+"+p.Platform)
+	}
+	for _, p := range d.Removed {
+		parts = append(parts, "-"+p.Platform)
+	}
+	for _, p := range d.Changed {
+		parts = append(parts, "~"+p.Platform)
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " ")
+}
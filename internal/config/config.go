@@ -0,0 +1,774 @@
+// Package config loads the configuration of the checker, which lists the images to track.
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/layout"
+	"github.com/shogo82148/docker-image-update-checker/internal/secret"
+	"github.com/shogo82148/docker-image-update-checker/registry"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultPath is the path to the config file used if no path is given.
+const DefaultPath = "images.json"
+
+// defaultPaths are the candidates of the config file used if no path is given, in the order of precedence.
+var defaultPaths = []string{DefaultPath, "images.yaml", "images.yml"}
+
+// FindDefault returns the first one of images.json, images.yaml and images.yml that exists,
+// or DefaultPath if none of them exist.
+func FindDefault() string {
+	for _, path := range defaultPaths {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return DefaultPath
+}
+
+// isYAML reports whether the config file is written in YAML, by the extension of the path.
+// The other files are JSON.
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// Config is the configuration of the checker.
+type Config struct {
+	// Timeout is the default timeout for checking an image.
+	Timeout Duration `json:"timeout,omitempty"`
+
+	// RunTimeout is the timeout for checking all images.
+	RunTimeout Duration `json:"runTimeout,omitempty"`
+
+	// Spread is the window that the checks are spread across with jitter, to avoid bursty traffic.
+	Spread Duration `json:"spread,omitempty"`
+
+	// StaleAfter is the default period after which the images that haven't changed are reported as stale,
+	// e.g. "180d" to find the abandoned base images. The images are never stale if it is zero.
+	StaleAfter Duration `json:"staleAfter,omitempty"`
+
+	// Notify is the notification of the results of the checks.
+	Notify *Notify `json:"notify,omitempty"`
+
+	// Tenants are the named sets of the groups with their own notifications and state.
+	Tenants []*Tenant `json:"tenants,omitempty"`
+
+	// Commit is the identity and the message of the commits of the state.
+	Commit *Commit `json:"commit,omitempty"`
+
+	// State is where the manifests of the images are stored in the repository.
+	State *State `json:"state,omitempty"`
+
+	// Images are the images to track.
+	Images []*Image `json:"images"`
+}
+
+// Notify is the notification of the results of the checks.
+// All the configured notifiers are notified.
+type Notify struct {
+	// Webhook is the URL that the summary of the check is posted to.
+	// It may refer to the environment variables or the secrets as the credentials do.
+	Webhook string `json:"webhook,omitempty"`
+
+	// Secret signs the payload with HMAC-SHA256 in the X-Diuc-Signature-256 header, if it is not empty.
+	Secret string `json:"secret,omitempty"`
+
+	// Slack is the URL of the incoming webhook of Slack that the updates and the failures are posted to.
+	// It may refer to the environment variables or the secrets as Webhook does.
+	Slack string `json:"slack,omitempty"`
+}
+
+// Commit is the identity and the message of the commits of the state.
+type Commit struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+
+	// Message is the Go template of the commit message, e.g. "chore: {{.Subject}}".
+	// See ParseCommitMessage for the functions.
+	Message string `json:"message,omitempty"`
+
+	// SigningKey is the armored OpenPGP private key or the SSH private key to sign the commits.
+	// It should refer to the environment variables or the secrets as the credentials do, e.g. "file:///run/secrets/signing-key".
+	SigningKey string `json:"signingKey,omitempty"`
+
+	// SigningKeyPassphrase is the passphrase of SigningKey, if it is encrypted.
+	SigningKeyPassphrase string `json:"signingKeyPassphrase,omitempty"`
+
+	// Tag is the Go template of the name of the tag of the update commits, e.g. `updates/{{.Time.Format "2006-01-02T15-04"}}`.
+	// It has the same data and functions as Message. The update commits are not tagged if it is empty.
+	Tag string `json:"tag,omitempty"`
+
+	// Release creates the GitHub release of the tag, whose notes list the updated images and their digests.
+	Release bool `json:"release,omitempty"`
+}
+
+// ParseCommitMessage parses the template of the commit message.
+// It has the "join" function, i.e. strings.Join.
+func ParseCommitMessage(text string) (*template.Template, error) {
+	return template.New("message").Option("missingkey=error").Funcs(template.FuncMap{
+		"join": strings.Join,
+	}).Parse(text)
+}
+
+func (c *Commit) validate() error {
+	if c == nil {
+		return nil
+	}
+	if strings.ContainsAny(c.Name, "<>\n") {
+		return errors.New("name must not contain '<', '>' or newlines")
+	}
+	if c.Email != "" && (!strings.Contains(c.Email, "@") || strings.ContainsAny(c.Email, "<>\n ")) {
+		return fmt.Errorf("invalid email: %q", c.Email)
+	}
+	for _, text := range []string{c.Message, c.Tag} {
+		if text == "" {
+			continue
+		}
+		if _, err := ParseCommitMessage(text); err != nil {
+			return err
+		}
+	}
+	for _, v := range []string{c.SigningKey, c.SigningKeyPassphrase} {
+		if err := checkExpand(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// State is where the manifests of the images are stored in the repository.
+type State struct {
+	// Dir is the directory of the status files, "manifests" by default.
+	// It is under the state directory of the tenant if a tenant is selected.
+	Dir string `json:"dir,omitempty"`
+
+	// Layout is the name of the layout of the status files, "nested" or "flat",
+	// or the pattern with the placeholders, e.g. "{host}/{repository}/{tag}.json".
+	// See the layout package for the details.
+	Layout string `json:"layout,omitempty"`
+
+	// ChildManifests also stores the manifests of the platforms of the updated images next to their status files,
+	// so that e.g. the layer digests of linux/amd64 are available offline.
+	ChildManifests bool `json:"childManifests,omitempty"`
+
+	// ImageConfigs also stores the image configs of the platforms of the updated images next to their status files.
+	ImageConfigs bool `json:"imageConfigs,omitempty"`
+
+	// History is the number of the previous versions of the status files kept next to them,
+	// for the consumers that don't read the git history. No previous versions are kept if it is zero.
+	History int `json:"history,omitempty"`
+
+	// Changelog is the path of the Markdown changelog of the updates, e.g. "UPDATES.md",
+	// which is prepended an entry per run with the updated images. It is under the state prefix of the tenant if a tenant is selected.
+	Changelog string `json:"changelog,omitempty"`
+
+	// AuditLog is the path of the append-only log of the detected changes in JSON Lines, e.g. "audit.jsonl".
+	// It is under the state prefix of the tenant if a tenant is selected.
+	AuditLog string `json:"auditLog,omitempty"`
+}
+
+// DefaultStateDir is the directory of the status files if it is not configured.
+const DefaultStateDir = "manifests"
+
+// StateDir returns the directory of the status files.
+func (cfg *Config) StateDir() string {
+	if cfg.State != nil && cfg.State.Dir != "" {
+		return filepath.FromSlash(cfg.State.Dir)
+	}
+	return DefaultStateDir
+}
+
+// StateChangelog returns the path of the changelog of the updates, or the empty string if it is not configured.
+func (cfg *Config) StateChangelog() string {
+	if cfg.State != nil && cfg.State.Changelog != "" {
+		return filepath.FromSlash(cfg.State.Changelog)
+	}
+	return ""
+}
+
+// StateAuditLog returns the path of the audit log, or the empty string if it is not configured.
+func (cfg *Config) StateAuditLog() string {
+	if cfg.State != nil && cfg.State.AuditLog != "" {
+		return filepath.FromSlash(cfg.State.AuditLog)
+	}
+	return ""
+}
+
+// StateLayout returns the layout of the status files.
+// The layout is validated when the config is loaded.
+func (cfg *Config) StateLayout() *layout.Layout {
+	var s string
+	if cfg.State != nil {
+		s = cfg.State.Layout
+	}
+	l, err := layout.Parse(s)
+	if err != nil {
+		l, _ = layout.Parse(layout.Default)
+	}
+	return l
+}
+
+func (s *State) validate() error {
+	if s == nil {
+		return nil
+	}
+	if s.Dir != "" && !isCleanRelativePath(s.Dir) {
+		return errors.New("dir must be a clean relative path")
+	}
+	if s.Changelog != "" && !isCleanRelativePath(s.Changelog) {
+		return errors.New("changelog must be a clean relative path")
+	}
+	if s.AuditLog != "" && !isCleanRelativePath(s.AuditLog) {
+		return errors.New("auditLog must be a clean relative path")
+	}
+	if _, err := layout.Parse(s.Layout); err != nil {
+		return err
+	}
+	if s.History < 0 {
+		return errors.New("history must not be negative")
+	}
+	return nil
+}
+
+// isCleanRelativePath reports whether p is a clean relative path in the repository.
+func isCleanRelativePath(p string) bool {
+	return !path.IsAbs(p) && path.Clean(p) == p && p != "." && p != ".." && !strings.HasPrefix(p, "../")
+}
+
+// Tenant is a named set of the groups of the images, with its own notification, schedule and state,
+// so that one deployment of the checker serves several teams without them seeing each other's noise.
+type Tenant struct {
+	Name string `json:"name"`
+
+	// Groups are the groups of the images that belong to the tenant.
+	Groups []string `json:"groups"`
+
+	// StatePrefix is the directory that the state of the tenant is stored under.
+	// It is "tenants/<name>" by default.
+	StatePrefix string `json:"statePrefix,omitempty"`
+
+	// CheckInterval is the default check interval of the images of the tenant.
+	CheckInterval Duration `json:"checkInterval,omitempty"`
+
+	// Notify overrides the notification of the config.
+	Notify *Notify `json:"notify,omitempty"`
+}
+
+// Dir returns the directory that the state of the tenant is stored under.
+func (t *Tenant) Dir() string {
+	if t.StatePrefix != "" {
+		return filepath.FromSlash(t.StatePrefix)
+	}
+	return filepath.Join("tenants", t.Name)
+}
+
+// Tenant returns the tenant with the name, or nil if it is not found.
+func (cfg *Config) Tenant(name string) *Tenant {
+	for _, t := range cfg.Tenants {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// TenantOf returns the tenant that the image belongs to, or nil if it belongs to no tenants.
+func (cfg *Config) TenantOf(img *Image) *Tenant {
+	for _, t := range cfg.Tenants {
+		for _, g := range t.Groups {
+			if img.InGroup(g) {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+// Image is a target image.
+// In the config file, it is either a string of the image reference or an object with the options.
+type Image struct {
+	// Image is the image reference, e.g. "alpine:3.17".
+	// The tag may have wildcards, e.g. "alpine:3.*", or a version constraint, e.g. "golang:>=1.21 <1.23",
+	// to track all tags matching the pattern.
+	Image string `json:"image"`
+
+	// Accept overrides the Accept header of the manifest request.
+	Accept []string `json:"accept,omitempty"`
+
+	// Auth is the credentials for the registry of the image.
+	Auth *Auth `json:"auth,omitempty"`
+
+	// Timeout overrides the timeout for checking the image,
+	// e.g. for private registries across a VPN.
+	Timeout Duration `json:"timeout,omitempty"`
+
+	// CheckInterval is the minimum interval between the checks of the image.
+	// The image is checked in every run if it is zero.
+	CheckInterval Duration `json:"checkInterval,omitempty"`
+
+	// Groups are the names of the groups that the image belongs to, e.g. "alpine" or "lambda".
+	Groups []string `json:"groups,omitempty"`
+
+	// Disabled excludes the image from the checks, keeping its stored manifests.
+	// It is set by check when the tag is removed from the registry.
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Platforms are the platforms to track in the form of os/arch[/variant], e.g. "linux/amd64".
+	// The changes of the other platforms are ignored. All platforms are tracked if it is empty.
+	Platforms []string `json:"platforms,omitempty"`
+
+	// WaitAllPlatforms delays the update until all tracked platforms have changed,
+	// so that a partial update during staggered rebuilds of a multi-platform image is not reported as updated.
+	WaitAllPlatforms bool `json:"waitAllPlatforms,omitempty"`
+
+	// StaleAfter overrides the period after which the image is reported as stale if it hasn't changed.
+	StaleAfter Duration `json:"staleAfter,omitempty"`
+
+	// Paused excludes the image from the checks temporarily, keeping its stored manifests,
+	// e.g. during a known upstream churn or an incident response.
+	Paused bool `json:"paused,omitempty"`
+
+	// Metadata is the information about the image for the people, included in the reports.
+	Metadata *Metadata `json:"metadata,omitempty"`
+
+	// Immutable asserts that the tag never changes, e.g. the release tags like "myapp:v1.2.3".
+	// A change of the tag is reported as an alert instead of an update, because it indicates a supply-chain problem.
+	Immutable bool `json:"immutable,omitempty"`
+}
+
+// PlatformFilters returns the parsed Platforms.
+func (img *Image) PlatformFilters() ([]*registry.Platform, error) {
+	var filters []*registry.Platform
+	for _, s := range img.Platforms {
+		p, err := registry.ParsePlatform(s)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, p)
+	}
+	return filters, nil
+}
+
+// InGroup reports whether the image belongs to the group.
+func (img *Image) InGroup(name string) bool {
+	for _, g := range img.Groups {
+		if g == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Metadata is the information about an image, e.g. who should see its updates.
+type Metadata struct {
+	// Owner is the owning team of the image, e.g. "platform-team".
+	Owner string `json:"owner,omitempty"`
+
+	// Channel is the chat channel to notify the updates, e.g. "#platform".
+	Channel string `json:"channel,omitempty"`
+
+	// Description describes the image, e.g. "the base image of the web servers".
+	Description string `json:"description,omitempty"`
+
+	// ReleaseNotes is the URL of the upstream changelog.
+	ReleaseNotes string `json:"releaseNotes,omitempty"`
+}
+
+// Auth is the credentials for a registry.
+// The values may refer to the environment variables, e.g. "${REGISTRY_PASSWORD}",
+// or to the secrets in the external stores, e.g. "aws-secretsmanager://name#password" or "file:///run/secrets/password".
+type Auth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Credentials returns the username and the password with the references resolved.
+func (a *Auth) Credentials(ctx context.Context) (username, password string, err error) {
+	username, err = Resolve(ctx, a.Username)
+	if err != nil {
+		return "", "", fmt.Errorf("auth.username: %w", err)
+	}
+	password, err = Resolve(ctx, a.Password)
+	if err != nil {
+		return "", "", fmt.Errorf("auth.password: %w", err)
+	}
+	return username, password, nil
+}
+
+// Resolve expands the environment variables in the value, and then resolves the reference to the secret.
+func Resolve(ctx context.Context, value string) (string, error) {
+	value, err := Expand(value)
+	if err != nil {
+		return "", err
+	}
+	return secret.Resolve(ctx, value)
+}
+
+// imageOptions is Image without the custom unmarshaler.
+type imageOptions Image
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (img *Image) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var ref string
+		if err := json.Unmarshal(data, &ref); err != nil {
+			return err
+		}
+		*img = Image{Image: ref}
+		return nil
+	}
+
+	var opts imageOptions
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&opts); err != nil {
+		return err
+	}
+	*img = Image(opts)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+// The images without options are marshaled into strings, to keep the config file short.
+func (img *Image) MarshalJSON() ([]byte, error) {
+	if !img.hasOptions() {
+		return json.Marshal(img.Image)
+	}
+	return json.Marshal((*imageOptions)(img))
+}
+
+// hasOptions reports whether any options other than the image reference are set.
+func (img *Image) hasOptions() bool {
+	return len(img.Accept) > 0 || img.Auth != nil || img.Timeout != 0 || img.CheckInterval != 0 || len(img.Groups) > 0 || len(img.Platforms) > 0 || img.Disabled || img.WaitAllPlatforms || img.StaleAfter != 0 || img.Immutable || img.Paused || img.Metadata != nil
+}
+
+// Default returns the config that tracks the images with no options.
+func Default(images []string) *Config {
+	cfg := &Config{}
+	for _, image := range images {
+		cfg.Images = append(cfg.Images, &Image{Image: image})
+	}
+	return cfg
+}
+
+// Load loads the config file. The files with the extension .yaml or .yml are parsed as YAML, and the others as JSON.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if isYAML(path) {
+		// the YAML is converted into JSON, so that it is decoded as strictly as the JSON is.
+		data, err = yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse parses the config, and validates it.
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// groupNameRegexp is the pattern of the group names.
+var groupNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// Groups returns the sorted names of the groups in the config.
+func (cfg *Config) Groups() []string {
+	seen := map[string]struct{}{}
+	var groups []string
+	for _, img := range cfg.Images {
+		for _, g := range img.Groups {
+			if _, ok := seen[g]; !ok {
+				seen[g] = struct{}{}
+				groups = append(groups, g)
+			}
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// Validate checks that the images are valid references,
+// and that the same image and the conflicting credentials are not configured.
+func (cfg *Config) Validate() error {
+	var errs []string
+	if cfg.Timeout < 0 {
+		errs = append(errs, "timeout: must not be negative")
+	}
+	if cfg.RunTimeout < 0 {
+		errs = append(errs, "runTimeout: must not be negative")
+	}
+	if cfg.Spread < 0 {
+		errs = append(errs, "spread: must not be negative")
+	}
+	if cfg.StaleAfter < 0 {
+		errs = append(errs, "staleAfter: must not be negative")
+	}
+	errs = append(errs, cfg.validateTenants()...)
+	if err := cfg.Commit.validate(); err != nil {
+		errs = append(errs, fmt.Sprintf("commit: %v", err))
+	}
+	if err := cfg.State.validate(); err != nil {
+		errs = append(errs, fmt.Sprintf("state: %v", err))
+	}
+	images := make(map[string]string, len(cfg.Images))
+	auths := map[string]*Auth{}
+	for i, img := range cfg.Images {
+		if img == nil {
+			errs = append(errs, fmt.Sprintf("images[%d]: empty", i))
+			continue
+		}
+		ref, err := registry.ParseReferencePattern(img.Image)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("images[%d]: %v", i, err))
+			continue
+		}
+		key := ref.String()
+		if prev, ok := images[key]; ok {
+			errs = append(errs, fmt.Sprintf("images[%d]: %s is the same image as %s", i, img.Image, prev))
+			continue
+		}
+		images[key] = img.Image
+
+		if img.Timeout < 0 {
+			errs = append(errs, fmt.Sprintf("images[%d]: timeout must not be negative", i))
+		}
+		if img.CheckInterval < 0 {
+			errs = append(errs, fmt.Sprintf("images[%d]: checkInterval must not be negative", i))
+		}
+		if img.StaleAfter < 0 {
+			errs = append(errs, fmt.Sprintf("images[%d]: staleAfter must not be negative", i))
+		}
+		for _, g := range img.Groups {
+			if !groupNameRegexp.MatchString(g) {
+				errs = append(errs, fmt.Sprintf("images[%d]: invalid group name %q", i, g))
+			}
+		}
+		if _, err := img.PlatformFilters(); err != nil {
+			errs = append(errs, fmt.Sprintf("images[%d]: %v", i, err))
+		}
+		tenants := map[string]bool{}
+		for _, t := range cfg.Tenants {
+			for _, g := range t.Groups {
+				if img.InGroup(g) {
+					tenants[t.Name] = true
+				}
+			}
+		}
+		if len(tenants) > 1 {
+			errs = append(errs, fmt.Sprintf("images[%d]: %s belongs to multiple tenants", i, img.Image))
+		}
+		if img.Metadata != nil && img.Metadata.ReleaseNotes != "" {
+			if u, err := url.Parse(img.Metadata.ReleaseNotes); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				errs = append(errs, fmt.Sprintf("images[%d]: metadata.releaseNotes must be an http(s) URL", i))
+			}
+		}
+
+		if img.Auth != nil {
+			for _, v := range []string{img.Auth.Username, img.Auth.Password} {
+				if err := checkExpand(v); err != nil {
+					errs = append(errs, fmt.Sprintf("images[%d]: auth: %v", i, err))
+				}
+			}
+			if prev, ok := auths[ref.Host]; ok && *prev != *img.Auth {
+				errs = append(errs, fmt.Sprintf("images[%d]: the credentials for %s conflict with another image", i, ref.Host))
+			}
+			auths[ref.Host] = img.Auth
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New("invalid config:\n" + strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// validateTenants checks the tenants and the notifications.
+func (cfg *Config) validateTenants() []string {
+	var errs []string
+	if err := cfg.Notify.validate(); err != nil {
+		errs = append(errs, fmt.Sprintf("notify: %v", err))
+	}
+	groups := map[string]bool{}
+	for _, g := range cfg.Groups() {
+		groups[g] = true
+	}
+	names := map[string]bool{}
+	dirs := map[string]bool{}
+	for i, t := range cfg.Tenants {
+		if t == nil {
+			errs = append(errs, fmt.Sprintf("tenants[%d]: empty", i))
+			continue
+		}
+		if !groupNameRegexp.MatchString(t.Name) {
+			errs = append(errs, fmt.Sprintf("tenants[%d]: invalid tenant name %q", i, t.Name))
+		}
+		if names[t.Name] {
+			errs = append(errs, fmt.Sprintf("tenants[%d]: duplicated tenant name %q", i, t.Name))
+		}
+		names[t.Name] = true
+		if len(t.Groups) == 0 {
+			errs = append(errs, fmt.Sprintf("tenants[%d]: no groups", i))
+		}
+		for _, g := range t.Groups {
+			if !groups[g] {
+				errs = append(errs, fmt.Sprintf("tenants[%d]: unknown group %q", i, g))
+			}
+		}
+		if t.StatePrefix != "" && !isCleanRelativePath(t.StatePrefix) {
+			errs = append(errs, fmt.Sprintf("tenants[%d]: statePrefix must be a clean relative path", i))
+		}
+		if dir := filepath.ToSlash(t.Dir()); dirs[dir] {
+			errs = append(errs, fmt.Sprintf("tenants[%d]: the state of %s conflicts with another tenant", i, t.Name))
+		} else {
+			dirs[dir] = true
+		}
+		if t.CheckInterval < 0 {
+			errs = append(errs, fmt.Sprintf("tenants[%d]: checkInterval must not be negative", i))
+		}
+		if err := t.Notify.validate(); err != nil {
+			errs = append(errs, fmt.Sprintf("tenants[%d]: notify: %v", i, err))
+		}
+	}
+	return errs
+}
+
+func (n *Notify) validate() error {
+	if n == nil {
+		return nil
+	}
+	for _, v := range []string{n.Webhook, n.Secret, n.Slack} {
+		if err := checkExpand(v); err != nil {
+			return err
+		}
+	}
+	if n.Webhook == "" && n.Slack == "" {
+		return errors.New("webhook or slack is required")
+	}
+	if n.Secret != "" && n.Webhook == "" {
+		return errors.New("secret requires webhook")
+	}
+	if n.Webhook != "" && !isNotifyURL(n.Webhook) {
+		return errors.New("webhook must be an http(s) URL")
+	}
+	if n.Slack != "" && !isNotifyURL(n.Slack) {
+		return errors.New("slack must be an http(s) URL")
+	}
+	return nil
+}
+
+// isNotifyURL reports whether s is an http(s) URL, or refers to the environment variables or the secrets, which are known at runtime.
+func isNotifyURL(s string) bool {
+	if strings.Contains(s, "${") || secret.IsReference(s) {
+		return true
+	}
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
+
+// Save writes the config into the file, in YAML if the extension is .yaml or .yml, or in JSON otherwise.
+// The keys of the YAML are sorted, and its comments are not kept.
+func (cfg *Config) Save(path string) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if isYAML(path) {
+		data, err = yaml.JSONToYAML(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	// write to a temporary file and rename it, not to break the config on failure.
+	f, err := os.CreateTemp(filepath.Dir(path), ".images-*"+filepath.Ext(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Find returns the index of the image in the config, or -1 if it is not found.
+// The images are compared after normalization, so "alpine" matches "docker.io/library/alpine:latest".
+func (cfg *Config) Find(image string) int {
+	ref, err := registry.ParseReferencePattern(image)
+	if err != nil {
+		return -1
+	}
+	key := ref.String()
+	for i, img := range cfg.Images {
+		r, err := registry.ParseReferencePattern(img.Image)
+		if err != nil {
+			continue
+		}
+		if r.String() == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Add adds the image to the config.
+func (cfg *Config) Add(img *Image) error {
+	if _, err := registry.ParseReferencePattern(img.Image); err != nil {
+		return err
+	}
+	if i := cfg.Find(img.Image); i >= 0 {
+		return fmt.Errorf("%s is already tracked as %s", img.Image, cfg.Images[i].Image)
+	}
+	cfg.Images = append(cfg.Images, img)
+	return nil
+}
+
+// Remove removes the image from the config, and returns the removed one.
+func (cfg *Config) Remove(image string) (*Image, error) {
+	i := cfg.Find(image)
+	if i < 0 {
+		return nil, fmt.Errorf("%s is not tracked", image)
+	}
+	img := cfg.Images[i]
+	cfg.Images = append(cfg.Images[:i], cfg.Images[i+1:]...)
+	return img, nil
+}
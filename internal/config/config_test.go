@@ -0,0 +1,404 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse([]byte(`{
+		"timeout": "15s",
+		"runTimeout": "10m",
+		"spread": "5m",
+		"staleAfter": "180d",
+		"notify": {"webhook": "https://example.com/hook", "slack": "${SLACK_WEBHOOK_URL}"},
+		"commit": {"name": "diuc", "email": "diuc@example.com", "message": "chore: {{.Subject}}", "signingKey": "file:///run/secrets/signing-key", "tag": "updates/{{.Time.Format \"2006-01-02T15-04\"}}", "release": true},
+		"state": {"dir": "state/manifests", "layout": "flat", "childManifests": true, "imageConfigs": true, "history": 10, "changelog": "UPDATES.md", "auditLog": "audit.jsonl"},
+		"tenants": [
+			{
+				"name": "app",
+				"groups": ["app"],
+				"checkInterval": "1h",
+				"notify": {"webhook": "${APP_WEBHOOK}", "secret": "file:///run/secrets/app-webhook"}
+			}
+		],
+		"images": [
+			"alpine:3.17",
+			{
+				"image": "ghcr.io/foo/bar:v1",
+				"accept": ["application/vnd.oci.image.manifest.v1+json"],
+				"auth": {"username": "user", "password": "pass"},
+				"timeout": "1m30s",
+				"checkInterval": "24h",
+				"groups": ["app", "ghcr"],
+				"platforms": ["linux/amd64", "linux/arm64"],
+				"staleAfter": "720h",
+				"immutable": true,
+				"paused": true,
+				"metadata": {
+					"owner": "app-team",
+					"channel": "#app",
+					"description": "the app server",
+					"releaseNotes": "https://github.com/foo/bar/releases"
+				}
+			}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Config{
+		Timeout:    Duration(15 * time.Second),
+		RunTimeout: Duration(10 * time.Minute),
+		Spread:     Duration(5 * time.Minute),
+		StaleAfter: Duration(180 * 24 * time.Hour),
+		Notify:     &Notify{Webhook: "https://example.com/hook", Slack: "${SLACK_WEBHOOK_URL}"},
+		Commit:     &Commit{Name: "diuc", Email: "diuc@example.com", Message: "chore: {{.Subject}}", SigningKey: "file:///run/secrets/signing-key", Tag: `updates/{{.Time.Format "2006-01-02T15-04"}}`, Release: true},
+		State:      &State{Dir: "state/manifests", Layout: "flat", ChildManifests: true, ImageConfigs: true, History: 10, Changelog: "UPDATES.md", AuditLog: "audit.jsonl"},
+		Tenants: []*Tenant{
+			{
+				Name:          "app",
+				Groups:        []string{"app"},
+				CheckInterval: Duration(time.Hour),
+				Notify:        &Notify{Webhook: "${APP_WEBHOOK}", Secret: "file:///run/secrets/app-webhook"},
+			},
+		},
+		Images: []*Image{
+			{Image: "alpine:3.17"},
+			{
+				Image:         "ghcr.io/foo/bar:v1",
+				Accept:        []string{"application/vnd.oci.image.manifest.v1+json"},
+				Auth:          &Auth{Username: "user", Password: "pass"},
+				Timeout:       Duration(90 * time.Second),
+				CheckInterval: Duration(24 * time.Hour),
+				Groups:        []string{"app", "ghcr"},
+				Platforms:     []string{"linux/amd64", "linux/arm64"},
+				StaleAfter:    Duration(720 * time.Hour),
+				Immutable:     true,
+				Paused:        true,
+				Metadata: &Metadata{
+					Owner:        "app-team",
+					Channel:      "#app",
+					Description:  "the app server",
+					ReleaseNotes: "https://github.com/foo/bar/releases",
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("want %#v, got %#v", want, cfg)
+	}
+
+	// round trip
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %#v, got %#v", want, got)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []string{
+		// unknown keys
+		`{"targets": ["alpine:3.17"]}`,
+		`{"images": [{"image": "alpine:3.17", "platform": "linux/amd64"}]}`,
+
+		// invalid timeouts
+		`{"timeout": "10", "images": ["alpine:3.17"]}`,
+		`{"images": [{"image": "alpine:3.17", "timeout": "-1s"}]}`,
+		`{"staleAfter": "d", "images": ["alpine:3.17"]}`,
+		`{"staleAfter": "-1d", "images": ["alpine:3.17"]}`,
+
+		// invalid group names
+		`{"images": [{"image": "alpine:3.17", "groups": ["Alpine Linux"]}]}`,
+
+		// invalid platforms
+		`{"images": [{"image": "alpine:3.17", "platforms": ["amd64"]}]}`,
+
+		// invalid version constraints
+		`{"images": ["golang:>=foo"]}`,
+
+		// invalid references
+		`{"images": ["../../etc:tag"]}`,
+
+		// invalid tenants
+		`{"tenants": [{"name": "app", "groups": ["unknown"]}], "images": ["alpine:3.17"]}`,
+		`{"tenants": [{"name": "app", "groups": []}], "images": ["alpine:3.17"]}`,
+		`{"tenants": [{"name": "app", "groups": ["app"], "statePrefix": "../app"}], "images": [{"image": "alpine:3.17", "groups": ["app"]}]}`,
+		`{"tenants": [{"name": "a", "groups": ["a"]}, {"name": "b", "groups": ["b"]}], "images": [{"image": "alpine:3.17", "groups": ["a", "b"]}]}`,
+		`{"notify": {"webhook": "ftp://example.com/hook"}, "images": ["alpine:3.17"]}`,
+		`{"notify": {"slack": "slack.example.com"}, "images": ["alpine:3.17"]}`,
+		`{"notify": {}, "images": ["alpine:3.17"]}`,
+		`{"notify": {"slack": "https://hooks.slack.com/services/x", "secret": "s"}, "images": ["alpine:3.17"]}`,
+
+		// invalid commits
+		`{"commit": {"email": "diuc"}, "images": ["alpine:3.17"]}`,
+		`{"commit": {"message": "{{.Subject"}, "images": ["alpine:3.17"]}`,
+		`{"commit": {"signingKey": "${SIGNING_KEY"}, "images": ["alpine:3.17"]}`,
+		`{"commit": {"tag": "updates/{{.Time"}, "images": ["alpine:3.17"]}`,
+
+		// invalid states
+		`{"state": {"dir": "../manifests"}, "images": ["alpine:3.17"]}`,
+		`{"state": {"layout": "{host}/{tag}.json"}, "images": ["alpine:3.17"]}`,
+		`{"state": {"layout": "unknown"}, "images": ["alpine:3.17"]}`,
+		`{"state": {"history": -1}, "images": ["alpine:3.17"]}`,
+		`{"state": {"changelog": "/UPDATES.md"}, "images": ["alpine:3.17"]}`,
+		`{"state": {"auditLog": "../audit.jsonl"}, "images": ["alpine:3.17"]}`,
+
+		// invalid release notes
+		`{"images": [{"image": "alpine:3.17", "metadata": {"releaseNotes": "javascript:alert(1)"}}]}`,
+
+		// invalid variable references
+		`{"images": [{"image": "ghcr.io/foo/bar:v1", "auth": {"username": "user", "password": "${PASSWORD"}}]}`,
+
+		// duplicated images
+		`{"images": ["alpine:3.17", "docker.io/library/alpine:3.17"]}`,
+
+		// conflicting credentials
+		`{"images": [
+			{"image": "ghcr.io/foo/bar:v1", "auth": {"username": "user", "password": "pass"}},
+			{"image": "ghcr.io/foo/baz:v1", "auth": {"username": "user", "password": "other"}}
+		]}`,
+	}
+	for _, tt := range tests {
+		if _, err := Parse([]byte(tt)); err == nil {
+			t.Errorf("%s: want error, got nil", tt)
+		}
+	}
+}
+
+func TestGroups(t *testing.T) {
+	cfg, err := Parse([]byte(`{
+		"images": [
+			{"image": "alpine:3.17", "groups": ["alpine", "base"]},
+			{"image": "debian:bookworm-slim", "groups": ["base"]},
+			"ubuntu:22.04",
+			{"image": "debian:*-slim", "groups": ["base"]}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cfg.Groups(), []string{"alpine", "base"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if cfg.Find("docker.io/library/debian:*-slim") != 3 {
+		t.Error("want the pattern to be found")
+	}
+	if !cfg.Images[0].InGroup("alpine") || cfg.Images[1].InGroup("alpine") || cfg.Images[2].InGroup("base") {
+		t.Error("unexpected group membership")
+	}
+}
+
+func TestAddRemove(t *testing.T) {
+	cfg := Default([]string{"alpine:3.17", "ubuntu:22.04"})
+	if err := cfg.Add(&Image{Image: "ubuntu:20.04"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Add(&Image{Image: "docker.io/library/alpine:3.17"}); err == nil {
+		t.Error("want error for the duplicated image, got nil")
+	}
+	if err := cfg.Add(&Image{Image: "../../etc:tag"}); err == nil {
+		t.Error("want error for the invalid image, got nil")
+	}
+
+	img, err := cfg.Remove("docker.io/library/ubuntu:22.04")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Image != "ubuntu:22.04" {
+		t.Errorf("want ubuntu:22.04, got %s", img.Image)
+	}
+	if _, err := cfg.Remove("debian:bookworm"); err == nil {
+		t.Error("want error for the untracked image, got nil")
+	}
+
+	path := filepath.Join(t.TempDir(), "images.json")
+	if err := cfg.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Default([]string{"alpine:3.17", "ubuntu:20.04"})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %#v, got %#v", want, got)
+	}
+}
+
+func TestLoad_YAML(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "images.yaml")
+	data := `# the images to track
+timeout: 15s
+images:
+  - alpine:3.17
+  - image: ghcr.io/example/app:v1
+    platforms: [linux/amd64, linux/arm64]
+    auth:
+      username: user
+      password: ${APP_REGISTRY_PASSWORD}
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Duration(cfg.Timeout) != 15*time.Second {
+		t.Errorf("want 15s, got %v", time.Duration(cfg.Timeout))
+	}
+	if len(cfg.Images) != 2 || cfg.Images[0].Image != "alpine:3.17" || cfg.Images[1].Image != "ghcr.io/example/app:v1" {
+		t.Fatalf("unexpected images: %v", cfg.Images)
+	}
+	if got, want := cfg.Images[1].Platforms, []string{"linux/amd64", "linux/arm64"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if cfg.Images[1].Auth == nil || cfg.Images[1].Auth.Username != "user" {
+		t.Errorf("unexpected auth: %v", cfg.Images[1].Auth)
+	}
+
+	// the config is saved in YAML, and loaded again.
+	if err := cfg.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if json.Valid(saved) {
+		t.Errorf("want YAML, got JSON: %s", saved)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, cfg) {
+		t.Errorf("want %#v, got %#v", cfg, got)
+	}
+
+	// the unknown fields are errors, as in JSON.
+	if err := os.WriteFile(path, []byte("images: [alpine]\nunknown: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("want error, got nil")
+	}
+}
+
+func TestExpand(t *testing.T) {
+	env := map[string]string{"USER": "foo", "PASSWORD": "p@$$"}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"${USER}", "foo"},
+		{"${USER}:${PASSWORD}", "foo:p@$$"},
+		{"$$USER", "$USER"},
+		{"$USER", "$USER"},
+		{"100$", "100$"},
+	}
+	for _, tt := range tests {
+		got, err := expand(tt.in, lookup)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: want %q, got %q", tt.in, tt.want, got)
+		}
+	}
+
+	for _, in := range []string{"${MISSING}", "${USER", "${}"} {
+		if _, err := expand(in, lookup); err == nil {
+			t.Errorf("%q: want error, got nil", in)
+		}
+	}
+}
+
+func TestTenantOf(t *testing.T) {
+	cfg, err := Parse([]byte(`{
+		"tenants": [
+			{"name": "app", "groups": ["app"]},
+			{"name": "infra", "groups": ["infra"], "statePrefix": "state/infra"}
+		],
+		"images": [
+			"alpine:3.17",
+			{"image": "ghcr.io/foo/app:v1", "groups": ["app"]},
+			{"image": "ghcr.io/foo/infra:v1", "groups": ["infra"]}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tenant := cfg.TenantOf(cfg.Images[0]); tenant != nil {
+		t.Errorf("want no tenant, got %s", tenant.Name)
+	}
+	if tenant := cfg.TenantOf(cfg.Images[1]); tenant != cfg.Tenant("app") {
+		t.Errorf("want app, got %v", tenant)
+	}
+	if dir := cfg.Tenant("app").Dir(); dir != filepath.Join("tenants", "app") {
+		t.Errorf("unexpected dir: %s", dir)
+	}
+	if dir := cfg.Tenant("infra").Dir(); dir != filepath.Join("state", "infra") {
+		t.Errorf("unexpected dir: %s", dir)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old, err := Parse([]byte(`{
+		"timeout": "10s",
+		"tenants": [{"name": "app", "groups": ["app"]}],
+		"images": [
+			"alpine:3.17",
+			{"image": "ghcr.io/foo/bar:v1", "groups": ["app"]},
+			"debian:bullseye"
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	new, err := Parse([]byte(`{
+		"timeout": "20s",
+		"notify": {"webhook": "https://example.com/hook"},
+		"images": [
+			"docker.io/library/alpine:3.17",
+			{"image": "ghcr.io/foo/bar:v1", "paused": true},
+			"debian:bookworm"
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Diff(old, new)
+	want := []string{
+		"~ timeout: 10s -> 20s",
+		"~ notify",
+		"- tenant app",
+		"~ ghcr.io/foo/bar:v1",
+		"+ debian:bookworm",
+		"- debian:bullseye",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if d := Diff(new, new); len(d) != 0 {
+		t.Errorf("want no changes, got %q", d)
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// Diff returns the changes of the effective configuration from old to new, one per line, e.g. "+ alpine:3.18".
+// The values of the credentials and the notifications are not included, because they may be secrets.
+func Diff(old, new *Config) []string {
+	var lines []string
+	durations := []struct {
+		name     string
+		old, new Duration
+	}{
+		{"timeout", old.Timeout, new.Timeout},
+		{"runTimeout", old.RunTimeout, new.RunTimeout},
+		{"spread", old.Spread, new.Spread},
+		{"staleAfter", old.StaleAfter, new.StaleAfter},
+	}
+	for _, d := range durations {
+		if d.old != d.new {
+			lines = append(lines, fmt.Sprintf("~ %s: %s -> %s", d.name, time.Duration(d.old), time.Duration(d.new)))
+		}
+	}
+	if !reflect.DeepEqual(old.Notify, new.Notify) {
+		lines = append(lines, "~ notify")
+	}
+	if !reflect.DeepEqual(old.Commit, new.Commit) {
+		lines = append(lines, "~ commit")
+	}
+	if !reflect.DeepEqual(old.State, new.State) {
+		lines = append(lines, "~ state")
+	}
+
+	oldTenants := map[string]*Tenant{}
+	for _, t := range old.Tenants {
+		oldTenants[t.Name] = t
+	}
+	newTenants := map[string]*Tenant{}
+	for _, t := range new.Tenants {
+		newTenants[t.Name] = t
+		if o, ok := oldTenants[t.Name]; !ok {
+			lines = append(lines, "+ tenant "+t.Name)
+		} else if !reflect.DeepEqual(o, t) {
+			lines = append(lines, "~ tenant "+t.Name)
+		}
+	}
+	for _, t := range old.Tenants {
+		if _, ok := newTenants[t.Name]; !ok {
+			lines = append(lines, "- tenant "+t.Name)
+		}
+	}
+
+	oldImages := imagesByKey(old)
+	newImages := imagesByKey(new)
+	for _, img := range new.Images {
+		o, ok := oldImages[imageKey(img)]
+		if !ok {
+			lines = append(lines, "+ "+img.Image)
+		} else if !sameImage(o, img) {
+			lines = append(lines, "~ "+img.Image)
+		}
+	}
+	for _, img := range old.Images {
+		if _, ok := newImages[imageKey(img)]; !ok {
+			lines = append(lines, "- "+img.Image)
+		}
+	}
+	return lines
+}
+
+// imageKey returns the normalized reference of the image, so that "alpine" and "docker.io/library/alpine" are the same.
+func imageKey(img *Image) string {
+	ref, err := registry.ParseReferencePattern(img.Image)
+	if err != nil {
+		return img.Image
+	}
+	return ref.String()
+}
+
+func imagesByKey(cfg *Config) map[string]*Image {
+	images := make(map[string]*Image, len(cfg.Images))
+	for _, img := range cfg.Images {
+		images[imageKey(img)] = img
+	}
+	return images
+}
+
+// sameImage reports whether the images have the same options, ignoring how the references are written.
+func sameImage(a, b *Image) bool {
+	x, y := *a, *b
+	x.Image, y.Image = "", ""
+	return reflect.DeepEqual(x, y)
+}
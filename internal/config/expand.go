@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Expand replaces ${VAR} in the value with the environment variable,
+// so that the secrets never need to be written into the config file.
+// "$$" is a literal "$". It returns an error if the variable is not set.
+func Expand(s string) (string, error) {
+	return expand(s, os.LookupEnv)
+}
+
+func expand(s string, lookup func(string) (string, bool)) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated variable reference in %q", s)
+			}
+			name := s[i+2 : i+2+end]
+			if name == "" {
+				return "", fmt.Errorf("empty variable reference in %q", s)
+			}
+			value, ok := lookup(name)
+			if !ok {
+				return "", fmt.Errorf("environment variable %s is not set", name)
+			}
+			b.WriteString(value)
+			i += end + 2
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), nil
+}
+
+// checkExpand checks the syntax of the variable references without the environment variables.
+func checkExpand(s string) error {
+	_, err := expand(s, func(string) (string, bool) { return "", true })
+	return err
+}
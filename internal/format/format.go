@@ -0,0 +1,54 @@
+// Package format writes the results of the commands in the format chosen by the user.
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"text/template"
+)
+
+// Write writes v to w in the format.
+// The format is one of "table", "json", "yaml" or a Go template, e.g. "{{.Image}}".
+// If v is a slice, the template is applied to each element.
+// table writes v as a human readable table, and it is used for "table" and the empty format.
+func Write(w io.Writer, format string, v interface{}, table func(w io.Writer) error) error {
+	switch format {
+	case "", "table":
+		return table(w)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "yaml":
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return encodeYAML(w, data)
+	}
+
+	tmpl, err := template.New("format").Parse(format)
+	if err != nil {
+		return fmt.Errorf("invalid format: %w", err)
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return executeLine(w, tmpl, v)
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if err := executeLine(w, tmpl, rv.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func executeLine(w io.Writer, tmpl *template.Template, v interface{}) error {
+	if err := tmpl.Execute(w, v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,113 @@
+package format
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+type item struct {
+	Image     string   `json:"image"`
+	Checked   bool     `json:"checked"`
+	Platforms []string `json:"platforms"`
+	Size      int64    `json:"size,omitempty"`
+}
+
+var items = []*item{
+	{Image: "alpine:3.17", Checked: true, Platforms: []string{"linux/amd64", "linux/arm/v7"}, Size: 1024},
+	{Image: "ghcr.io/foo/bar:true", Platforms: []string{}},
+}
+
+func TestWrite_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "json", items[1:], nil); err != nil {
+		t.Fatal(err)
+	}
+	want := `[
+  {
+    "image": "ghcr.io/foo/bar:true",
+    "checked": false,
+    "platforms": []
+  }
+]
+`
+	if got := buf.String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestWrite_YAML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "yaml", items, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := `- image: alpine:3.17
+  checked: true
+  platforms:
+    - linux/amd64
+    - linux/arm/v7
+  size: 1024
+- image: ghcr.io/foo/bar:true
+  checked: false
+  platforms: []
+`
+	if got := buf.String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestWrite_Template(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "{{.Image}} {{len .Platforms}}", items, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := "alpine:3.17 2\nghcr.io/foo/bar:true 0\n"
+	if got := buf.String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	if err := Write(&buf, "{{.Image", items, nil); err == nil {
+		t.Error("want error, got nil")
+	}
+}
+
+func TestWrite_Table(t *testing.T) {
+	var buf bytes.Buffer
+	table := func(w io.Writer) error {
+		_, err := io.WriteString(w, "table\n")
+		return err
+	}
+	for _, format := range []string{"", "table"} {
+		buf.Reset()
+		if err := Write(&buf, format, items, table); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != "table\n" {
+			t.Errorf("%q: want %q, got %q", format, "table\n", got)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"alpine:3.17", "alpine:3.17"},
+		{"sha256:0123abcd", "sha256:0123abcd"},
+		{"application/vnd.oci.image.index.v1+json", "application/vnd.oci.image.index.v1+json"},
+		{"(single platform)", "(single platform)"},
+		{"", `""`},
+		{"true", `"true"`},
+		{"3.17", `"3.17"`},
+		{"key: value", `"key: value"`},
+		{"-foo", `"-foo"`},
+		{"#comment", `"#comment"`},
+		{"line\nbreak", `"line\nbreak"`},
+	}
+	for _, tt := range tests {
+		if got := quote(tt.in); got != tt.want {
+			t.Errorf("quote(%q): want %s, got %s", tt.in, tt.want, got)
+		}
+	}
+}
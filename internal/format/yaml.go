@@ -0,0 +1,173 @@
+package format
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// node is a JSON value, keeping the order of the object keys.
+type node struct {
+	// scalar is the YAML representation of the value if it is not an object or an array.
+	scalar string
+
+	isObject bool
+	isArray  bool
+	keys     []string
+	values   []*node
+}
+
+// encodeYAML converts the JSON data into YAML.
+// The encoding/json package is used for the marshaling, so the field names and omitempty are the same as JSON.
+func encodeYAML(w io.Writer, data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	n, err := parseNode(dec)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	writeNode(bw, n, 0, false)
+	return bw.Flush()
+}
+
+func parseNode(dec *json.Decoder) (*node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok := tok.(type) {
+	case json.Delim:
+		switch tok {
+		case '{':
+			n := &node{isObject: true}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := parseNode(dec)
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, key.(string))
+				n.values = append(n.values, value)
+			}
+			_, err := dec.Token() // '}'
+			return n, err
+		case '[':
+			n := &node{isArray: true}
+			for dec.More() {
+				value, err := parseNode(dec)
+				if err != nil {
+					return nil, err
+				}
+				n.values = append(n.values, value)
+			}
+			_, err := dec.Token() // ']'
+			return n, err
+		}
+	case nil:
+		return &node{scalar: "null"}, nil
+	case bool:
+		return &node{scalar: strconv.FormatBool(tok)}, nil
+	case json.Number:
+		return &node{scalar: tok.String()}, nil
+	case string:
+		return &node{scalar: quote(tok)}, nil
+	}
+	return nil, errors.New("format: unexpected JSON token")
+}
+
+// writeNode writes the node with the indent.
+// If inline is true, the first line is not indented because it follows "- ".
+func writeNode(w *bufio.Writer, n *node, indent int, inline bool) {
+	prefix := strings.Repeat(" ", indent)
+	first := true
+	linePrefix := func() string {
+		if first && inline {
+			first = false
+			return ""
+		}
+		first = false
+		return prefix
+	}
+
+	switch {
+	case n.isObject && len(n.keys) == 0:
+		w.WriteString(linePrefix() + "{}\n")
+	case n.isArray && len(n.values) == 0:
+		w.WriteString(linePrefix() + "[]\n")
+	case n.isObject:
+		for i, key := range n.keys {
+			value := n.values[i]
+			w.WriteString(linePrefix() + quote(key) + ":")
+			if isEmpty(value) {
+				w.WriteString(" ")
+				writeNode(w, value, 0, true)
+				continue
+			}
+			if value.isObject || value.isArray {
+				w.WriteString("\n")
+				writeNode(w, value, indent+2, false)
+				continue
+			}
+			w.WriteString(" " + value.scalar + "\n")
+		}
+	case n.isArray:
+		for _, value := range n.values {
+			w.WriteString(linePrefix() + "- ")
+			if isEmpty(value) || !(value.isObject || value.isArray) {
+				writeNode(w, value, 0, true)
+				continue
+			}
+			writeNode(w, value, indent+2, true)
+		}
+	default:
+		w.WriteString(linePrefix() + n.scalar + "\n")
+	}
+}
+
+func isEmpty(n *node) bool {
+	return (n.isObject || n.isArray) && len(n.values) == 0
+}
+
+// quote quotes the string if it is not safe as a plain scalar of YAML.
+// The quoted string of JSON is also a valid double-quoted scalar of YAML.
+func quote(s string) string {
+	if needsQuote(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~":
+		return true
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	if strings.HasSuffix(s, ":") || strings.Contains(s, ": ") {
+		return true
+	}
+	for i, r := range s {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		case r == '.' || r == '/' || r == '_' || r == '+' || r == '@' || r == ':' || r == '(' || r == ')':
+		case r == '-' && i > 0:
+		case r == ' ' && i > 0 && i < len(s)-1:
+		default:
+			return true
+		}
+	}
+	return false
+}
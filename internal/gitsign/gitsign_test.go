@@ -0,0 +1,168 @@
+package gitsign
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"golang.org/x/crypto/ssh"
+)
+
+// readSSHString reads a string in the SSH wire format.
+func readSSHString(t *testing.T, b []byte) ([]byte, []byte) {
+	t.Helper()
+	if len(b) < 4 {
+		t.Fatal("too short")
+	}
+	n := binary.BigEndian.Uint32(b)
+	if uint32(len(b)-4) < n {
+		t.Fatal("too short")
+	}
+	return b[4 : 4+n], b[4+n:]
+}
+
+// verifySSHSignature verifies the armored signature of the message, as "ssh-keygen -Y verify" does.
+func verifySSHSignature(t *testing.T, pub ssh.PublicKey, message string, armored []byte) {
+	t.Helper()
+	s := strings.TrimSpace(string(armored))
+	if !strings.HasPrefix(s, "-----BEGIN SSH SIGNATURE-----\n") || !strings.HasSuffix(s, "\n-----END SSH SIGNATURE-----") {
+		t.Fatalf("unexpected armor: %s", s)
+	}
+	s = strings.TrimPrefix(s, "-----BEGIN SSH SIGNATURE-----\n")
+	s = strings.TrimSuffix(s, "\n-----END SSH SIGNATURE-----")
+	blob, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(s, "\n", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(blob, []byte("SSHSIG\x00\x00\x00\x01")) {
+		t.Fatal("unexpected preamble")
+	}
+	rest := blob[10:]
+	var pubKey, namespace, reserved, hashAlg, sigBlob []byte
+	pubKey, rest = readSSHString(t, rest)
+	namespace, rest = readSSHString(t, rest)
+	reserved, rest = readSSHString(t, rest)
+	hashAlg, rest = readSSHString(t, rest)
+	sigBlob, rest = readSSHString(t, rest)
+	if len(rest) != 0 {
+		t.Fatal("trailing data")
+	}
+	if !bytes.Equal(pubKey, pub.Marshal()) {
+		t.Error("unexpected public key")
+	}
+	if string(namespace) != "git" || len(reserved) != 0 || string(hashAlg) != "sha512" {
+		t.Errorf("unexpected fields: %q, %q, %q", namespace, reserved, hashAlg)
+	}
+
+	var sig ssh.Signature
+	if err := ssh.Unmarshal(sigBlob, &sig); err != nil {
+		t.Fatal(err)
+	}
+	if sig.Format == ssh.KeyAlgoRSA {
+		t.Error("the RSA signature must not use SHA-1")
+	}
+	h := sha512.Sum512([]byte(message))
+	signed := append([]byte("SSHSIG"), sshStrings([]byte("git"), nil, []byte("sha512"), h[:])...)
+	if err := pub.Verify(signed, &sig); err != nil {
+		t.Errorf("invalid signature: %v", err)
+	}
+}
+
+func TestSSH(t *testing.T) {
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []interface{}{ed25519Key, rsaKey} {
+		block, err := ssh.MarshalPrivateKey(key, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := pem.EncodeToMemory(block)
+		if f, err := DetectFormat(data); err != nil || f != FormatSSH {
+			t.Errorf("want ssh, got %q, %v", f, err)
+		}
+		signer, err := New(data, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := signer.Sign(strings.NewReader("tree 1234\n\nupdate: alpine:3.17\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub, err := ssh.NewSignerFromKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		verifySSHSignature(t, pub.PublicKey(), "tree 1234\n\nupdate: alpine:3.17\n", sig)
+	}
+}
+
+func TestSSH_Passphrase(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(key, "", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pem.EncodeToMemory(block)
+	if _, err := New(data, nil); err == nil {
+		t.Error("want error, got nil")
+	}
+	if _, err := New(data, []byte("wrong")); err == nil {
+		t.Error("want error, got nil")
+	}
+	if _, err := New(data, []byte("secret")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenPGP(t *testing.T) {
+	entity, err := openpgp.NewEntity("diuc", "", "diuc@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.SerializePrivate(w, nil); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	signer, err := New(buf.Bytes(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := "tree 1234\n\nupdate: alpine:3.17\n"
+	sig, err := signer.Sign(strings.NewReader(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openpgp.CheckArmoredDetachedSignature(openpgp.EntityList{entity}, strings.NewReader(message), bytes.NewReader(sig), nil); err != nil {
+		t.Errorf("invalid signature: %v", err)
+	}
+}
+
+func TestDetectFormat_Unknown(t *testing.T) {
+	if _, err := New([]byte("not a key"), nil); err == nil {
+		t.Error("want error, got nil")
+	}
+}
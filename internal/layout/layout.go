@@ -0,0 +1,289 @@
+// Package layout maps the image references to the paths of the status files, and back.
+//
+// The hosts, the repositories and the tags are escaped to be safe as the path segments
+// on the case-insensitive file systems and on Windows:
+// the upper case letters are "!" followed by the lower case ones, as the module cache of Go does,
+// the characters other than [a-z0-9_.-] and the trailing dots are percent-encoded,
+// and the first character of the reserved names of Windows, e.g. "con" and "nul", is percent-encoded.
+// e.g. "localhost:5000" is "localhost%3A5000", and "Latest" is "!latest".
+package layout
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Default is the name of the default layout.
+const Default = "nested"
+
+// named are the patterns of the named layouts.
+var named = map[string]string{
+	// e.g. registry-1.docker.io/library/alpine/3.17.json
+	"nested": "{host}/{repository}/{tag}.json",
+
+	// e.g. registry-1.docker.io+library+alpine+3.17.json
+	"flat": "{host}+{repository:flat}+{tag}.json",
+
+	// e.g. registry-1.docker.io/library/alpine/3.17@sha256%3Aabc.json
+	"digest": "{host}/{repository}/{tag}@{digest}.json",
+}
+
+// flatSeparator replaces the slashes of the repositories in the flat placeholders.
+// It never appears in the escaped hosts, repositories and tags.
+const flatSeparator = "+"
+
+// placeholderRegexp matches the placeholders in the patterns.
+var placeholderRegexp = regexp.MustCompile(`\{([a-z]+)(:flat)?\}`)
+
+// Layout maps the image references to the relative paths of the status files.
+type Layout struct {
+	pattern string
+	re      *regexp.Regexp
+
+	// placeholders are the names of the placeholders in the order of the submatches of re.
+	placeholders []string
+}
+
+// Parse parses the name of a layout, or a pattern with the placeholders {host}, {repository} and {tag}, e.g. "{host}/{repository}/{tag}.json".
+// {repository:flat} is the repository with the slashes replaced by "+".
+// The optional {digest} is the digest of the manifests, e.g. "{host}/{repository}/{tag}@{digest}.json".
+// It must be in the file name, so that the file of an image is found by listing a directory.
+// The empty string is the default layout.
+func Parse(s string) (*Layout, error) {
+	if s == "" {
+		s = Default
+	}
+	pattern, ok := named[s]
+	if !ok {
+		pattern = s
+	}
+	if !strings.HasSuffix(pattern, ".json") {
+		return nil, fmt.Errorf("layout %q: must end with .json", s)
+	}
+	if path.IsAbs(pattern) || strings.Contains(pattern, "\\") {
+		return nil, fmt.Errorf("layout %q: must be a relative path with the slashes", s)
+	}
+
+	var re strings.Builder
+	re.WriteString("^")
+	var placeholders []string
+	last := 0
+	for _, m := range placeholderRegexp.FindAllStringSubmatchIndex(pattern, -1) {
+		re.WriteString(regexp.QuoteMeta(pattern[last:m[0]]))
+		name, flat := pattern[m[2]:m[3]], m[4] >= 0
+		switch {
+		case name == "host" && !flat:
+			re.WriteString(`([^/+]+)`)
+		case name == "repository" && !flat:
+			re.WriteString(`([^+]+)`)
+		case name == "repository" && flat:
+			re.WriteString(`([^/]+)`)
+		case name == "tag" && !flat:
+			re.WriteString(`([^/+]+)`)
+		case name == "digest" && !flat:
+			// the escaped digest, e.g. "sha256%3Aabc". it never contains the other characters,
+			// so the separators like "-" between it and the tag are unambiguous.
+			re.WriteString(`([a-z0-9]+%3A[0-9a-f]+)`)
+			if strings.Contains(pattern[m[1]:], "/") {
+				return nil, fmt.Errorf("layout %q: {digest} must be in the file name", s)
+			}
+		default:
+			return nil, fmt.Errorf("layout %q: unknown placeholder %s", s, pattern[m[0]:m[1]])
+		}
+		if contains(placeholders, name) {
+			return nil, fmt.Errorf("layout %q: duplicated placeholder {%s}", s, name)
+		}
+		placeholders = append(placeholders, name)
+		last = m[1]
+	}
+	re.WriteString(regexp.QuoteMeta(pattern[last:]))
+	re.WriteString("$")
+	for _, name := range []string{"host", "repository", "tag"} {
+		if !contains(placeholders, name) {
+			return nil, fmt.Errorf("layout %q: missing placeholder {%s}", s, name)
+		}
+	}
+	if strings.ContainsAny(placeholderRegexp.ReplaceAllString(pattern, ""), "{}") {
+		return nil, fmt.Errorf("layout %q: invalid braces", s)
+	}
+	return &Layout{
+		pattern:      pattern,
+		re:           regexp.MustCompile(re.String()),
+		placeholders: placeholders,
+	}, nil
+}
+
+// String returns the pattern of the layout.
+func (l *Layout) String() string {
+	return l.pattern
+}
+
+// HasDigest reports whether the layout has the digest in the file name.
+// The path of the status file of such a layout changes when the manifests change.
+func (l *Layout) HasDigest() bool {
+	return contains(l.placeholders, "digest")
+}
+
+// Path returns the relative path of the status file, separated by slashes.
+// The digest is used only if the layout has {digest}.
+func (l *Layout) Path(host, repository, tag, digest string) string {
+	return placeholderRegexp.ReplaceAllStringFunc(l.pattern, func(s string) string {
+		switch s {
+		case "{host}":
+			return escape(host)
+		case "{repository}":
+			return escapePath(repository, "/")
+		case "{repository:flat}":
+			return escapePath(repository, flatSeparator)
+		case "{tag}":
+			return escape(tag)
+		case "{digest}":
+			return escape(digest)
+		}
+		return s
+	})
+}
+
+// errNotMatch is returned when the path doesn't match the layout.
+var errNotMatch = errors.New("the path doesn't match the layout")
+
+// Parse returns the host, the repository, the tag and the digest of the relative path of the status file.
+// The digest is empty if the layout doesn't have {digest}.
+func (l *Layout) Parse(rel string) (host, repository, tag, digest string, err error) {
+	m := l.re.FindStringSubmatch(rel)
+	if m == nil {
+		return "", "", "", "", errNotMatch
+	}
+	for i, name := range l.placeholders {
+		var v string
+		var err error
+		switch name {
+		case "host":
+			v, err = unescape(m[i+1])
+			host = v
+		case "repository":
+			v, err = unescapePath(strings.ReplaceAll(m[i+1], flatSeparator, "/"))
+			repository = v
+		case "tag":
+			v, err = unescape(m[i+1])
+			tag = v
+		case "digest":
+			v, err = unescape(m[i+1])
+			digest = v
+		}
+		if err != nil {
+			return "", "", "", "", err
+		}
+	}
+	return host, repository, tag, digest, nil
+}
+
+// reservedNames are the names that Windows reserves for the devices, with or without the extensions.
+var reservedNames = map[string]bool{
+	"con": true, "prn": true, "aux": true, "nul": true,
+	"com1": true, "com2": true, "com3": true, "com4": true, "com5": true, "com6": true, "com7": true, "com8": true, "com9": true,
+	"lpt1": true, "lpt2": true, "lpt3": true, "lpt4": true, "lpt5": true, "lpt6": true, "lpt7": true, "lpt8": true, "lpt9": true,
+}
+
+// escapePath escapes each segment of the slash-separated path, and joins them with sep.
+func escapePath(s, sep string) string {
+	segments := strings.Split(s, "/")
+	for i, seg := range segments {
+		segments[i] = escape(seg)
+	}
+	return strings.Join(segments, sep)
+}
+
+// unescapePath unescapes each segment of the slash-separated path.
+func unescapePath(s string) (string, error) {
+	segments := strings.Split(s, "/")
+	for i, seg := range segments {
+		v, err := unescape(seg)
+		if err != nil {
+			return "", err
+		}
+		segments[i] = v
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// escape escapes a path segment.
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z':
+			b.WriteByte('!')
+			b.WriteByte(c - 'A' + 'a')
+		case c == '.' && i == len(s)-1:
+			b.WriteString("%2E")
+		case 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '_', c == '.', c == '-':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	escaped := b.String()
+	name := escaped
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		name = name[:i]
+	}
+	if reservedNames[name] {
+		escaped = fmt.Sprintf("%%%02X", escaped[0]) + escaped[1:]
+	}
+	return escaped
+}
+
+// errInvalidEscape is returned when the path segment is not escaped by escape.
+var errInvalidEscape = errors.New("invalid escape sequence")
+
+// unescape unescapes a path segment.
+// The segments that are not escaped, e.g. the ones written before the escaping was introduced, are returned as they are,
+// because the image references never contain "!" and "%".
+func unescape(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '!':
+			if i+1 >= len(s) || s[i+1] < 'a' || s[i+1] > 'z' {
+				return "", errInvalidEscape
+			}
+			b.WriteByte(s[i+1] - 'a' + 'A')
+			i++
+		case '%':
+			if i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+				return "", errInvalidEscape
+			}
+			b.WriteByte(unhex(s[i+1])<<4 | unhex(s[i+2]))
+			i += 2
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	if c <= '9' {
+		return c - '0'
+	}
+	return c - 'A' + 10
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
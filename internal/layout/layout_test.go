@@ -0,0 +1,165 @@
+package layout
+
+import "testing"
+
+func TestLayout(t *testing.T) {
+	tests := []struct {
+		layout string
+		path   string
+	}{
+		{"", "registry-1.docker.io/library/alpine/3.17.json"},
+		{"nested", "registry-1.docker.io/library/alpine/3.17.json"},
+		{"flat", "registry-1.docker.io+library+alpine+3.17.json"},
+		{"{host}/{repository:flat}/{tag}.json", "registry-1.docker.io/library+alpine/3.17.json"},
+		{"images/{tag}/{host}/{repository}.json", "images/3.17/registry-1.docker.io/library/alpine.json"},
+	}
+	for _, tt := range tests {
+		l, err := Parse(tt.layout)
+		if err != nil {
+			t.Errorf("%q: %v", tt.layout, err)
+			continue
+		}
+		if got := l.Path("registry-1.docker.io", "library/alpine", "3.17", ""); got != tt.path {
+			t.Errorf("%q: want %q, got %q", tt.layout, tt.path, got)
+		}
+		host, repository, tag, _, err := l.Parse(tt.path)
+		if err != nil {
+			t.Errorf("%q: %v", tt.layout, err)
+			continue
+		}
+		if host != "registry-1.docker.io" || repository != "library/alpine" || tag != "3.17" {
+			t.Errorf("%q: unexpected parse result: %q, %q, %q", tt.layout, host, repository, tag)
+		}
+	}
+}
+
+func TestLayout_Digest(t *testing.T) {
+	const digest = "sha256:0123456789abcdef"
+	tests := []struct {
+		layout string
+		path   string
+	}{
+		{"digest", "registry-1.docker.io/library/alpine/3.17@sha256%3A0123456789abcdef.json"},
+		{"{host}+{repository:flat}+{tag}-{digest}.json", "registry-1.docker.io+library+alpine+3.17-sha256%3A0123456789abcdef.json"},
+	}
+	for _, tt := range tests {
+		l, err := Parse(tt.layout)
+		if err != nil {
+			t.Errorf("%q: %v", tt.layout, err)
+			continue
+		}
+		if !l.HasDigest() {
+			t.Errorf("%q: want the digest", tt.layout)
+		}
+		if got := l.Path("registry-1.docker.io", "library/alpine", "3.17", digest); got != tt.path {
+			t.Errorf("%q: want %q, got %q", tt.layout, tt.path, got)
+		}
+		host, repository, tag, d, err := l.Parse(tt.path)
+		if err != nil {
+			t.Errorf("%q: %v", tt.layout, err)
+			continue
+		}
+		if host != "registry-1.docker.io" || repository != "library/alpine" || tag != "3.17" || d != digest {
+			t.Errorf("%q: unexpected parse result: %q, %q, %q, %q", tt.layout, host, repository, tag, d)
+		}
+
+		// the path without the digest is not a status file.
+		if _, _, _, _, err := l.Parse(l.Path("registry-1.docker.io", "library/alpine", "3.17", "")); err == nil {
+			t.Errorf("%q: want error for the path without the digest, got nil", tt.layout)
+		}
+	}
+
+	l, err := Parse("nested")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.HasDigest() {
+		t.Error("want no digest in the nested layout")
+	}
+}
+
+func TestLayout_NotMatch(t *testing.T) {
+	l, err := Parse("flat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rel := range []string{
+		"registry-1.docker.io/library/alpine/3.17.json",
+		"registry-1.docker.io+alpine.json",
+		"README.md",
+	} {
+		if _, _, _, _, err := l.Parse(rel); err == nil {
+			t.Errorf("%q: want error, got nil", rel)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, s := range []string{
+		"unknown",
+		"{host}/{repository}/{tag}",
+		"/{host}/{repository}/{tag}.json",
+		"{host}/{repository}.json",
+		"{host}/{repository}/{tag}/{tag}.json",
+		"{host}/{repository}/{digest}/{tag}.json",
+		"{host}/{repository}/{tag}@{digest:flat}.json",
+		"{host:flat}/{repository}/{tag}.json",
+		"{host}/{repository}/{tag}{.json",
+	} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("%q: want error, got nil", s)
+		}
+	}
+}
+
+func TestLayout_Escape(t *testing.T) {
+	tests := []struct {
+		host, repository, tag string
+		path                  string
+	}{
+		{"localhost:5000", "foo/bar", "v1", "localhost%3A5000/foo/bar/v1.json"},
+		{"ghcr.io", "foo/bar", "Latest", "ghcr.io/foo/bar/!latest.json"},
+		{"ghcr.io", "foo/bar", "sha256:abc", "ghcr.io/foo/bar/sha256%3Aabc.json"},
+		{"ghcr.io", "foo/con", "nul", "ghcr.io/foo/%63on/%6Eul.json"},
+		{"ghcr.io", "foo/bar", "v1.", "ghcr.io/foo/bar/v1%2E.json"},
+	}
+	l, err := Parse("nested")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		path := l.Path(tt.host, tt.repository, tt.tag, "")
+		if path != tt.path {
+			t.Errorf("want %q, got %q", tt.path, path)
+		}
+		host, repository, tag, _, err := l.Parse(path)
+		if err != nil {
+			t.Errorf("%q: %v", path, err)
+			continue
+		}
+		if host != tt.host || repository != tt.repository || tag != tt.tag {
+			t.Errorf("%q: unexpected parse result: %q, %q, %q", path, host, repository, tag)
+		}
+	}
+}
+
+func TestLayout_Unescaped(t *testing.T) {
+	// the files written before the escaping was introduced.
+	l, err := Parse("nested")
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, repository, tag, _, err := l.Parse("localhost:5000/foo/bar/Latest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host != "localhost:5000" || repository != "foo/bar" || tag != "Latest" {
+		t.Errorf("unexpected parse result: %q, %q, %q", host, repository, tag)
+	}
+
+	for _, rel := range []string{"ghcr.io/foo/bar/v1%3.json", "ghcr.io/foo/bar/v1!.json", "ghcr.io/foo/bar/!1.json"} {
+		if _, _, _, _, err := l.Parse(rel); err == nil {
+			t.Errorf("%q: want error, got nil", rel)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Package secret resolves the references to the secrets in the external stores,
+// e.g. "aws-secretsmanager://name" or "file:///run/secrets/name".
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// the schemes of the references.
+const (
+	schemeFile           = "file://"
+	schemeSecretsManager = "aws-secretsmanager://"
+	schemeSSM            = "aws-ssm://"
+)
+
+// IsReference reports whether the value is a reference to a secret.
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, schemeFile) || strings.HasPrefix(value, schemeSecretsManager) || strings.HasPrefix(value, schemeSSM)
+}
+
+// Resolver resolves the references to the secrets. The resolved secrets are cached.
+type Resolver struct {
+	// AWS is the configuration of the AWS SDK.
+	// If it is nil, the default one is loaded with the default credential chain on the first use.
+	AWS *aws.Config
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// NewResolver returns a new resolver with the default AWS configuration.
+func NewResolver() *Resolver {
+	return &Resolver{}
+}
+
+var defaultResolver = NewResolver()
+
+// Resolve resolves the reference with the default resolver.
+func Resolve(ctx context.Context, value string) (string, error) {
+	return defaultResolver.Resolve(ctx, value)
+}
+
+// Resolve returns the secret that the value refers to. The values that are not references are returned as is.
+//
+//   - file:///path/to/file reads the file, without the trailing newline.
+//   - aws-secretsmanager://name[?region=region][#key] gets the secret string from AWS Secrets Manager.
+//     With the key, the secret string is a JSON object, and the value of the key is returned.
+//   - aws-ssm://name[?region=region] gets the parameter from AWS Systems Manager Parameter Store, with decryption.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	if !IsReference(value) {
+		return value, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if secret, ok := r.cache[value]; ok {
+		return secret, nil
+	}
+
+	var secret string
+	var err error
+	switch {
+	case strings.HasPrefix(value, schemeFile):
+		secret, err = resolveFile(strings.TrimPrefix(value, schemeFile))
+	case strings.HasPrefix(value, schemeSecretsManager):
+		secret, err = r.resolveSecretsManager(ctx, strings.TrimPrefix(value, schemeSecretsManager))
+	case strings.HasPrefix(value, schemeSSM):
+		secret, err = r.resolveSSM(ctx, strings.TrimPrefix(value, schemeSSM))
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", value, err)
+	}
+	if r.cache == nil {
+		r.cache = map[string]string{}
+	}
+	r.cache[value] = secret
+	return secret, nil
+}
+
+func resolveFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// parseReference splits the reference into the name, the region and the key.
+// The region is the one of the ARN, or empty if it is not given.
+func parseReference(ref string) (name, region, key string, err error) {
+	if idx := strings.LastIndexByte(ref, '#'); idx >= 0 {
+		ref, key = ref[:idx], ref[idx+1:]
+	}
+	if idx := strings.IndexByte(ref, '?'); idx >= 0 {
+		query, err := url.ParseQuery(ref[idx+1:])
+		if err != nil {
+			return "", "", "", err
+		}
+		ref, region = ref[:idx], query.Get("region")
+	}
+	name = ref
+	if name == "" {
+		return "", "", "", fmt.Errorf("empty name")
+	}
+	if region == "" && strings.HasPrefix(name, "arn:") {
+		// arn:partition:service:region:account-id:resource
+		if parts := strings.SplitN(name, ":", 6); len(parts) == 6 {
+			region = parts[3]
+		}
+	}
+	return name, region, key, nil
+}
+
+// awsConfig returns the configuration of the AWS SDK in the region, or in the default one if the region is empty.
+// The caller must hold r.mu.
+func (r *Resolver) awsConfig(ctx context.Context, region string) (aws.Config, error) {
+	if r.AWS == nil {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			return aws.Config{}, fmt.Errorf("failed to load the AWS configuration: %w", err)
+		}
+		r.AWS = &cfg
+	}
+	cfg := r.AWS.Copy()
+	if region != "" {
+		cfg.Region = region
+	}
+	if cfg.Region == "" {
+		return aws.Config{}, fmt.Errorf("no region: set AWS_REGION or give ?region=")
+	}
+	return cfg, nil
+}
+
+func (r *Resolver) resolveSecretsManager(ctx context.Context, ref string) (string, error) {
+	name, region, key, err := parseReference(ref)
+	if err != nil {
+		return "", err
+	}
+	cfg, err := r.awsConfig(ctx, region)
+	if err != nil {
+		return "", err
+	}
+	out, err := secretsmanager.NewFromConfig(cfg).GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(name),
+	})
+	if err != nil {
+		return "", err
+	}
+	if out.SecretString == nil {
+		return "", fmt.Errorf("the secret is binary")
+	}
+	if key == "" {
+		return *out.SecretString, nil
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(*out.SecretString), &values); err != nil {
+		return "", fmt.Errorf("the secret is not a JSON object: %w", err)
+	}
+	v, ok := values[key]
+	if !ok {
+		return "", fmt.Errorf("the secret doesn't have the key %q", key)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("the value of the key %q is not a string", key)
+	}
+	return s, nil
+}
+
+func (r *Resolver) resolveSSM(ctx context.Context, ref string) (string, error) {
+	name, region, key, err := parseReference(ref)
+	if err != nil {
+		return "", err
+	}
+	if key != "" {
+		return "", fmt.Errorf("the parameters don't have keys")
+	}
+	cfg, err := r.awsConfig(ctx, region)
+	if err != nil {
+		return "", err
+	}
+	out, err := ssm.NewFromConfig(cfg).GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	if out.Parameter == nil || out.Parameter.Value == nil {
+		return "", fmt.Errorf("empty parameter")
+	}
+	return *out.Parameter.Value, nil
+}
@@ -0,0 +1,132 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+func TestResolve_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(path, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	r := NewResolver()
+	got, err := r.Resolve(context.Background(), "file://"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "secret" {
+		t.Errorf("want %q, got %q", "secret", got)
+	}
+
+	got, err = r.Resolve(context.Background(), "plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "plain" {
+		t.Errorf("want %q, got %q", "plain", got)
+	}
+
+	if _, err := r.Resolve(context.Background(), "file://"+path+".missing"); err == nil {
+		t.Error("want error, got nil")
+	}
+}
+
+func TestResolve_AWS(t *testing.T) {
+	var calls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var in map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			t.Fatal(err)
+		}
+		switch r.Header.Get("X-Amz-Target") {
+		case "secretsmanager.GetSecretValue":
+			if in["SecretId"] != "prod/registry" {
+				t.Errorf("unexpected secret id: %v", in["SecretId"])
+			}
+			fmt.Fprint(w, `{"SecretString":"{\"username\":\"user\",\"password\":\"pass\"}"}`)
+		case "AmazonSSM.GetParameter":
+			if in["Name"] != "/prod/registry/password" || in["WithDecryption"] != true {
+				t.Errorf("unexpected input: %v", in)
+			}
+			fmt.Fprint(w, `{"Parameter":{"Value":"ssm-pass"}}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	r := &Resolver{
+		AWS: &aws.Config{
+			Region:       "ap-northeast-1",
+			Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+			BaseEndpoint: aws.String(ts.URL),
+		},
+	}
+
+	tests := []struct {
+		ref, want string
+	}{
+		{"aws-secretsmanager://prod/registry#password", "pass"},
+		{"aws-secretsmanager://prod/registry", `{"username":"user","password":"pass"}`},
+		{"aws-ssm:///prod/registry/password", "ssm-pass"},
+	}
+	for _, tt := range tests {
+		got, err := r.Resolve(context.Background(), tt.ref)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.ref, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: want %q, got %q", tt.ref, tt.want, got)
+		}
+	}
+
+	// the secrets are cached.
+	if _, err := r.Resolve(context.Background(), "aws-secretsmanager://prod/registry#password"); err != nil {
+		t.Fatal(err)
+	}
+	if calls != len(tests) {
+		t.Errorf("want %d calls, got %d", len(tests), calls)
+	}
+
+	if _, err := r.Resolve(context.Background(), "aws-secretsmanager://prod/registry#missing"); err == nil {
+		t.Error("want error, got nil")
+	}
+}
+
+func TestParseReference(t *testing.T) {
+	name, region, key, err := parseReference("arn:aws:secretsmanager:us-west-2:123456789012:secret:prod/registry#password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "arn:aws:secretsmanager:us-west-2:123456789012:secret:prod/registry" || region != "us-west-2" || key != "password" {
+		t.Errorf("unexpected result: %q, %q, %q", name, region, key)
+	}
+
+	name, region, key, err = parseReference("prod/registry?region=eu-west-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "prod/registry" || region != "eu-west-1" || key != "" {
+		t.Errorf("unexpected result: %q, %q, %q", name, region, key)
+	}
+	// the default region of the AWS configuration is used.
+	name, region, key, err = parseReference("prod/registry#password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "prod/registry" || region != "" || key != "password" {
+		t.Errorf("unexpected result: %q, %q, %q", name, region, key)
+	}
+}
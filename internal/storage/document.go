@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// Document is the persisted document of an image: the manifests wrapped in an envelope with the metadata of the retrieval.
+type Document struct {
+	// Digest is the Docker-Content-Digest of the manifests, so that the consumers don't have to compute it.
+	Digest string `json:"digest,omitempty"`
+
+	// MediaType is the Content-Type that the registry returned.
+	MediaType string `json:"mediaType,omitempty"`
+
+	// FetchedAt is the time when the manifests were fetched.
+	FetchedAt *time.Time `json:"fetchedAt,omitempty"`
+
+	// Manifest is the manifests in the canonical form.
+	Manifest *registry.Manifests `json:"manifest"`
+
+	// Raw is the manifests as the registry served them, encoded in base64 to keep the bytes.
+	// The digest of the canonical form differs from Digest, so Raw is kept for the consumers that need the real digest,
+	// e.g. the OCI image layout.
+	Raw []byte `json:"raw,omitempty"`
+}
+
+// MarshalIndent returns the indented JSON of the document, with the manifests in the canonical form.
+func (doc *Document) MarshalIndent(prefix, indent string) ([]byte, error) {
+	c := *doc
+	c.Manifest = registry.Canonical(doc.Manifest)
+	return json.MarshalIndent(&c, prefix, indent)
+}
+
+// DecodeDocument parses the document.
+// It also accepts the bare manifests that were stored before the envelope was introduced.
+// It returns ErrCorrupted if the document can't be parsed.
+func DecodeDocument(data []byte) (*Document, error) {
+	var envelope struct {
+		Manifest json.RawMessage `json:"manifest"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupted, err)
+	}
+	if len(envelope.Manifest) == 0 {
+		// the bare manifests never have the "manifest" field.
+		var m *registry.Manifests
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorrupted, err)
+		}
+		return &Document{Manifest: m}, nil
+	}
+
+	var doc *Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupted, err)
+	}
+	return doc, nil
+}
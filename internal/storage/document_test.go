@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+func TestDocument(t *testing.T) {
+	fetchedAt := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	doc := &Document{
+		Digest:    "sha256:index",
+		MediaType: "application/vnd.oci.image.index.v1+json",
+		FetchedAt: &fetchedAt,
+		Manifest: &registry.Manifests{
+			SchemaVersion: 2,
+			MediaType:     "application/vnd.oci.image.index.v1+json",
+			Manifests: []*registry.Manifest{
+				{Digest: "sha256:arm64", Platform: &registry.Platform{OS: "linux", Architecture: "arm64"}},
+				{Digest: "sha256:amd64", Platform: &registry.Platform{OS: "linux", Architecture: "amd64"}},
+			},
+		},
+		Raw: []byte(`{"schemaVersion": 2,  "manifests": []}`),
+	}
+	data, err := doc.MarshalIndent("", "    ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeDocument(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Digest != doc.Digest || got.MediaType != doc.MediaType || !got.FetchedAt.Equal(fetchedAt) {
+		t.Errorf("unexpected metadata: %+v", got)
+	}
+	if got.Manifest.Manifests[0].Digest != "sha256:amd64" {
+		t.Errorf("want the canonical form, got %s first", got.Manifest.Manifests[0].Digest)
+	}
+	if string(got.Raw) != string(doc.Raw) {
+		t.Errorf("want the raw manifests byte for byte, got %s", got.Raw)
+	}
+}
+
+func TestDecodeDocument_Bare(t *testing.T) {
+	data := `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[{"digest":"sha256:amd64","mediaType":"","platform":{"architecture":"amd64","os":"linux"},"size":0}]}`
+	doc, err := DecodeDocument([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Digest != "" || doc.FetchedAt != nil {
+		t.Errorf("want no metadata, got %+v", doc)
+	}
+	if len(doc.Manifest.Manifests) != 1 || doc.Manifest.Manifests[0].Digest != "sha256:amd64" {
+		t.Errorf("unexpected manifests: %+v", doc.Manifest)
+	}
+}
+
+func TestDecodeDocument_Corrupted(t *testing.T) {
+	for _, data := range []string{`{`, `{"manifest": 1}`, `[]`} {
+		if _, err := DecodeDocument([]byte(data)); !errors.Is(err, ErrCorrupted) {
+			t.Errorf("%s: want ErrCorrupted, got %v", data, err)
+		}
+	}
+}
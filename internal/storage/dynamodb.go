@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// dynamoDBTimeout is the timeout for a call of the DynamoDB API.
+const dynamoDBTimeout = 30 * time.Second
+
+// DynamoDB stores the manifests in a DynamoDB table.
+// The table has the partition key "image" of the string type.
+// The items have the attributes "manifests" (the JSON), "digest" (the digest of the JSON)
+// and "changedAt" (the UNIX time of the last change, usable for TTL and analytics).
+//
+// The writes are conditional on the digest loaded, so that the concurrent instances of the checker can't clobber each other.
+type DynamoDB struct {
+	Table  string
+	Region string
+
+	// AWS is the configuration of the AWS SDK.
+	// If it is nil, the default one is loaded with the default credential chain on the first use.
+	AWS *aws.Config
+
+	mu     sync.Mutex
+	client *dynamodb.Client
+
+	// digests are the digests of the loaded manifests. The empty string means that the image is not stored.
+	digests map[string]string
+}
+
+// NewDynamoDB returns a new DynamoDB store with the default AWS configuration.
+// The region is the default one of the configuration if it is empty.
+func NewDynamoDB(table, region string) *DynamoDB {
+	return &DynamoDB{
+		Table:  table,
+		Region: region,
+	}
+}
+
+// dynamoDB returns the client of DynamoDB.
+func (s *DynamoDB) dynamoDB(ctx context.Context) (*dynamodb.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client != nil {
+		return s.client, nil
+	}
+	if s.AWS == nil {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the AWS configuration: %w", err)
+		}
+		s.AWS = &cfg
+	}
+	s.client = dynamodb.NewFromConfig(*s.AWS, func(o *dynamodb.Options) {
+		if s.Region != "" {
+			o.Region = s.Region
+		}
+	})
+	return s.client, nil
+}
+
+func dynamoDBString(s string) types.AttributeValue {
+	return &types.AttributeValueMemberS{Value: s}
+}
+
+// stringAttribute returns the value of the string attribute of the item.
+func stringAttribute(item map[string]types.AttributeValue, name string) (string, bool) {
+	v, ok := item[name].(*types.AttributeValueMemberS)
+	if !ok {
+		return "", false
+	}
+	return v.Value, true
+}
+
+// Load returns the stored manifests of the image, or nil if the image is not stored.
+func (s *DynamoDB) Load(image string) (*registry.Manifests, error) {
+	image, err := imageKey(image)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dynamoDBTimeout)
+	defer cancel()
+	client, err := s.dynamoDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.Table),
+		Key:            map[string]types.AttributeValue{"image": dynamoDBString(image)},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s from %s: %w", image, s.Table, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.digests == nil {
+		s.digests = map[string]string{}
+	}
+	if out.Item == nil {
+		s.digests[image] = ""
+		return nil, nil
+	}
+	data, ok1 := stringAttribute(out.Item, "manifests")
+	digest, ok2 := stringAttribute(out.Item, "digest")
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("%w: %s in %s: missing attributes", ErrCorrupted, image, s.Table)
+	}
+	s.digests[image] = digest
+
+	var m *registry.Manifests
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		return nil, fmt.Errorf("%w: %s in %s: %v", ErrCorrupted, image, s.Table, err)
+	}
+	return m, nil
+}
+
+// Save stores the manifests of the image, if no other instances have modified them since they are loaded.
+// The images that are not loaded are stored only if they don't exist.
+func (s *DynamoDB) Save(image string, m *registry.Manifests) error {
+	image, err := imageKey(image)
+	if err != nil {
+		return err
+	}
+	data, digest, err := encode(m)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	old, loaded := s.digests[image]
+	s.mu.Unlock()
+
+	in := &dynamodb.PutItemInput{
+		TableName: aws.String(s.Table),
+		Item: map[string]types.AttributeValue{
+			"image":     dynamoDBString(image),
+			"manifests": dynamoDBString(data),
+			"digest":    dynamoDBString(digest),
+			"changedAt": &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Unix(), 10)},
+		},
+	}
+	if loaded && old != "" {
+		in.ConditionExpression = aws.String("#digest = :digest")
+		in.ExpressionAttributeNames = map[string]string{"#digest": "digest"}
+		in.ExpressionAttributeValues = map[string]types.AttributeValue{":digest": dynamoDBString(old)}
+	} else {
+		in.ConditionExpression = aws.String("attribute_not_exists(#image)")
+		in.ExpressionAttributeNames = map[string]string{"#image": "image"}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dynamoDBTimeout)
+	defer cancel()
+	client, err := s.dynamoDB(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := client.PutItem(ctx, in); err != nil {
+		var conflict *types.ConditionalCheckFailedException
+		if errors.As(err, &conflict) {
+			return fmt.Errorf("%w: %s", ErrConflict, image)
+		}
+		return fmt.Errorf("failed to put %s into %s: %w", image, s.Table, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.digests == nil {
+		s.digests = map[string]string{}
+	}
+	s.digests[image] = digest
+	return nil
+}
+
+// List returns the stored images.
+func (s *DynamoDB) List() ([]string, error) {
+	client, err := s.dynamoDB(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	var images []string
+	paginator := dynamodb.NewScanPaginator(client, &dynamodb.ScanInput{
+		TableName:                aws.String(s.Table),
+		ProjectionExpression:     aws.String("#image"),
+		ExpressionAttributeNames: map[string]string{"#image": "image"},
+	})
+	for paginator.HasMorePages() {
+		ctx, cancel := context.WithTimeout(context.Background(), dynamoDBTimeout)
+		out, err := paginator.NextPage(ctx)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", s.Table, err)
+		}
+		for _, item := range out.Items {
+			if image, ok := stringAttribute(item, "image"); ok {
+				images = append(images, image)
+			}
+		}
+	}
+	return images, nil
+}
+
+// Commit does nothing, because the manifests are written when they are saved.
+func (s *DynamoDB) Commit(summary string) error {
+	log.Printf("stored in %s: %s", s.Table, summary)
+	return nil
+}
@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// attributeValue is an attribute value in the JSON of the DynamoDB API.
+type attributeValue struct {
+	S *string `json:"S,omitempty"`
+	N *string `json:"N,omitempty"`
+}
+
+func stringValue(s string) *attributeValue {
+	return &attributeValue{S: &s}
+}
+
+// fakeDynamoDB is an in-memory table that supports the subset of the API used by DynamoDB.
+type fakeDynamoDB struct {
+	mu    sync.Mutex
+	items map[string]map[string]*attributeValue
+}
+
+func (f *fakeDynamoDB) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var in struct {
+		Key                       map[string]*attributeValue `json:"Key"`
+		Item                      map[string]*attributeValue `json:"Item"`
+		ConditionExpression       string                     `json:"ConditionExpression"`
+		ExpressionAttributeValues map[string]*attributeValue `json:"ExpressionAttributeValues"`
+		ExclusiveStartKey         map[string]*attributeValue `json:"ExclusiveStartKey"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	switch r.Header.Get("X-Amz-Target") {
+	case "DynamoDB_20120810.GetItem":
+		json.NewEncoder(w).Encode(map[string]interface{}{"Item": f.items[*in.Key["image"].S]})
+	case "DynamoDB_20120810.PutItem":
+		image := *in.Item["image"].S
+		old, ok := f.items[image]
+		var pass bool
+		switch in.ConditionExpression {
+		case "attribute_not_exists(#image)":
+			pass = !ok
+		case "#digest = :digest":
+			pass = ok && *old["digest"].S == *in.ExpressionAttributeValues[":digest"].S
+		}
+		if !pass {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"__type":"com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException","message":"The conditional request failed"}`)
+			return
+		}
+		f.items[image] = in.Item
+		fmt.Fprint(w, `{}`)
+	case "DynamoDB_20120810.Scan":
+		// return an item per page to test the pagination.
+		var keys []string
+		for key := range f.items {
+			if in.ExclusiveStartKey == nil || key > *in.ExclusiveStartKey["image"].S {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		out := map[string]interface{}{"Items": []interface{}{}}
+		if len(keys) > 0 {
+			item := map[string]*attributeValue{"image": stringValue(keys[0])}
+			out["Items"] = []interface{}{item}
+			out["LastEvaluatedKey"] = item
+		}
+		json.NewEncoder(w).Encode(out)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func newTestDynamoDB(t *testing.T, f *fakeDynamoDB) *DynamoDB {
+	t.Helper()
+	ts := httptest.NewServer(f)
+	t.Cleanup(ts.Close)
+	return &DynamoDB{
+		Table:  "diuc",
+		Region: "us-east-1",
+		AWS: &aws.Config{
+			Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+			BaseEndpoint: aws.String(ts.URL),
+		},
+	}
+}
+
+func TestDynamoDB(t *testing.T) {
+	f := &fakeDynamoDB{items: map[string]map[string]*attributeValue{}}
+	s1 := newTestDynamoDB(t, f)
+	s2 := newTestDynamoDB(t, f)
+
+	m, err := s1.Load("alpine:3.17")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != nil {
+		t.Errorf("want nil, got %v", m)
+	}
+	v1 := &registry.Manifests{SchemaVersion: 2, MediaType: "application/vnd.oci.image.index.v1+json"}
+	if err := s1.Save("alpine:3.17", v1); err != nil {
+		t.Fatal(err)
+	}
+	item := f.items["registry-1.docker.io/library/alpine:3.17"]
+	if item == nil {
+		t.Fatal("the item is not stored under the normalized key")
+	}
+	if item["changedAt"] == nil || item["changedAt"].N == nil {
+		t.Error("changedAt is not stored")
+	}
+
+	// both instances load the same manifests.
+	got, err := s2.Load("docker.io/library/alpine:3.17")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, v1) {
+		t.Errorf("want %v, got %v", v1, got)
+	}
+
+	// the first instance can update the manifests it saved.
+	v2 := &registry.Manifests{SchemaVersion: 2, MediaType: "application/vnd.docker.distribution.manifest.list.v2+json"}
+	if err := s1.Save("alpine:3.17", v2); err != nil {
+		t.Fatal(err)
+	}
+
+	// the second instance can't clobber them.
+	if err := s2.Save("alpine:3.17", v1); !errors.Is(err, ErrConflict) {
+		t.Errorf("want ErrConflict, got %v", err)
+	}
+
+	// the instance that didn't load the image can't overwrite it.
+	s3 := newTestDynamoDB(t, f)
+	if err := s3.Save("alpine:3.17", v1); !errors.Is(err, ErrConflict) {
+		t.Errorf("want ErrConflict, got %v", err)
+	}
+
+	if err := s1.Save("ghcr.io/owner/app:latest", v1); err != nil {
+		t.Fatal(err)
+	}
+	images, err := s2.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"ghcr.io/owner/app:latest", "registry-1.docker.io/library/alpine:3.17"}
+	if !reflect.DeepEqual(images, want) {
+		t.Errorf("want %v, got %v", want, images)
+	}
+}
+
+func TestDynamoDB_Corrupted(t *testing.T) {
+	f := &fakeDynamoDB{items: map[string]map[string]*attributeValue{
+		"registry-1.docker.io/library/alpine:3.17": {
+			"image":     stringValue("registry-1.docker.io/library/alpine:3.17"),
+			"manifests": stringValue("{"),
+			"digest":    stringValue("sha256:invalid"),
+		},
+	}}
+	s := newTestDynamoDB(t, f)
+	if _, err := s.Load("alpine:3.17"); !errors.Is(err, ErrCorrupted) {
+		t.Errorf("want ErrCorrupted, got %v", err)
+	}
+
+	// the corrupted manifests can be overwritten by the instance that loaded them.
+	if err := s.Save("alpine:3.17", &registry.Manifests{SchemaVersion: 2}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// newTestPostgres returns the store of the database in DIUC_TEST_POSTGRES_DSN, with the empty tables.
+func newTestPostgres(t *testing.T) *Postgres {
+	t.Helper()
+	dsn := os.Getenv("DIUC_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("DIUC_TEST_POSTGRES_DSN is not set")
+	}
+	s := NewPostgres(dsn)
+	db, err := s.open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`TRUNCATE diuc_manifests, diuc_updates`); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestPostgres(t *testing.T) {
+	s1 := newTestPostgres(t)
+	s2 := NewPostgres(s1.DSN)
+	defer s2.Close()
+
+	m, err := s1.Load("alpine:3.17")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != nil {
+		t.Errorf("want nil, got %v", m)
+	}
+	v1 := &registry.Manifests{SchemaVersion: 2, MediaType: "application/vnd.oci.image.index.v1+json"}
+	v2 := &registry.Manifests{SchemaVersion: 2, MediaType: "application/vnd.docker.distribution.manifest.list.v2+json"}
+	if err := s1.Save("alpine:3.17", v1); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s2.Load("docker.io/library/alpine:3.17")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, v1) {
+		t.Errorf("want %v, got %v", v1, got)
+	}
+
+	// the first replica can update the manifests it saved, and the second one can't clobber them.
+	if err := s1.Save("alpine:3.17", v2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s2.Save("alpine:3.17", v1); !errors.Is(err, ErrConflict) {
+		t.Errorf("want ErrConflict, got %v", err)
+	}
+
+	revs, err := s2.History("alpine:3.17")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 2 || !reflect.DeepEqual(revs[1].Manifests, v2) {
+		t.Errorf("unexpected revisions: %v", revs)
+	}
+
+	images, err := s2.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"registry-1.docker.io/library/alpine:3.17"}
+	if !reflect.DeepEqual(images, want) {
+		t.Errorf("want %v, got %v", want, images)
+	}
+}
@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// sqliteSchema creates the table that keeps every manifests observed per image.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS manifests (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	image       TEXT    NOT NULL,
+	digest      TEXT    NOT NULL,
+	manifests   TEXT    NOT NULL,
+	observed_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS manifests_image ON manifests (image, id);
+`
+
+// Revision is a version of the stored manifests of an image.
+type Revision struct {
+	// ID identifies the revision, i.e. the hex-encoded digest of the stored JSON.
+	ID         string
+	ObservedAt time.Time
+	Manifests  *registry.Manifests
+}
+
+// ErrSQLiteUnavailable is returned by the SQLite store if the binary is built without cgo,
+// which the driver of SQLite requires.
+var ErrSQLiteUnavailable = errors.New("the SQLite store requires cgo: build with CGO_ENABLED=1")
+
+// SQLite stores the manifests in a SQLite database file.
+// It keeps every manifests observed per image with the timestamps, so the history is available without git.
+// It requires cgo; without cgo, the methods return ErrSQLiteUnavailable.
+type SQLite struct {
+	Path string
+
+	once sync.Once
+	db   *sql.DB
+	err  error
+}
+
+// NewSQLite returns a new SQLite store of the database file. The file is created when it is used first.
+func NewSQLite(path string) *SQLite {
+	return &SQLite{Path: path}
+}
+
+func (s *SQLite) open() (*sql.DB, error) {
+	s.once.Do(func() {
+		if !sqliteAvailable {
+			s.err = ErrSQLiteUnavailable
+			return
+		}
+		db, err := sql.Open("sqlite3", s.Path)
+		if err != nil {
+			s.err = err
+			return
+		}
+		if _, err := db.Exec(sqliteSchema); err != nil {
+			db.Close()
+			s.err = fmt.Errorf("failed to initialize %s: %w", s.Path, err)
+			return
+		}
+		s.db = db
+	})
+	return s.db, s.err
+}
+
+// Close closes the database.
+func (s *SQLite) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// Load returns the latest manifests of the image, or nil if the image is not stored.
+func (s *SQLite) Load(image string) (*registry.Manifests, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	key, err := imageKey(image)
+	if err != nil {
+		return nil, err
+	}
+	var data string
+	err = db.QueryRow(`SELECT manifests FROM manifests WHERE image = ? ORDER BY id DESC LIMIT 1`, key).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m *registry.Manifests
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		return nil, fmt.Errorf("%w: %s in %s: %v", ErrCorrupted, key, s.Path, err)
+	}
+	return m, nil
+}
+
+// Save adds the manifests of the image to the history, unless they are the same as the latest ones.
+func (s *SQLite) Save(image string, m *registry.Manifests) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	key, err := imageKey(image)
+	if err != nil {
+		return err
+	}
+	data, digest, err := encode(m)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var latest string
+	err = tx.QueryRow(`SELECT digest FROM manifests WHERE image = ? ORDER BY id DESC LIMIT 1`, key).Scan(&latest)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if latest == digest {
+		return nil
+	}
+	if _, err := tx.Exec(`INSERT INTO manifests (image, digest, manifests, observed_at) VALUES (?, ?, ?, ?)`,
+		key, digest, data, time.Now().Unix()); err != nil {
+		return fmt.Errorf("failed to save %s into %s: %w", key, s.Path, err)
+	}
+	return tx.Commit()
+}
+
+// List returns the stored images.
+func (s *SQLite) List() ([]string, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`SELECT DISTINCT image FROM manifests ORDER BY image`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var images []string
+	for rows.Next() {
+		var image string
+		if err := rows.Scan(&image); err != nil {
+			return nil, err
+		}
+		images = append(images, image)
+	}
+	return images, rows.Err()
+}
+
+// History returns the revisions of the manifests of the image, from the oldest to the newest.
+func (s *SQLite) History(image string) ([]*Revision, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	key, err := imageKey(image)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`SELECT digest, manifests, observed_at FROM manifests WHERE image = ? ORDER BY id`, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var revs []*Revision
+	for rows.Next() {
+		var digest, data string
+		var observedAt int64
+		if err := rows.Scan(&digest, &data, &observedAt); err != nil {
+			return nil, err
+		}
+		rev := &Revision{
+			ID:         strings.TrimPrefix(digest, "sha256:"),
+			ObservedAt: time.Unix(observedAt, 0),
+		}
+		// keep the revision even if it is corrupted, as the git history does.
+		json.Unmarshal([]byte(data), &rev.Manifests)
+		revs = append(revs, rev)
+	}
+	return revs, rows.Err()
+}
+
+// Commit does nothing, because the manifests are written when they are saved.
+func (s *SQLite) Commit(summary string) error {
+	log.Printf("stored in %s: %s", s.Path, summary)
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build cgo
+
+package storage
+
+import (
+	// the driver of SQLite. It requires cgo.
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteAvailable reports whether the driver of SQLite is linked.
+const sqliteAvailable = true
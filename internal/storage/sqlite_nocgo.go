@@ -0,0 +1,7 @@
+//go:build !cgo
+
+package storage
+
+// sqliteAvailable reports whether the driver of SQLite is linked.
+// The driver requires cgo, so it isn't without cgo.
+const sqliteAvailable = false
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+func TestSQLite(t *testing.T) {
+	if !sqliteAvailable {
+		t.Skip("the SQLite store requires cgo")
+	}
+	s := NewSQLite(filepath.Join(t.TempDir(), "diuc.db"))
+	defer s.Close()
+
+	m, err := s.Load("alpine:3.17")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != nil {
+		t.Errorf("want nil, got %v", m)
+	}
+
+	v1 := &registry.Manifests{SchemaVersion: 2, MediaType: "application/vnd.oci.image.index.v1+json"}
+	v2 := &registry.Manifests{SchemaVersion: 2, MediaType: "application/vnd.docker.distribution.manifest.list.v2+json"}
+	for _, v := range []*registry.Manifests{v1, v1, v2} {
+		if err := s.Save("alpine:3.17", v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Save("ghcr.io/owner/app:latest", v1); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Load("docker.io/library/alpine:3.17")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, v2) {
+		t.Errorf("want %v, got %v", v2, got)
+	}
+
+	// the same manifests are not recorded twice.
+	revs, err := s.History("alpine:3.17")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 2 {
+		t.Fatalf("want 2 revisions, got %d", len(revs))
+	}
+	if !reflect.DeepEqual(revs[0].Manifests, v1) || !reflect.DeepEqual(revs[1].Manifests, v2) {
+		t.Errorf("unexpected revisions: %v, %v", revs[0].Manifests, revs[1].Manifests)
+	}
+	if revs[0].ID == revs[1].ID || len(revs[0].ID) != 64 {
+		t.Errorf("unexpected ids: %q, %q", revs[0].ID, revs[1].ID)
+	}
+
+	images, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"ghcr.io/owner/app:latest", "registry-1.docker.io/library/alpine:3.17"}
+	if !reflect.DeepEqual(images, want) {
+		t.Errorf("want %v, got %v", want, images)
+	}
+}
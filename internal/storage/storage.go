@@ -0,0 +1,39 @@
+// Package storage implements the backends that store the manifests of the images,
+// other than the files committed by git.
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// ErrCorrupted is returned when the stored manifests can't be parsed.
+var ErrCorrupted = errors.New("corrupted status")
+
+// ErrConflict is returned when the stored manifests are modified by another instance of the checker
+// after they are loaded.
+var ErrConflict = errors.New("the stored manifests are modified by another instance")
+
+// imageKey returns the key of the image in the stores, e.g. "registry-1.docker.io/library/alpine:3.17" for "alpine:3.17".
+func imageKey(image string) (string, error) {
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	return ref.Host + "/" + ref.Repository + ":" + ref.Reference(), nil
+}
+
+// encode returns the JSON of the manifests and its digest.
+func encode(m *registry.Manifests) (string, string, error) {
+	// the canonical form, so that the semantically identical manifests have the same digest.
+	data, err := json.Marshal(registry.Canonical(m))
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256(data)
+	return string(data), "sha256:" + hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/format"
+)
+
+var listCommand = &command{
+	name:    "list",
+	usage:   "list [options]",
+	summary: "list the tracked images and their stored manifests",
+	run:     runList,
+}
+
+// listItem is an image in the output of the list command.
+type listItem struct {
+	Image     string   `json:"image"`
+	Groups    []string `json:"groups,omitempty"`
+	Checked   bool     `json:"checked"`
+	MediaType string   `json:"mediaType,omitempty"`
+	Platforms []string `json:"platforms,omitempty"`
+}
+
+func runList(cmd *command, args []string) error {
+	fs := newFlagSet(cmd)
+	addConfigFlags(fs)
+	addTenantFlags(fs)
+	addStoreFlags(fs)
+	addGroupFlags(fs)
+	addFormatFlags(fs, "table")
+	fs.Parse(args)
+
+	if err := loadConfig(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := loadStatus(); err != nil {
+		return fmt.Errorf("failed to load status: %w", err)
+	}
+
+	items := make([]*listItem, 0, len(targets))
+	for _, image := range targets {
+		item := &listItem{Image: image, Groups: targetGroups(image)}
+		if m := status[image]; m != nil {
+			item.Checked = true
+			item.MediaType = m.MediaType
+			for _, desc := range m.Manifests {
+				item.Platforms = append(item.Platforms, desc.Platform.String())
+			}
+		}
+		items = append(items, item)
+	}
+	return format.Write(os.Stdout, outputFormat, items, func(w io.Writer) error {
+		return printList(w, items)
+	})
+}
+
+func printList(w io.Writer, items []*listItem) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "IMAGE\tMEDIA TYPE\tPLATFORMS")
+	for _, item := range items {
+		if !item.Checked {
+			fmt.Fprintf(tw, "%s\t(not checked yet)\t\n", item.Image)
+			continue
+		}
+		platforms := "-"
+		if len(item.Platforms) > 0 {
+			platforms = fmt.Sprint(len(item.Platforms))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", item.Image, item.MediaType, platforms)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+var loginCommand = &command{
+	name:    "login",
+	usage:   "login [options] host",
+	summary: "validate the credentials for the registry, and store them in the credential store",
+	run:     runLogin,
+}
+
+// authFile returns the path to the credential store of diuc.
+// It is $DIUC_AUTH_FILE, ${XDG_CONFIG_HOME}/diuc/auth.json or ~/.config/diuc/auth.json.
+func authFile() (string, error) {
+	if path := os.Getenv("DIUC_AUTH_FILE"); path != "" {
+		return path, nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "diuc", "auth.json"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "diuc", "auth.json"), nil
+}
+
+// loadAuthFile loads the credential store. It returns an empty store if the file doesn't exist.
+func loadAuthFile() (*registry.DockerConfig, error) {
+	path, err := authFile()
+	if err != nil {
+		return nil, err
+	}
+	auth, err := registry.LoadDockerConfigFile(path)
+	if os.IsNotExist(err) {
+		return registry.NewDockerConfig(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	return auth, nil
+}
+
+func runLogin(cmd *command, args []string) error {
+	fs := newFlagSet(cmd)
+	addClientFlags(fs)
+	username := fs.String("u", os.Getenv("DIUC_USERNAME"), "the `username` (default $DIUC_USERNAME)")
+	password := fs.String("p", "", "the `password`; prefer -password-stdin or $DIUC_PASSWORD not to leave it in the shell history")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from stdin")
+	repo := fs.String("repository", "", "also check that the credentials grant the pull access to the `repository`")
+	noVerify := fs.Bool("no-verify", false, "store the credentials without validation")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("want exactly one host")
+	}
+	host := fs.Arg(0)
+
+	stdin := bufio.NewReader(os.Stdin)
+	if *passwordStdin {
+		if *password != "" {
+			return errors.New("-p and -password-stdin are exclusive")
+		}
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		*password = strings.TrimRight(string(data), "\r\n")
+	}
+	if *password == "" {
+		*password = os.Getenv("DIUC_PASSWORD")
+	}
+	if *username == "" {
+		if *passwordStdin {
+			return errors.New("the username is required with -password-stdin")
+		}
+		v, err := prompt(stdin, "Username: ", false)
+		if err != nil {
+			return err
+		}
+		*username = v
+	}
+	if *password == "" {
+		v, err := prompt(stdin, "Password: ", true)
+		if err != nil {
+			return err
+		}
+		*password = v
+	}
+	if *username == "" || *password == "" {
+		return errors.New("the username and the password are required")
+	}
+
+	if !*noVerify {
+		c, err := newClient()
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := c.Login(ctx, host, *username, *password, registry.WithValidation(*repo)); err != nil {
+			return err
+		}
+	}
+
+	auth, err := loadAuthFile()
+	if err != nil {
+		return err
+	}
+	auth.Set(host, *username, *password)
+	path, err := authFile()
+	if err != nil {
+		return err
+	}
+	if err := auth.SaveFile(path); err != nil {
+		return err
+	}
+	log.Printf("login succeeded: the credentials for %s are stored in %s", host, path)
+	return nil
+}
+
+// prompt reads a line from stdin. The echo is disabled if secret is true and stdin is a terminal.
+func prompt(stdin *bufio.Reader, message string, secret bool) (string, error) {
+	fmt.Fprint(os.Stderr, message)
+	if secret && setEcho(false) {
+		defer func() {
+			setEcho(true)
+			fmt.Fprintln(os.Stderr)
+		}()
+	}
+	line, err := stdin.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// setEcho turns the echo of the terminal on or off, and reports whether it succeeded.
+// It uses stty(1) because the standard library has no terminal API.
+func setEcho(on bool) bool {
+	arg := "-echo"
+	if on {
+		arg = "echo"
+	}
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = os.Stdin
+	return cmd.Run() == nil
+}
@@ -1,21 +1,15 @@
 package main
 
 import (
-	"context"
-	"encoding/json"
-	"log"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
-	"os/exec"
-	"path/filepath"
-	"reflect"
-	"sort"
 	"strings"
-	"time"
-
-	"github.com/shogo82148/docker-image-update-checker/registry"
 )
 
-var targets = []string{
+// defaultTargets are the images tracked if no config file is found.
+var defaultTargets = []string{
 	// alpine
 	"alpine:3.17",
 	"alpine:3.16",
@@ -51,121 +45,124 @@ var targets = []string{
 	"lambci/lambda:provided.al2",
 }
 
-var status map[string]*registry.Manifests
-var updated map[string]struct{}
+// progName is the name of the command in the usages.
+const progName = "diuc"
 
-func loadStatus() error {
-	status = map[string]*registry.Manifests{}
-	for _, image := range targets {
-		host, repo, tag := registry.GetRepository(image)
-		statusFile := filepath.FromSlash("manifests/" + host + "/" + repo + "/" + tag + ".json")
-		data, err := os.ReadFile(statusFile)
-		if os.IsNotExist(err) {
-			continue
-		}
-		if err != nil {
-			return err
-		}
+// command is a subcommand of the CLI.
+type command struct {
+	name string
 
-		var manifests *registry.Manifests
-		if err := json.Unmarshal(data, &manifests); err != nil {
-			continue
-		}
-		status[image] = manifests
-	}
-	return nil
+	// usage is the synopsis of the command, e.g. "list [options]".
+	usage string
+
+	// summary is a one-line description of the command.
+	summary string
+
+	// hidden commands are not shown in the usage.
+	hidden bool
+
+	run func(cmd *command, args []string) error
 }
 
-func saveStatus() error {
-	for image := range updated {
-		host, repo, tag := registry.GetRepository(image)
-		statusFile := filepath.FromSlash("manifests/" + host + "/" + repo + "/" + tag + ".json")
-		if err := os.MkdirAll(filepath.Dir(statusFile), 0755); err != nil {
-			return err
-		}
-		data, err := json.MarshalIndent(status[image], "", "    ")
-		if err != nil {
-			return err
-		}
-		if err := os.WriteFile(statusFile, data, 0644); err != nil {
-			return err
-		}
+// commands are the subcommands. The first one is the default.
+var commands []*command
+
+func init() {
+	commands = []*command{
+		checkCommand,
+		listCommand,
+		addCommand,
+		removeCommand,
+		pauseCommand,
+		resumeCommand,
+		diffCommand,
+		discoverCommand,
+		historyCommand,
+		statsCommand,
+		exportCommand,
+		importCommand,
+		pruneCommand,
+		migrateCommand,
+		loginCommand,
+		configCommand,
+		verifyCommand,
+		versionCommand,
+		completionCommand,
+		completeCommand,
 	}
-	return commit()
 }
 
-func checkUpdates() {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	c := registry.New()
-	for _, image := range targets {
-		if err := checkUpdate(ctx, c, image); err != nil {
-			log.Printf("failed to get %s: %v", image, err)
+// newFlagSet returns the flag set of the command with the usage.
+func newFlagSet(cmd *command) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "usage: %s %s\n\n%s\n", progName, cmd.usage, cmd.summary)
+		if hasFlags(fs) {
+			fmt.Fprintf(out, "\noptions:\n")
+			fs.PrintDefaults()
 		}
 	}
+	return fs
 }
 
-func checkUpdate(ctx context.Context, c *registry.Client, image string) error {
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
+func hasFlags(fs *flag.FlagSet) bool {
+	var ok bool
+	fs.VisitAll(func(*flag.Flag) { ok = true })
+	return ok
+}
 
-	log.Printf("getting manifest: %s", image)
-	m, err := c.GetManifests(ctx, image)
-	if err != nil {
-		return err
-	}
-	if !reflect.DeepEqual(status[image], m) {
-		log.Printf("updated: %s", image)
-		updated[image] = struct{}{}
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s <command> [options] [arguments]\n\ncommands:\n", progName)
+	for _, cmd := range commands {
+		if cmd.hidden {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
 	}
-	status[image] = m
-	return nil
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for the options of the command.\n", progName)
+	fmt.Fprintf(os.Stderr, "Without a command, %s runs '%s' for compatibility.\n", progName, commands[0].name)
 }
 
-func commit() error {
-	if len(updated) == 0 {
-		return nil
-	}
-	updates := make([]string, 0, len(updated))
-	for image := range updated {
-		updates = append(updates, image)
-	}
-	sort.Strings(updates)
+// exitStatus is an error that makes the command exit with the status code, without printing any messages.
+type exitStatus int
 
-	git, err := exec.LookPath("git")
-	if err != nil {
-		return err
-	}
-	commands := []struct {
-		cmd  string
-		args []string
-	}{
-		{git, []string{"config", "--local", "user.name", "Ichinose Shogo"}},
-		{git, []string{"config", "--local", "user.email", "shogo82148@gmail.com"}},
-		{git, []string{"add", "."}},
-		{git, []string{"commit", "-m", "update: " + strings.Join(updates, ", ")}},
-		{git, []string{"push", "origin", "main"}},
-	}
-	for _, command := range commands {
-		if err := exec.Command(command.cmd, command.args...).Run(); err != nil {
-			return err
-		}
-	}
-	return nil
+func (s exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(s))
 }
 
 func main() {
-	log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds)
-
-	updated = map[string]struct{}{}
-	if err := loadStatus(); err != nil {
-		log.Fatalf("failed to load status: %v", err)
+	args := os.Args[1:]
+
+	// no command means "check", which was the only behavior in the old versions.
+	cmd := commands[0]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name := args[0]
+		args = args[1:]
+		if name == "help" {
+			usage()
+			return
+		}
+		cmd = nil
+		for _, c := range commands {
+			if c.name == name {
+				cmd = c
+				break
+			}
+		}
+		if cmd == nil {
+			fmt.Fprintf(os.Stderr, "%s: unknown command %q\n\n", progName, name)
+			usage()
+			os.Exit(2)
+		}
 	}
 
-	checkUpdates()
-
-	if err := saveStatus(); err != nil {
-		log.Fatalf("failed to save status: %v", err)
+	if err := cmd.run(cmd, args); err != nil {
+		var code exitStatus
+		if errors.As(err, &code) {
+			os.Exit(int(code))
+		}
+		fmt.Fprintf(os.Stderr, "%s %s: %v\n", progName, cmd.name, err)
+		os.Exit(1)
 	}
 }
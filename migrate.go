@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+var migrateCommand = &command{
+	name:    "migrate",
+	usage:   "migrate [options]",
+	summary: "move the status files to the paths of the configured layout and escaping, and commit them",
+	run:     runMigrate,
+}
+
+func runMigrate(cmd *command, args []string) error {
+	fs := newFlagSet(cmd)
+	addConfigFlags(fs)
+	addTenantFlags(fs)
+	addGitFlags(fs)
+	dryRun := fs.Bool("dry-run", false, "only show the files to be moved")
+	fs.Parse(args)
+
+	if err := loadConfig(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	moves, err := statusFileMoves()
+	if err != nil {
+		return err
+	}
+	if len(moves) == 0 {
+		log.Printf("nothing to migrate")
+		return nil
+	}
+	if *dryRun {
+		for _, m := range moves {
+			log.Printf("would move %s to %s", m[0], m[1])
+		}
+		return nil
+	}
+	if err := migrateStatusFiles(); err != nil {
+		return err
+	}
+	return commitChanges("migrate", nil, fmt.Sprintf("move %d status files", len(moves)))
+}
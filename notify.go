@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/config"
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// notifyTimeout is the timeout for sending a notification.
+const notifyTimeout = 30 * time.Second
+
+// UpdateEvent is an update of an image found in a check run.
+type UpdateEvent struct {
+	Image string
+
+	// OldDigest is the digest of the stored manifests before the update, if it is known.
+	OldDigest string
+	NewDigest string
+
+	// Changes are the changes of the platforms.
+	Changes *registry.ManifestsDiff
+
+	// Metadata is the metadata of the image in the config, e.g. the owner and the release notes.
+	Metadata *config.Metadata
+}
+
+// CheckError is a failure of the check of an image: failed, skipped by the timeout of the run, or mutated.
+type CheckError struct {
+	Image   string
+	Status  string
+	Message string
+}
+
+func (e CheckError) Error() string {
+	return e.Image + ": " + e.Status + ": " + e.Message
+}
+
+// Notifier is notified of the results of a check run.
+// NotifyUpdates is called if any images are updated, and NotifyFailures if any images failed.
+type Notifier interface {
+	NotifyUpdates(ctx context.Context, events []UpdateEvent) error
+	NotifyFailures(ctx context.Context, errs []CheckError) error
+}
+
+// SummaryNotifier is notified of the whole summary of a check run at once, if any images are not unchanged,
+// e.g. for the receivers that need the stale images and the counts of the statuses as well.
+type SummaryNotifier interface {
+	NotifySummary(ctx context.Context, s *summary) error
+}
+
+// notifyConfig returns the notification of the selected tenant, or the one of the config.
+func notifyConfig() *config.Notify {
+	if tenant != nil {
+		return tenant.Notify
+	}
+	return cfg.Notify
+}
+
+// newNotifiers returns the notifiers of the configuration, with the secrets resolved.
+func newNotifiers(ctx context.Context, n *config.Notify) ([]Notifier, []SummaryNotifier, error) {
+	if n == nil {
+		return nil, nil, nil
+	}
+	var notifiers []Notifier
+	var summaries []SummaryNotifier
+	if n.Webhook != "" {
+		endpoint, err := config.Resolve(ctx, n.Webhook)
+		if err != nil {
+			return nil, nil, fmt.Errorf("webhook: %w", err)
+		}
+		secret, err := config.Resolve(ctx, n.Secret)
+		if err != nil {
+			return nil, nil, fmt.Errorf("secret: %w", err)
+		}
+		summaries = append(summaries, &webhookNotifier{endpoint: endpoint, secret: secret})
+	}
+	if n.Slack != "" {
+		endpoint, err := config.Resolve(ctx, n.Slack)
+		if err != nil {
+			return nil, nil, fmt.Errorf("slack: %w", err)
+		}
+		notifiers = append(notifiers, &slackNotifier{endpoint: endpoint})
+	}
+	return notifiers, summaries, nil
+}
+
+// hasNews reports whether the results have anything to notify, i.e. any images are not unchanged.
+func hasNews() bool {
+	for _, r := range checkResults {
+		if r.Status != checkUnchanged || r.Stale {
+			return true
+		}
+	}
+	return false
+}
+
+// updateEvents returns the updates of the check run.
+func updateEvents() []UpdateEvent {
+	var events []UpdateEvent
+	for _, r := range checkResults {
+		if r.Status != checkUpdated {
+			continue
+		}
+		events = append(events, UpdateEvent{
+			Image:     r.Image,
+			OldDigest: r.OldDigest,
+			NewDigest: r.Digest,
+			Changes:   r.Changes,
+			Metadata:  r.Metadata,
+		})
+	}
+	return events
+}
+
+// checkErrors returns the failures of the check run.
+func checkErrors() []CheckError {
+	var errs []CheckError
+	for _, r := range failedResults() {
+		errs = append(errs, CheckError{Image: r.Image, Status: r.Status, Message: r.Error})
+	}
+	return errs
+}
+
+// notify notifies all the notifiers of the results of the check run.
+// The failure of a notifier doesn't prevent the others from being notified.
+func notify(startedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	notifiers, summaries, err := newNotifiers(ctx, notifyConfig())
+	if err != nil {
+		return err
+	}
+	events := updateEvents()
+	failures := checkErrors()
+	var errs []string
+	if hasNews() {
+		for _, n := range summaries {
+			if err := n.NotifySummary(ctx, newSummary(startedAt)); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	for _, n := range notifiers {
+		if len(events) > 0 {
+			if err := n.NotifyUpdates(ctx, events); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+		if len(failures) > 0 {
+			if err := n.NotifyFailures(ctx, failures); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// webhookNotifier posts the summary of the run to the webhook, signed with the secret if it is not empty.
+type webhookNotifier struct {
+	endpoint string
+	secret   string
+}
+
+// NotifySummary implements SummaryNotifier.
+func (n *webhookNotifier) NotifySummary(ctx context.Context, s *summary) error {
+	body, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	if n.secret != "" {
+		mac := hmac.New(sha256.New, []byte(n.secret))
+		mac.Write(body)
+		header.Set("X-Diuc-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	if err := postJSON(ctx, n.endpoint, body, header); err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	log.Printf("notified the results to the webhook")
+	return nil
+}
+
+// postJSON posts the JSON body to the endpoint with the header.
+func postJSON(ctx context.Context, endpoint string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return hideURL(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return hideURL(err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// hideURL returns the error without the URL, which may contain the credentials.
+func hideURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
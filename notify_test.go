@@ -0,0 +1,115 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/config"
+)
+
+// testReceiver records the bodies posted to it.
+type testReceiver struct {
+	mu         sync.Mutex
+	bodies     []string
+	signatures []string
+}
+
+func (r *testReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bodies = append(r.bodies, string(body))
+	r.signatures = append(r.signatures, req.Header.Get("X-Diuc-Signature-256"))
+}
+
+func TestNotify(t *testing.T) {
+	tests := []struct {
+		name      string
+		results   []*checkResult
+		summaries int
+		slack     []string
+	}{
+		{
+			name: "updated and failed",
+			results: []*checkResult{
+				{Image: "alpine:3.17", Status: checkUpdated, Digest: "sha256:1111111111111111111111111111111111111111111111111111111111111111"},
+				{Image: "ubuntu:22.04", Status: checkFailed, Error: "unexpected status code: 500"},
+			},
+			summaries: 1,
+			slack:     []string{"*1 images updated*", "*1 images failed*"},
+		},
+		{
+			name: "stale",
+			results: []*checkResult{
+				{Image: "alpine:3.17", Status: checkUnchanged, Stale: true},
+			},
+			summaries: 1,
+		},
+		{
+			name: "unchanged",
+			results: []*checkResult{
+				{Image: "alpine:3.17", Status: checkUnchanged},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhook, slack := &testReceiver{}, &testReceiver{}
+			webhookServer := httptest.NewServer(webhook)
+			t.Cleanup(webhookServer.Close)
+			slackServer := httptest.NewServer(slack)
+			t.Cleanup(slackServer.Close)
+
+			useConfig(t, &config.Config{
+				Images: []*config.Image{{Image: "alpine:3.17"}, {Image: "ubuntu:22.04"}},
+				Notify: &config.Notify{Webhook: webhookServer.URL, Secret: "secret", Slack: slackServer.URL},
+			})
+			checkResults = tt.results
+			t.Cleanup(func() { checkResults = nil })
+
+			if err := notify(time.Now()); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(webhook.bodies) != tt.summaries {
+				t.Fatalf("want %d summaries, got %d", tt.summaries, len(webhook.bodies))
+			}
+			for i, body := range webhook.bodies {
+				var s summary
+				if err := json.Unmarshal([]byte(body), &s); err != nil {
+					t.Fatal(err)
+				}
+				if len(s.Images) != len(tt.results) {
+					t.Errorf("want %d images in the summary, got %d", len(tt.results), len(s.Images))
+				}
+				mac := hmac.New(sha256.New, []byte("secret"))
+				mac.Write([]byte(body))
+				if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); webhook.signatures[i] != want {
+					t.Errorf("want the signature %s, got %s", want, webhook.signatures[i])
+				}
+			}
+
+			if len(slack.bodies) != len(tt.slack) {
+				t.Fatalf("want %d messages to Slack, got %d", len(tt.slack), len(slack.bodies))
+			}
+			for i, body := range slack.bodies {
+				var m slackMessage
+				if err := json.Unmarshal([]byte(body), &m); err != nil {
+					t.Fatal(err)
+				}
+				if !strings.HasPrefix(m.Text, tt.slack[i]) {
+					t.Errorf("want the message starting with %q, got %q", tt.slack[i], m.Text)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/storage"
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// the media types of the OCI image index and manifest.
+const (
+	mediaTypeOCIIndex    = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIManifest = "application/vnd.oci.image.manifest.v1+json"
+)
+
+// annotationRefName is the annotation of the descriptors in index.json of the OCI image layout,
+// which is the image, e.g. "registry-1.docker.io/library/alpine:3.17".
+const annotationRefName = "org.opencontainers.image.ref.name"
+
+// ociLayout is the content of the oci-layout file.
+type ociLayout struct {
+	ImageLayoutVersion string `json:"imageLayoutVersion"`
+}
+
+// ociIndex is the content of index.json.
+type ociIndex struct {
+	SchemaVersion int                    `json:"schemaVersion"`
+	MediaType     string                 `json:"mediaType"`
+	Manifests     []*registry.Descriptor `json:"manifests"`
+}
+
+// writeOCILayout writes the stored manifests of the images into the OCI image layout in dir.
+// The blobs are the manifests as the registry served them, so that their digests are the ones in the registry,
+// and index.json refers to them by the images.
+// The images stored without the bodies of the responses, e.g. before they were kept, are skipped.
+// The blobs referred from the manifests, e.g. the platform manifests and the layers, are not included.
+// It returns the number of the exported images.
+func writeOCILayout(dir string, images []string) (int, error) {
+	blobs := filepath.Join(dir, "blobs", "sha256")
+	if err := os.MkdirAll(blobs, 0755); err != nil {
+		return 0, err
+	}
+
+	index := &ociIndex{SchemaVersion: 2, MediaType: mediaTypeOCIIndex, Manifests: []*registry.Descriptor{}}
+	sort.Strings(images)
+	for _, image := range images {
+		doc, err := loadDocument(image)
+		if err != nil {
+			return 0, err
+		}
+		if doc == nil || doc.Manifest == nil {
+			continue
+		}
+		if len(doc.Raw) == 0 {
+			log.Printf("WARNING: %s is skipped, because it is stored without the manifests served by the registry; it is exported after the next update", image)
+			continue
+		}
+		sum := sha256.Sum256(doc.Raw)
+		digest := "sha256:" + hex.EncodeToString(sum[:])
+		if doc.Digest != "" && doc.Digest != digest {
+			// e.g. the signed manifests of the schema version 1, whose digests exclude the signatures.
+			log.Printf("WARNING: %s is skipped, because its digest %s is not the one of its content", image, doc.Digest)
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(blobs, digest[len("sha256:"):]), doc.Raw, 0644); err != nil {
+			return 0, err
+		}
+
+		index.Manifests = append(index.Manifests, &registry.Descriptor{
+			MediaType:   ociMediaType(doc),
+			Digest:      digest,
+			Size:        int64(len(doc.Raw)),
+			Annotations: map[string]string{annotationRefName: image},
+		})
+	}
+
+	if err := writeJSONFile(filepath.Join(dir, "oci-layout"), &ociLayout{ImageLayoutVersion: "1.0.0"}); err != nil {
+		return 0, err
+	}
+	if err := writeJSONFile(filepath.Join(dir, "index.json"), index); err != nil {
+		return 0, err
+	}
+	return len(index.Manifests), nil
+}
+
+// loadDocument returns the stored manifests of the image with the metadata of the retrieval, if the store keeps them.
+func loadDocument(image string) (*storage.Document, error) {
+	if s, ok := store.(documentStore); ok {
+		return s.LoadDocument(image)
+	}
+	m, err := store.Load(image)
+	if err != nil || m == nil {
+		return nil, err
+	}
+	return &storage.Document{Manifest: m}, nil
+}
+
+// ociMediaType returns the media type of the stored manifests.
+// The OCI manifests may omit their media types, so it is guessed from the content.
+func ociMediaType(doc *storage.Document) string {
+	if doc.MediaType != "" {
+		// without the parameters, e.g. "; charset=utf-8".
+		if i := strings.IndexByte(doc.MediaType, ';'); i >= 0 {
+			return strings.TrimSpace(doc.MediaType[:i])
+		}
+		return doc.MediaType
+	}
+	if doc.Manifest.MediaType != "" {
+		return doc.Manifest.MediaType
+	}
+	if len(doc.Manifest.Manifests) > 0 {
+		return mediaTypeOCIIndex
+	}
+	return mediaTypeOCIManifest
+}
+
+// writeJSONFile writes v into the file in JSON.
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
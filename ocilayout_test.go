@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/storage"
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+func TestWriteOCILayout(t *testing.T) {
+	statusDir = t.TempDir()
+	t.Cleanup(func() { statusDir = "manifests" })
+
+	// the registry serves the manifests in its own formatting, which differs from the canonical form.
+	raw := []byte(`{
+   "schemaVersion": 2,
+   "mediaType": "application/vnd.oci.image.index.v1+json",
+   "manifests": []
+}`)
+	sum := sha256.Sum256(raw)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	m := &registry.Manifests{SchemaVersion: 2, MediaType: "application/vnd.oci.image.index.v1+json"}
+	docs := map[string]*storage.Document{
+		"registry-1.docker.io/library/alpine:3.17": {Digest: digest, MediaType: "application/vnd.oci.image.index.v1+json", Manifest: m, Raw: raw},
+		// stored before the raw manifests were kept.
+		"registry-1.docker.io/library/alpine:3.16": {Digest: "sha256:old", Manifest: m},
+	}
+	var images []string
+	for image, doc := range docs {
+		if err := (fileStore{}).SaveDocument(image, doc); err != nil {
+			t.Fatal(err)
+		}
+		images = append(images, image)
+	}
+
+	dir := t.TempDir()
+	n, err := writeOCILayout(dir, images)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("want 1 image, got %d", n)
+	}
+
+	blob, err := os.ReadFile(filepath.Join(dir, "blobs", "sha256", digest[len("sha256:"):]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(blob) != string(raw) {
+		t.Errorf("want the manifests as served, got %s", blob)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index ociIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Manifests) != 1 {
+		t.Fatalf("want 1 manifest, got %d", len(index.Manifests))
+	}
+	desc := index.Manifests[0]
+	if desc.Digest != digest || desc.Size != int64(len(raw)) || desc.Annotations[annotationRefName] != "registry-1.docker.io/library/alpine:3.17" {
+		t.Errorf("unexpected descriptor: %+v", desc)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/config"
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// patterns are the images with the tag patterns in the config, e.g. "alpine:3.*".
+// They are expanded into the concrete tags in targets.
+var patterns []string
+
+// patternConfigs are the configs of the patterns. The concrete tags share the config of their pattern.
+var patternConfigs map[string]*config.Image
+
+// patternTargets are the concrete images expanded from each pattern.
+var patternTargets map[string][]string
+
+// removedTags are the stored tags that no longer exist in the registries.
+var removedTags []string
+
+// storedTags returns the images of the stored tags that match the tag pattern of the image.
+func storedTags(image string) ([]string, error) {
+	ref, err := registry.ParseReferencePattern(image)
+	if err != nil {
+		return nil, err
+	}
+	images, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	prefix := ref.Host + "/" + ref.Repository + ":"
+	var tags []string
+	for _, stored := range images {
+		if strings.HasPrefix(stored, prefix) {
+			tags = append(tags, strings.TrimPrefix(stored, prefix))
+		}
+	}
+	return registry.ExpandTagPattern(image, tags), nil
+}
+
+// expandStoredPatterns adds the stored tags matching the patterns to the targets.
+// It works offline, so the tags published after the last check are not included.
+func expandStoredPatterns() error {
+	patternTargets = make(map[string][]string, len(patterns))
+	for _, pattern := range patterns {
+		images, err := storedTags(pattern)
+		if err != nil {
+			return err
+		}
+		for _, image := range images {
+			addPatternTarget(pattern, image)
+		}
+	}
+	return nil
+}
+
+func addPatternTarget(pattern, image string) {
+	patternTargets[pattern] = append(patternTargets[pattern], image)
+	if _, ok := targetConfigs[image]; ok {
+		// it is also tracked explicitly.
+		return
+	}
+	targets = append(targets, image)
+	targetConfigs[image] = patternConfigs[pattern]
+}
+
+// expandPatterns lists the tags of the patterns in the registries,
+// adds the new tags to the targets, and removes the tags that no longer exist.
+// If listing the tags fails, the stored tags are checked.
+func expandPatterns(c *registry.Client) {
+	removed := map[string]struct{}{}
+	for _, pattern := range patterns {
+		ctx, cancel := context.WithTimeout(context.Background(), checkTimeout(pattern))
+		tags, err := c.ListTags(ctx, pattern)
+		cancel()
+		if err != nil {
+			log.Printf("failed to list the tags of %s, checking the stored tags: %v", pattern, err)
+			continue
+		}
+
+		live := map[string]struct{}{}
+		for _, image := range registry.ExpandTagPattern(pattern, tags) {
+			live[image] = struct{}{}
+			if !contains(patternTargets[pattern], image) {
+				log.Printf("new tag matching %s: %s", pattern, image)
+				addPatternTarget(pattern, image)
+			}
+		}
+		for _, image := range patternTargets[pattern] {
+			if _, ok := live[image]; !ok {
+				removed[image] = struct{}{}
+			}
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
+
+	images := targets[:0]
+	for _, image := range targets {
+		if _, ok := removed[image]; ok {
+			log.Printf("WARNING: %s no longer exists in the registry", image)
+			removedTags = append(removedTags, image)
+			continue
+		}
+		images = append(images, image)
+	}
+	targets = images
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// pinnedDigest returns the digest of the image pinned with the expected tag, e.g. "alpine:3.17@sha256:...".
+// It returns an empty string if the image is not pinned, or if it has no tag to compare with.
+func pinnedDigest(image string) string {
+	ref, err := registry.ParseReference(image)
+	if err != nil || ref.Tag == "" || ref.Digest == "" {
+		return ""
+	}
+	return ref.Digest
+}
+
+// fetchName returns the image to request for checking the image.
+// The pinned images are requested by the tags, to detect that the tags have moved away from the pinned digests.
+func fetchName(image string) string {
+	if pinnedDigest(image) == "" {
+		return image
+	}
+	return image[:strings.LastIndex(image, "@")]
+}
+
+// fetchNames returns fetchName of the images.
+func fetchNames(images []string) []string {
+	names := make([]string, 0, len(images))
+	for _, image := range images {
+		names = append(names, fetchName(image))
+	}
+	return names
+}
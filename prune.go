@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+var pruneCommand = &command{
+	name:    "prune",
+	usage:   "prune [options]",
+	summary: "delete the stored manifests of the images that are no longer tracked, and commit the cleanup",
+	run:     runPrune,
+}
+
+func runPrune(cmd *command, args []string) error {
+	fs := newFlagSet(cmd)
+	addConfigFlags(fs)
+	addTenantFlags(fs)
+	addGitFlags(fs)
+	addClientFlags(fs)
+	addCatalogFlags(fs)
+	dryRun := fs.Bool("dry-run", false, "only show the files to be deleted")
+	fs.Parse(args)
+
+	if err := loadConfig(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if len(catalogHosts) > 0 {
+		// the images in the catalogs are tracked, too.
+		c, err := newClient()
+		if err != nil {
+			return err
+		}
+		if err := login(c); err != nil {
+			return err
+		}
+		if err := loadCatalogs(c); err != nil {
+			return err
+		}
+	}
+
+	files, images, err := orphanedStatusFiles(nil)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		log.Printf("nothing to prune")
+		return nil
+	}
+	if *dryRun {
+		return removeStatusFiles(files, true)
+	}
+	if err := loadStatusIndex(); err != nil {
+		return fmt.Errorf("failed to load status index: %w", err)
+	}
+	if err := removeStatusFiles(files, false); err != nil {
+		return err
+	}
+	forgetImages(images)
+	if err := saveStatusIndex(); err != nil {
+		return fmt.Errorf("failed to save status index: %w", err)
+	}
+	return commitChanges("prune", images, "")
+}
+
+// pruneOrphans is given by the flag of check. It removes the status files of the images that are no longer tracked in the commit of the run.
+var pruneOrphans bool
+
+// prunedImages are the images whose status files are removed in this run.
+var prunedImages []string
+
+// pruneOrphanedStatus removes the status files of the images that are no longer tracked, and their metadata in the status index.
+// The tags removed from the patterns are handled by the checks.
+func pruneOrphanedStatus() error {
+	if len(selectedGroups) > 0 {
+		// the images in the other groups are not in the targets.
+		return errors.New("-prune can't be used with -group")
+	}
+	if _, ok := store.(fileStore); !ok {
+		return errors.New("-prune supports only the files in the repository")
+	}
+	files, images, err := orphanedStatusFiles(removedTags)
+	if err != nil || len(files) == 0 {
+		return err
+	}
+	if err := removeStatusFiles(files, dryRun); err != nil {
+		return err
+	}
+	if !dryRun {
+		forgetImages(images)
+	}
+	prunedImages = images
+	return nil
+}
+
+// orphanedStatusFiles returns the status files of the images that are no longer in the targets, and their images.
+// The stored manifests of the paused and disabled images, and of the images in keep, are kept.
+func orphanedStatusFiles(keep []string) (files, images []string, err error) {
+	tracked := make(map[string]struct{}, len(targets))
+	for _, image := range append(append([]string(nil), targets...), keep...) {
+		path, err := statusFile(image)
+		if err != nil {
+			return nil, nil, err
+		}
+		tracked[path] = struct{}{}
+	}
+	// keep the stored manifests of the paused and disabled images.
+	for _, image := range inactiveImages {
+		images := []string{image}
+		if registry.IsTagPattern(image) {
+			tags, err := storedTags(image)
+			if err != nil {
+				return nil, nil, err
+			}
+			images = tags
+		}
+		for _, image := range images {
+			path, err := statusFile(image)
+			if err != nil {
+				return nil, nil, err
+			}
+			tracked[path] = struct{}{}
+		}
+	}
+
+	err = walkStatusFiles(func(image, path string) error {
+		// compare the normalized paths, so that the files under the aliases of the hosts are kept.
+		if normalized, err := statusFile(image); err == nil {
+			if _, ok := tracked[normalized]; ok {
+				return nil
+			}
+		}
+		files = append(files, path)
+		images = append(images, image)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(files)
+	sort.Strings(images)
+	return files, images, nil
+}
+
+// removeStatusFiles removes the status files and the directories next to them.
+// If dryRun is true, it only logs the files.
+func removeStatusFiles(files []string, dryRun bool) error {
+	for _, path := range files {
+		if dryRun {
+			log.Printf("would remove %s", path)
+			continue
+		}
+		log.Printf("remove %s", path)
+		if err := removeSidecars(path); err != nil {
+			return err
+		}
+		recordChange(path)
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removeEmptyDirs(filepath.Dir(path))
+	}
+	return nil
+}
+
+// removeEmptyDirs removes dir and its parents while they are empty, up to statusDir.
+func removeEmptyDirs(dir string) {
+	for dir != statusDir && dir != "." && dir != string(filepath.Separator) {
+		if err := os.Remove(dir); err != nil {
+			// not empty
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/config"
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// useConfig applies the config as loadConfig does, and restores the state of the run after the test.
+func useConfig(t *testing.T, c *config.Config) {
+	t.Helper()
+	oldCfg, oldTenant, oldTenantName := cfg, tenant, tenantName
+	oldDir, oldLayout, oldIndexFile := statusDir, statusLayout, statusIndexFile
+	oldChangelog, oldAuditLog := changelogFile, auditLogFile
+	oldTargets, oldTargetConfigs, oldInactive := targets, targetConfigs, inactiveImages
+	oldPatterns, oldPatternConfigs, oldPatternTargets := patterns, patternConfigs, patternTargets
+	t.Cleanup(func() {
+		cfg, tenant, tenantName = oldCfg, oldTenant, oldTenantName
+		statusDir, statusLayout, statusIndexFile = oldDir, oldLayout, oldIndexFile
+		changelogFile, auditLogFile = oldChangelog, oldAuditLog
+		targets, targetConfigs, inactiveImages = oldTargets, oldTargetConfigs, oldInactive
+		patterns, patternConfigs, patternTargets = oldPatterns, oldPatternConfigs, oldPatternTargets
+		changedFiles = nil
+	})
+	if err := applyConfig(c); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeStatusFiles stores empty manifests of the images, and returns their status files.
+func writeStatusFiles(t *testing.T, images ...string) map[string]string {
+	t.Helper()
+	files := make(map[string]string, len(images))
+	for _, image := range images {
+		if err := (fileStore{}).Save(image, &registry.Manifests{SchemaVersion: 2}); err != nil {
+			t.Fatal(err)
+		}
+		path, err := statusFile(image)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[image] = path
+	}
+	changedFiles = nil
+	return files
+}
+
+func TestOrphanedStatusFiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout string
+		images []*config.Image
+		keep   []string
+		stored []string
+		want   []string
+	}{
+		{
+			name:   "untracked image",
+			images: []*config.Image{{Image: "alpine:3.17"}},
+			stored: []string{"alpine:3.16", "alpine:3.17"},
+			want:   []string{"alpine:3.16"},
+		},
+		{
+			name:   "flat layout",
+			layout: "flat",
+			images: []*config.Image{{Image: "ghcr.io/shogo82148/foo/bar:latest"}},
+			stored: []string{"ghcr.io/shogo82148/foo/bar:latest", "ghcr.io/shogo82148/foo/baz:latest"},
+			want:   []string{"ghcr.io/shogo82148/foo/baz:latest"},
+		},
+		{
+			name:   "pattern layout",
+			layout: "{repository:flat}/{host}/{tag}.json",
+			images: []*config.Image{{Image: "alpine:3.17"}, {Image: "quay.io/prometheus/node-exporter:v1.7.0"}},
+			stored: []string{"alpine:3.17", "quay.io/prometheus/node-exporter:v1.7.0", "quay.io/prometheus/node-exporter:v1.6.0"},
+			want:   []string{"quay.io/prometheus/node-exporter:v1.6.0"},
+		},
+		{
+			name:   "tag pattern",
+			images: []*config.Image{{Image: "alpine:3.*"}},
+			stored: []string{"alpine:3.16", "alpine:3.17", "alpine:edge"},
+			want:   []string{"alpine:edge"},
+		},
+		{
+			name:   "paused tag pattern",
+			images: []*config.Image{{Image: "alpine:3.*", Paused: true}},
+			stored: []string{"alpine:3.16", "alpine:3.17", "ubuntu:22.04"},
+			want:   []string{"ubuntu:22.04"},
+		},
+		{
+			name:   "disabled image",
+			images: []*config.Image{{Image: "alpine:3.17", Disabled: true}},
+			stored: []string{"alpine:3.16", "alpine:3.17"},
+			want:   []string{"alpine:3.16"},
+		},
+		{
+			name:   "kept removed tag",
+			images: []*config.Image{{Image: "alpine:3.17"}},
+			keep:   []string{"alpine:3.16"},
+			stored: []string{"alpine:3.16", "alpine:3.17"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useConfig(t, &config.Config{
+				State:  &config.State{Dir: t.TempDir(), Layout: tt.layout},
+				Images: tt.images,
+			})
+			stored := writeStatusFiles(t, tt.stored...)
+			// the stored tags of the patterns are expanded after the files are written.
+			if err := expandStoredPatterns(); err != nil {
+				t.Fatal(err)
+			}
+
+			files, images, err := orphanedStatusFiles(tt.keep)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var wantFiles []string
+			for _, image := range tt.want {
+				wantFiles = append(wantFiles, stored[image])
+			}
+			sort.Strings(wantFiles)
+			// the images are the normalized names parsed from the files.
+			if len(images) != len(tt.want) {
+				t.Errorf("images = %v, want %v", images, tt.want)
+			}
+			if !reflect.DeepEqual(files, wantFiles) {
+				t.Errorf("files = %v, want %v", files, wantFiles)
+			}
+		})
+	}
+}
+
+func TestRemoveStatusFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		dryRun  bool
+		removed bool
+	}{
+		{name: "remove", dryRun: false, removed: true},
+		{name: "dry run", dryRun: true, removed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			useConfig(t, &config.Config{
+				State:  &config.State{Dir: dir},
+				Images: []*config.Image{{Image: "alpine:3.17"}},
+			})
+			stored := writeStatusFiles(t, "alpine:3.16", "alpine:3.17")
+			path := stored["alpine:3.16"]
+			sidecars := []string{
+				filepath.Join(sidecarDir(path, childrenSuffix), "linux+amd64.json"),
+				filepath.Join(sidecarDir(path, historySuffix), "20240101T000000Z.json"),
+			}
+			for _, f := range sidecars {
+				if err := os.MkdirAll(filepath.Dir(f), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(f, []byte("{}\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := removeStatusFiles([]string{path}, tt.dryRun); err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range append([]string{path}, sidecars...) {
+				_, err := os.Stat(f)
+				if tt.removed && !os.IsNotExist(err) {
+					t.Errorf("%s is not removed: %v", f, err)
+				}
+				if !tt.removed && err != nil {
+					t.Errorf("%s is removed in the dry run: %v", f, err)
+				}
+			}
+			for _, d := range []string{sidecarDir(path, childrenSuffix), sidecarDir(path, historySuffix)} {
+				if _, err := os.Stat(d); tt.removed && !os.IsNotExist(err) {
+					t.Errorf("the directory %s is left", d)
+				}
+			}
+			if _, err := os.Stat(stored["alpine:3.17"]); err != nil {
+				t.Errorf("the tracked status file is removed: %v", err)
+			}
+			if _, err := os.Stat(dir); err != nil {
+				t.Errorf("the state directory is removed: %v", err)
+			}
+			if !tt.removed && len(changedFiles) > 0 {
+				t.Errorf("changedFiles = %v in the dry run, want none", changedFiles)
+			}
+			if tt.removed {
+				want := append([]string{path}, sidecars...)
+				sort.Strings(want)
+				got := append([]string(nil), changedFiles...)
+				sort.Strings(got)
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("changedFiles = %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
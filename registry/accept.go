@@ -0,0 +1,63 @@
+package registry
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultAccept is the media types accepted by the manifest requests by default.
+// The manifest lists are preferred so that all platforms are tracked.
+var DefaultAccept = []string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json;q=0.9",
+	"application/vnd.oci.image.manifest.v1+json;q=0.9",
+	"application/vnd.docker.distribution.manifest.v1+prettyjws;q=0.5",
+	"application/vnd.docker.distribution.manifest.v1+json;q=0.5",
+}
+
+// WithAccept overrides the Accept header of the manifest requests.
+// The media types may have the quality values, e.g. "application/vnd.oci.image.manifest.v1+json;q=0.9".
+func WithAccept(mediaTypes ...string) Option {
+	return func(c *Client) {
+		c.accept = strings.Join(mediaTypes, ", ")
+	}
+}
+
+// RequestOption is an option for a request.
+type RequestOption func(*requestOptions)
+
+type requestOptions struct {
+	accept  string
+	timeout time.Duration
+}
+
+// WithRequestAccept overrides the Accept header of the manifest request.
+// It takes precedence over WithAccept.
+// e.g. accept only single-platform manifests to get the manifest that the registry chooses for the client.
+func WithRequestAccept(mediaTypes ...string) RequestOption {
+	return func(o *requestOptions) {
+		o.accept = strings.Join(mediaTypes, ", ")
+	}
+}
+
+// WithRequestTimeout limits the time of the manifest request, including the authentication and reading the response.
+// In GetManifestsBatch, it is applied to each image.
+func WithRequestTimeout(d time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.timeout = d
+	}
+}
+
+func (c *Client) newRequestOptions(opts []RequestOption) *requestOptions {
+	o := &requestOptions{
+		accept: c.accept,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.accept == "" {
+		o.accept = strings.Join(DefaultAccept, ", ")
+	}
+	return o
+}
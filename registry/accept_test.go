@@ -0,0 +1,58 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccept(t *testing.T) {
+	var accept string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept")
+		w.Write([]byte(testManifest))
+	})
+
+	c, host := newTestServer(t, handler)
+	if _, err := c.GetManifests(context.Background(), host+"/foo:latest"); err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Join(DefaultAccept, ", "); accept != want {
+		t.Errorf("want %q, got %q", want, accept)
+	}
+
+	WithAccept("application/vnd.oci.image.index.v1+json", "application/vnd.oci.image.manifest.v1+json;q=0.9")(c)
+	if _, err := c.GetManifests(context.Background(), host+"/foo:latest"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "application/vnd.oci.image.index.v1+json, application/vnd.oci.image.manifest.v1+json;q=0.9"; accept != want {
+		t.Errorf("want %q, got %q", want, accept)
+	}
+
+	if _, err := c.GetManifests(context.Background(), host+"/foo:latest", WithRequestAccept("application/vnd.oci.image.manifest.v1+json")); err != nil {
+		t.Fatal(err)
+	}
+	if want := "application/vnd.oci.image.manifest.v1+json"; accept != want {
+		t.Errorf("want %q, got %q", want, accept)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+		w.Write([]byte(testManifest))
+	})
+
+	c, host := newTestServer(t, handler)
+	c.staticTokens = map[string]string{host: "secret"}
+	_, err := c.GetManifests(context.Background(), host+"/foo:latest", WithRequestTimeout(10*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want context.DeadlineExceeded, got %v", err)
+	}
+}
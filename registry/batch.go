@@ -0,0 +1,148 @@
+package registry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchResult is the result of GetManifestsBatch for each image.
+type BatchResult struct {
+	Image     string
+	Manifests *Manifests
+
+	// Digest is the digest of the manifests.
+	Digest string
+
+	// MediaType is the Content-Type of the response.
+	MediaType string
+
+	// Raw is the body of the response as the registry served it.
+	Raw []byte
+
+	// FetchedAt is the time when the manifests are received.
+	FetchedAt time.Time
+
+	// Duration is the time taken to get the manifests, including the time waiting for the other requests.
+	Duration time.Duration
+
+	Err error
+}
+
+// SetResponse sets the response of GetManifestsResponse to the result.
+func (r *BatchResult) SetResponse(resp *ManifestsResponse, err error) {
+	r.Err = err
+	if err != nil {
+		return
+	}
+	r.Manifests = resp.Manifests
+	r.Digest = resp.Digest
+	r.MediaType = resp.MediaType
+	r.Raw = resp.Raw
+	r.FetchedAt = time.Now()
+}
+
+// WithHostConcurrency limits the number of requests in flight to each host in GetManifestsBatch,
+// to stay polite to each registry while checking many images concurrently.
+// n <= 0 means no limit other than the concurrency of GetManifestsBatch.
+func WithHostConcurrency(n int) Option {
+	return func(c *Client) {
+		c.hostConcurrency = n
+	}
+}
+
+// GetManifestsBatch gets the manifests of the images concurrently.
+// At most concurrency requests are in flight at the same time,
+// and at most the number given by WithHostConcurrency requests are in flight to each host.
+// The results are in the same order as images.
+//
+// The images are grouped by host, and the first request to each host is sent alone,
+// so that the other requests can get tokens up front without bouncing on 401.
+// The options are applied to all requests.
+func (c *Client) GetManifestsBatch(ctx context.Context, images []string, concurrency int, opts ...RequestOption) []*BatchResult {
+	return c.GetManifestsBatchAt(ctx, images, nil, concurrency, opts...)
+}
+
+// GetManifestsBatchAt is GetManifestsBatch, but the request of each image is not sent before its start time,
+// e.g. to spread the requests across a window. starts are in the same order as images, and nil means no delays.
+// The requests waiting for their start times fail with the error of ctx when it is done.
+func (c *Client) GetManifestsBatchAt(ctx context.Context, images []string, starts []time.Time, concurrency int, opts ...RequestOption) []*BatchResult {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	results := make([]*BatchResult, len(images))
+	hosts := []string{}
+	groups := map[string][]int{}
+	for i, image := range images {
+		results[i] = &BatchResult{Image: image}
+		host, _, _ := GetRepository(image)
+		if _, ok := groups[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		groups[host] = append(groups[host], i)
+	}
+
+	// wait waits for the start time of the request, and reports whether the request should be sent.
+	wait := func(i int) bool {
+		if starts == nil {
+			return true
+		}
+		timer := time.NewTimer(time.Until(starts[i]))
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			return false
+		case <-timer.C:
+			return true
+		}
+	}
+
+	sem := make(chan struct{}, concurrency)
+	get := func(i int) {
+		sem <- struct{}{}
+		defer func() { <-sem }()
+		r := results[i]
+		start := time.Now()
+		r.SetResponse(c.GetManifestsResponse(ctx, r.Image, opts...))
+		r.Duration = time.Since(start)
+	}
+
+	var wg sync.WaitGroup
+	for _, host := range hosts {
+		indexes := groups[host]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if wait(indexes[0]) {
+				get(indexes[0])
+			}
+
+			hostConcurrency := c.hostConcurrency
+			if hostConcurrency <= 0 {
+				hostConcurrency = len(indexes)
+			}
+			hostSem := make(chan struct{}, hostConcurrency)
+			var hostWg sync.WaitGroup
+			for _, i := range indexes[1:] {
+				i := i
+				hostWg.Add(1)
+				go func() {
+					defer hostWg.Done()
+					// wait before taking the slot of the host, not to block the requests that start earlier.
+					if !wait(i) {
+						return
+					}
+					hostSem <- struct{}{}
+					defer func() { <-hostSem }()
+					get(i)
+				}()
+			}
+			hostWg.Wait()
+		}()
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,188 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetManifestsBatch(t *testing.T) {
+	var mu sync.Mutex
+	var unauthorized, tokenRequests int
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			mu.Lock()
+			tokenRequests++
+			mu.Unlock()
+			fmt.Fprintf(w, `{"token":%q}`, r.URL.Query().Get("scope"))
+			return
+		}
+
+		// /v2/<repo>/manifests/<tag>
+		repo := strings.TrimPrefix(r.URL.Path, "/v2/")
+		repo = repo[:strings.Index(repo, "/manifests/")]
+		if r.Header.Get("Authorization") != "Bearer repository:"+repo+":pull" {
+			mu.Lock()
+			unauthorized++
+			mu.Unlock()
+			w.Header().Set("Www-Authenticate", fmt.Sprintf(`Bearer realm="https://registry.example.com/token",service="test",scope="repository:%s:pull"`, repo))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if repo == "missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(testManifest))
+	}))
+
+	images := []string{
+		host + "/foo:latest",
+		host + "/bar:latest",
+		host + "/baz:latest",
+		host + "/missing:latest",
+	}
+	results := c.GetManifestsBatch(context.Background(), images, 2)
+	if len(results) != len(images) {
+		t.Fatalf("want %d results, got %d", len(images), len(results))
+	}
+	for i, r := range results {
+		if r.Image != images[i] {
+			t.Errorf("want %s, got %s", images[i], r.Image)
+		}
+		if r.Image == host+"/missing:latest" {
+			if !errors.Is(r.Err, ErrNotFound) {
+				t.Errorf("want ErrNotFound for %s, got %v", r.Image, r.Err)
+			}
+			continue
+		}
+		if r.Err != nil {
+			t.Errorf("unexpected error for %s: %v", r.Image, r.Err)
+		}
+		if want := sha256Digest(testManifest); r.Digest != want {
+			t.Errorf("want digest %s, got %s", want, r.Digest)
+		}
+	}
+
+	// only the first request bounces on 401.
+	if unauthorized != 1 {
+		t.Errorf("want 1 unauthorized response, got %d", unauthorized)
+	}
+	if tokenRequests != len(images) {
+		t.Errorf("want %d token requests, got %d", len(images), tokenRequests)
+	}
+}
+
+func TestGetManifestsBatch_HostConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte(testManifest))
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	c.staticTokens = map[string]string{host: "secret"}
+	WithHostConcurrency(2)(c)
+
+	var images []string
+	for i := 0; i < 10; i++ {
+		images = append(images, fmt.Sprintf("%s/foo:%d", host, i))
+	}
+	for _, r := range c.GetManifestsBatch(context.Background(), images, 8) {
+		if r.Err != nil {
+			t.Errorf("unexpected error for %s: %v", r.Image, r.Err)
+		}
+	}
+	if maxInFlight > 2 {
+		t.Errorf("want at most 2 requests in flight, got %d", maxInFlight)
+	}
+}
+
+func TestGetManifestsBatchAt(t *testing.T) {
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	received := map[string]time.Time{}
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received[r.URL.Path] = time.Now()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte(testManifest))
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	c.staticTokens = map[string]string{host: "secret"}
+	WithHostConcurrency(1)(c)
+
+	start := time.Now()
+	var images []string
+	var starts []time.Time
+	for i := 0; i < 4; i++ {
+		images = append(images, fmt.Sprintf("%s/foo:%d", host, i))
+		starts = append(starts, start.Add(time.Duration(i)*30*time.Millisecond))
+	}
+	for _, r := range c.GetManifestsBatchAt(context.Background(), images, starts, 8) {
+		if r.Err != nil {
+			t.Errorf("unexpected error for %s: %v", r.Image, r.Err)
+		}
+	}
+	for i := range images {
+		path := fmt.Sprintf("/v2/foo/manifests/%d", i)
+		if got := received[path]; got.Before(starts[i]) {
+			t.Errorf("%s is requested %s before its start time", path, starts[i].Sub(got))
+		}
+	}
+	if maxInFlight > 1 {
+		t.Errorf("want at most 1 request in flight, got %d", maxInFlight)
+	}
+}
+
+func TestGetManifestsBatchAt_Canceled(t *testing.T) {
+	var requests int
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(testManifest))
+	}))
+	c.staticTokens = map[string]string{host: "secret"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	images := []string{host + "/foo:latest", host + "/bar:latest"}
+	starts := []time.Time{time.Now(), time.Now().Add(time.Hour)}
+	begin := time.Now()
+	results := c.GetManifestsBatchAt(ctx, images, starts, 2)
+	if elapsed := time.Since(begin); elapsed > 10*time.Second {
+		t.Errorf("want to return after the context is done, took %s", elapsed)
+	}
+	if results[0].Err != nil {
+		t.Errorf("unexpected error for %s: %v", results[0].Image, results[0].Err)
+	}
+	if !errors.Is(results[1].Err, context.DeadlineExceeded) {
+		t.Errorf("want context.DeadlineExceeded, got %v", results[1].Err)
+	}
+	if requests != 1 {
+		t.Errorf("want 1 request, got %d", requests)
+	}
+}
@@ -0,0 +1,78 @@
+package registry
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+)
+
+// Blob is a blob downloaded from the registry.
+// The content is verified against the digest while reading,
+// and Read returns ErrDigestMismatch at the end of the content if it doesn't match.
+type Blob struct {
+	io.ReadCloser
+
+	// Size is the size of the blob. It is -1 if unknown.
+	Size int64
+
+	// Digest is the digest of the blob.
+	Digest string
+}
+
+// GetBlob downloads the blob of the image, e.g. an image config or a layer.
+// The caller must close the returned blob.
+//
+// Registries often redirect blob downloads to CDNs.
+// The redirects are followed without the credentials for the registry.
+func (c *Client) GetBlob(ctx context.Context, image, digest string) (*Blob, error) {
+	host, repo, _ := GetRepository(image)
+	return c.getBlob(ctx, host, repo, digest)
+}
+
+func (c *Client) getBlob(ctx context.Context, host, repo, digest string) (*Blob, error) {
+	h, encoded, err := newDigestHash(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	repo = c.repository(host, repo)
+	resp, err := c.get(ctx, host, "/v2/"+repo+"/blobs/"+digest, "repository:"+repo+":pull", nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Blob{
+		ReadCloser: &verifyingReader{
+			r:        resp.Body,
+			h:        h,
+			digest:   digest,
+			expected: strings.ToLower(encoded),
+		},
+		Size:   resp.ContentLength,
+		Digest: digest,
+	}, nil
+}
+
+type verifyingReader struct {
+	r        io.ReadCloser
+	h        hash.Hash
+	digest   string
+	expected string
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.h.Write(p[:n])
+	if err == io.EOF {
+		if got := hex.EncodeToString(r.h.Sum(nil)); got != r.expected {
+			return n, fmt.Errorf("%w: expected %s, got %s", ErrDigestMismatch, r.digest, r.digest[:len(r.digest)-len(r.expected)]+got)
+		}
+	}
+	return n, err
+}
+
+func (r *verifyingReader) Close() error {
+	return r.r.Close()
+}
@@ -0,0 +1,65 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestGetBlob(t *testing.T) {
+	const content = "hello blob"
+	digest := sha256Digest(content)
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Host {
+		case "registry.example.com":
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				w.Header().Set("Www-Authenticate", `Bearer realm="https://auth.example.com/token",service="test"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			switch r.URL.Path {
+			case "/v2/foo/bar/blobs/" + digest:
+				http.Redirect(w, r, "https://cdn.example.com/blob", http.StatusTemporaryRedirect)
+			case "/v2/foo/bar/blobs/" + sha256Digest("other"):
+				w.Write([]byte(content))
+			default:
+				http.NotFound(w, r)
+			}
+		case "auth.example.com":
+			w.Write([]byte(`{"token":"secret"}`))
+		case "cdn.example.com":
+			if r.Header.Get("Authorization") != "" {
+				t.Error("the credentials leaked to the CDN")
+			}
+			w.Write([]byte(content))
+		}
+	}))
+
+	blob, err := c.GetBlob(context.Background(), host+"/foo/bar", digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blob.Close()
+	data, err := io.ReadAll(blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Errorf("want %q, got %q", content, string(data))
+	}
+	if blob.Size != int64(len(content)) {
+		t.Errorf("want size %d, got %d", len(content), blob.Size)
+	}
+
+	// the content doesn't match the digest.
+	blob, err = c.GetBlob(context.Background(), host+"/foo/bar", sha256Digest("other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blob.Close()
+	if _, err := io.ReadAll(blob); !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("want ErrDigestMismatch, got %v", err)
+	}
+}
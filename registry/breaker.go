@@ -0,0 +1,84 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// ErrCircuitOpen is returned when the requests to the host are skipped because of the consecutive failures.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// WithCircuitBreaker enables the per-host circuit breaker.
+// After threshold consecutive failures to a host, the rest of the requests to the host fail fast with ErrCircuitOpen,
+// instead of waiting for the timeout of each request during an outage.
+// The circuit never closes again, because the client is expected to be used for a single run.
+func WithCircuitBreaker(threshold int) Option {
+	return func(c *Client) {
+		if threshold <= 0 {
+			c.breaker = nil
+			return
+		}
+		c.breaker = &circuitBreaker{
+			threshold: threshold,
+			failures:  make(map[string]int),
+		}
+	}
+}
+
+type circuitBreaker struct {
+	threshold int
+
+	mu       sync.Mutex
+	failures map[string]int
+}
+
+// allow returns ErrCircuitOpen if the circuit of the host is open.
+func (b *circuitBreaker) allow(host string) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures[host] >= b.threshold {
+		return fmt.Errorf("%w: %s failed %d times in a row", ErrCircuitOpen, host, b.failures[host])
+	}
+	return nil
+}
+
+// record records the result of a request to the host.
+func (b *circuitBreaker) record(host string, err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures[host] >= b.threshold {
+		// the circuit is already open.
+		return
+	}
+	if isHostFailure(err) {
+		b.failures[host]++
+	} else {
+		b.failures[host] = 0
+	}
+}
+
+// isHostFailure reports whether err means that the host is unhealthy.
+// The errors about the requested resources, such as 401 and 404, are not.
+func isHostFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var repoErr *registryError
+	if errors.As(err, &repoErr) {
+		return repoErr.statusCode >= http.StatusInternalServerError || repoErr.statusCode == http.StatusTooManyRequests
+	}
+
+	// network errors and timeouts
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) || errors.Is(err, context.DeadlineExceeded)
+}
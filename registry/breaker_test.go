@@ -0,0 +1,41 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var requests int
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/v2/missing/manifests/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	WithCircuitBreaker(2)(c)
+
+	// not found errors don't open the circuit.
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetManifests(context.Background(), host+"/missing:latest"); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("want not found error, got %v", err)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetManifests(context.Background(), host+"/foo:latest"); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("want service unavailable error, got %v", err)
+		}
+	}
+	requests = 0
+	if _, err := c.GetManifests(context.Background(), host+"/foo:latest"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("want ErrCircuitOpen, got %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("want no requests, got %d", requests)
+	}
+}
@@ -0,0 +1,76 @@
+package registry
+
+import "sort"
+
+// Canonical returns a copy of the manifests in the canonical form,
+// so that the semantically identical responses are equal and are serialized into the same JSON.
+// The manifests in the list are sorted by the platforms, then the attestations by the manifests they refer to,
+// the os.features are sorted, and the empty maps and slices are nil.
+// The layers are kept in order, because the order is significant.
+func Canonical(m *Manifests) *Manifests {
+	if m == nil {
+		return nil
+	}
+	ret := *m
+	ret.Annotations = canonicalAnnotations(m.Annotations)
+	if m.Subject != nil {
+		subject := *m.Subject
+		subject.Annotations = canonicalAnnotations(subject.Annotations)
+		ret.Subject = &subject
+	}
+	if len(m.Layers) == 0 {
+		ret.Layers = nil
+	}
+	if len(m.FSLayers) == 0 {
+		ret.FSLayers = nil
+	}
+
+	ret.Manifests = nil
+	for _, manifest := range m.Manifests {
+		if manifest == nil {
+			continue
+		}
+		c := *manifest
+		c.Annotations = canonicalAnnotations(c.Annotations)
+		if c.Platform != nil {
+			p := *c.Platform
+			if len(p.OSFeatures) == 0 {
+				p.OSFeatures = nil
+			} else {
+				p.OSFeatures = append([]string(nil), p.OSFeatures...)
+				sort.Strings(p.OSFeatures)
+			}
+			c.Platform = &p
+		}
+		ret.Manifests = append(ret.Manifests, &c)
+	}
+	sort.SliceStable(ret.Manifests, func(i, j int) bool {
+		return lessManifest(ret.Manifests[i], ret.Manifests[j])
+	})
+	return &ret
+}
+
+// lessManifest orders the manifests in the list: the images by the platforms, and then the attestations.
+func lessManifest(a, b *Manifest) bool {
+	if aa, ba := isAttestation(a), isAttestation(b); aa != ba {
+		return ba
+	}
+	if a.Platform.String() != b.Platform.String() {
+		return a.Platform.String() < b.Platform.String()
+	}
+	if a.Platform != nil && b.Platform != nil && a.Platform.OSVersion != b.Platform.OSVersion {
+		return a.Platform.OSVersion < b.Platform.OSVersion
+	}
+	aref, bref := a.Annotations[attestationReferenceAnnotation], b.Annotations[attestationReferenceAnnotation]
+	if aref != bref {
+		return aref < bref
+	}
+	return a.Digest < b.Digest
+}
+
+func canonicalAnnotations(annotations map[string]string) map[string]string {
+	if len(annotations) == 0 {
+		return nil
+	}
+	return annotations
+}
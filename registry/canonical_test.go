@@ -0,0 +1,73 @@
+package registry
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	a := `{
+		"schemaVersion": 2,
+		"mediaType": "application/vnd.oci.image.index.v1+json",
+		"annotations": {},
+		"manifests": [
+			{"digest": "sha256:att-arm64", "mediaType": "application/vnd.oci.image.manifest.v1+json", "platform": {"os": "unknown", "architecture": "unknown"}, "annotations": {"vnd.docker.reference.digest": "sha256:arm64"}},
+			{"digest": "sha256:arm64", "mediaType": "application/vnd.oci.image.manifest.v1+json", "platform": {"os": "linux", "architecture": "arm64", "variant": "v8"}},
+			{"digest": "sha256:att-amd64", "mediaType": "application/vnd.oci.image.manifest.v1+json", "platform": {"os": "unknown", "architecture": "unknown"}, "annotations": {"vnd.docker.reference.digest": "sha256:amd64"}},
+			{"digest": "sha256:amd64", "mediaType": "application/vnd.oci.image.manifest.v1+json", "platform": {"os": "linux", "architecture": "amd64", "os.features": ["b", "a"]}}
+		]
+	}`
+	b := `{
+		"schemaVersion": 2,
+		"mediaType": "application/vnd.oci.image.index.v1+json",
+		"manifests": [
+			{"digest": "sha256:amd64", "mediaType": "application/vnd.oci.image.manifest.v1+json", "platform": {"os": "linux", "architecture": "amd64", "os.features": ["a", "b"]}},
+			{"digest": "sha256:att-amd64", "mediaType": "application/vnd.oci.image.manifest.v1+json", "platform": {"os": "unknown", "architecture": "unknown"}, "annotations": {"vnd.docker.reference.digest": "sha256:amd64"}},
+			{"digest": "sha256:arm64", "mediaType": "application/vnd.oci.image.manifest.v1+json", "platform": {"os": "linux", "architecture": "arm64", "variant": "v8"}, "annotations": {}},
+			{"digest": "sha256:att-arm64", "mediaType": "application/vnd.oci.image.manifest.v1+json", "platform": {"os": "unknown", "architecture": "unknown"}, "annotations": {"vnd.docker.reference.digest": "sha256:arm64"}}
+		]
+	}`
+	var ma, mb *Manifests
+	if err := json.Unmarshal([]byte(a), &ma); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(b), &mb); err != nil {
+		t.Fatal(err)
+	}
+	ca, cb := Canonical(ma), Canonical(mb)
+	if !reflect.DeepEqual(ca, cb) {
+		t.Error("want the same manifests")
+	}
+	ja, err := json.Marshal(ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jb, err := json.Marshal(cb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(ja) != string(jb) {
+		t.Errorf("want the same JSON, got\n%s\n%s", ja, jb)
+	}
+
+	var digests []string
+	for _, m := range ca.Manifests {
+		digests = append(digests, m.Digest)
+	}
+	want := []string{"sha256:amd64", "sha256:arm64", "sha256:att-amd64", "sha256:att-arm64"}
+	if !reflect.DeepEqual(digests, want) {
+		t.Errorf("want %v, got %v", want, digests)
+	}
+
+	// the original is not modified.
+	if ma.Manifests[0].Digest != "sha256:att-arm64" || ma.Manifests[3].Platform.OSFeatures[0] != "b" {
+		t.Error("the original manifests are modified")
+	}
+}
+
+func TestCanonical_Nil(t *testing.T) {
+	if Canonical(nil) != nil {
+		t.Error("want nil")
+	}
+}
@@ -0,0 +1,64 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+)
+
+// catalogPageSize is the number of repositories requested per page.
+const catalogPageSize = 100
+
+// Catalog returns the list of all repositories in the registry.
+// It follows the pagination of the /v2/_catalog API.
+func (c *Client) Catalog(ctx context.Context, host string) ([]string, error) {
+	var repos []string
+	path := "/v2/_catalog?n=" + strconv.Itoa(catalogPageSize)
+	for path != "" {
+		resp, err := c.get(ctx, host, path, "registry:catalog:*", nil)
+		if errors.Is(err, ErrInsufficientScope) {
+			// e.g. Harbor allows only system administrators to list the catalog, not robot accounts.
+			return nil, fmt.Errorf("listing the catalog of %s requires the registry:catalog:* scope, list the repositories explicitly instead: %w", host, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var body struct {
+			Repositories []string `json:"repositories"`
+		}
+		dec := json.NewDecoder(resp.Body)
+		err = dec.Decode(&body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, body.Repositories...)
+
+		path, err = nextLink(resp.Header.Get("Link"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return repos, nil
+}
+
+var linkRegexp = regexp.MustCompile(`<([^>]*)>\s*;\s*rel="?next"?`)
+
+// nextLink parses the Link header, and returns the path and query of the next page.
+// It returns an empty string if there is no next page.
+func nextLink(link string) (string, error) {
+	m := linkRegexp.FindStringSubmatch(link)
+	if m == nil {
+		return "", nil
+	}
+	u, err := url.Parse(m[1])
+	if err != nil {
+		return "", err
+	}
+	return u.RequestURI(), nil
+}
@@ -0,0 +1,57 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCatalog(t *testing.T) {
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/_catalog" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.URL.Query().Get("last") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`</v2/_catalog?last=b&n=%d>; rel="next"`, catalogPageSize))
+			fmt.Fprint(w, `{"repositories":["a","b"]}`)
+		case "b":
+			fmt.Fprint(w, `{"repositories":["c"]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	repos, err := c.Catalog(context.Background(), host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(repos, want) {
+		t.Errorf("want %v, got %v", want, repos)
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{`</v2/_catalog?last=b&n=100>; rel="next"`, "/v2/_catalog?last=b&n=100"},
+		{`<https://example.com/v2/_catalog?last=b&n=100>; rel="next"`, "/v2/_catalog?last=b&n=100"},
+		{`</v2/_catalog?last=b&n=100>; rel="prev"`, ""},
+	}
+	for _, tt := range tests {
+		got, err := nextLink(tt.in)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: want %q, got %q", tt.in, tt.want, got)
+		}
+	}
+}
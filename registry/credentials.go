@@ -0,0 +1,48 @@
+package registry
+
+import (
+	"context"
+	"strings"
+)
+
+// CredentialProvider provides credentials for Docker registries.
+type CredentialProvider interface {
+	// GetCredentials returns the username and the password (or the token) for the host.
+	// It returns empty strings if it has no credentials for the host.
+	GetCredentials(ctx context.Context, host string) (username, password string, err error)
+}
+
+// CredentialProviderFunc is an adapter to allow the use of ordinary functions as CredentialProvider.
+type CredentialProviderFunc func(ctx context.Context, host string) (username, password string, err error)
+
+// GetCredentials calls f(ctx, host).
+func (f CredentialProviderFunc) GetCredentials(ctx context.Context, host string) (username, password string, err error) {
+	return f(ctx, host)
+}
+
+// Credential is a pair of username and password.
+type Credential struct {
+	Username string
+	Password string
+}
+
+// StaticCredentials is a CredentialProvider that has fixed credentials per host.
+// It is not safe for concurrent writes.
+type StaticCredentials map[string]*Credential
+
+// Set sets the credentials for the host.
+func (s StaticCredentials) Set(host, username, password string) {
+	s[strings.ToLower(host)] = &Credential{
+		Username: username,
+		Password: password,
+	}
+}
+
+// GetCredentials implements CredentialProvider.
+func (s StaticCredentials) GetCredentials(ctx context.Context, host string) (username, password string, err error) {
+	cred, ok := s[strings.ToLower(host)]
+	if !ok {
+		return "", "", nil
+	}
+	return cred.Username, cred.Password, nil
+}
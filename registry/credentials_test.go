@@ -0,0 +1,71 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestCredentialProvider(t *testing.T) {
+	var calls int
+	provider := CredentialProviderFunc(func(ctx context.Context, host string) (string, string, error) {
+		calls++
+		if host != "registry.example.com" {
+			return "", "", nil
+		}
+		return "user", "pass", nil
+	})
+
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			username, password, ok := r.BasicAuth()
+			if !ok || username != "user" || password != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"token":"secret"}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("Www-Authenticate", `Bearer realm="https://registry.example.com/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(testManifest))
+	}))
+	WithCredentialProvider(provider)(c)
+
+	if _, err := c.GetManifests(context.Background(), host+"/foo/bar:latest"); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("want 1 call, got %d", calls)
+	}
+
+	// the credentials given by Login take precedence.
+	if err := c.Login(context.Background(), host, "user", "wrong"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetManifests(context.Background(), host+"/foo/baz:latest"); err == nil {
+		t.Error("want error, got nil")
+	}
+	if calls != 1 {
+		t.Errorf("want 1 call, got %d", calls)
+	}
+}
+
+// setenvForTest sets the environment value during the test.
+func setenvForTest(t *testing.T, key, value string) {
+	t.Helper()
+	old, ok := os.LookupEnv(key)
+	os.Setenv(key, value)
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package registry
+
+import (
+	"sort"
+	"strings"
+)
+
+// String returns the platform in the form of os/arch[/variant], e.g. "linux/arm/v7".
+// The Windows platforms have the build of os.version, e.g. "windows/amd64:10.0.20348",
+// because an index may have the images for multiple Windows versions.
+func (p *Platform) String() string {
+	if p == nil {
+		return "unknown"
+	}
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	if p.OS == "windows" && p.OSVersion != "" {
+		s += ":" + windowsBuild(p.OSVersion)
+	}
+	return s
+}
+
+// windowsBuild returns the build of the Windows version without the revision,
+// e.g. "10.0.20348" for "10.0.20348.2113".
+func windowsBuild(version string) string {
+	parts := strings.SplitN(version, ".", 4)
+	if len(parts) < 4 {
+		return version
+	}
+	return strings.Join(parts[:3], ".")
+}
+
+// singleManifestPlatform is the platform name used for the manifests that are not lists.
+const singleManifestPlatform = "(single platform)"
+
+// PlatformDiff is the difference of a platform between two manifests.
+type PlatformDiff struct {
+	// Platform is the platform, e.g. "linux/amd64".
+	Platform string `json:"platform"`
+
+	// OldDigest and NewDigest are the digests of the platform-specific manifests,
+	// or the config digests if the manifests are not lists.
+	OldDigest string `json:"oldDigest,omitempty"`
+	NewDigest string `json:"newDigest,omitempty"`
+
+	// OldSize and NewSize are the sizes of the platform-specific manifests,
+	// or the total sizes of the layers if the manifests are not lists.
+	OldSize int64 `json:"oldSize,omitempty"`
+	NewSize int64 `json:"newSize,omitempty"`
+
+	// OldOSVersion and NewOSVersion are the os.version of the platforms, e.g. "10.0.20348.2113" for Windows.
+	OldOSVersion string `json:"oldOSVersion,omitempty"`
+	NewOSVersion string `json:"newOSVersion,omitempty"`
+}
+
+// ManifestsDiff is the difference between two manifests.
+type ManifestsDiff struct {
+	OldMediaType string `json:"oldMediaType,omitempty"`
+	NewMediaType string `json:"newMediaType,omitempty"`
+
+	Added   []*PlatformDiff `json:"added,omitempty"`
+	Removed []*PlatformDiff `json:"removed,omitempty"`
+	Changed []*PlatformDiff `json:"changed,omitempty"`
+}
+
+// Empty reports whether there is no difference.
+func (d *ManifestsDiff) Empty() bool {
+	return d.OldMediaType == d.NewMediaType && len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+type platformEntry struct {
+	digest    string
+	size      int64
+	osVersion string
+}
+
+// platformEntries returns the digests and the sizes per platform.
+// The attestations are skipped because they change together with the images.
+func platformEntries(m *Manifests) ([]string, map[string]platformEntry) {
+	var keys []string
+	entries := map[string]platformEntry{}
+	if m == nil {
+		return keys, entries
+	}
+	if len(m.Manifests) == 0 {
+		var e platformEntry
+		if m.Config != nil {
+			e.digest = m.Config.Digest
+		}
+		for _, layer := range m.Layers {
+			e.size += layer.Size
+		}
+		keys = append(keys, singleManifestPlatform)
+		entries[singleManifestPlatform] = e
+		return keys, entries
+	}
+	for _, manifest := range m.Manifests {
+		if isAttestation(manifest) {
+			continue
+		}
+		key := manifest.Platform.String()
+		if _, ok := entries[key]; ok {
+			// the same platform appears twice; keep the both.
+			key += " (" + manifest.Digest + ")"
+		}
+		keys = append(keys, key)
+		e := platformEntry{
+			digest: manifest.Digest,
+			size:   manifest.Size,
+		}
+		if manifest.Platform != nil {
+			e.osVersion = manifest.Platform.OSVersion
+		}
+		entries[key] = e
+	}
+	return keys, entries
+}
+
+// DiffManifests compares the manifests per platform.
+// from may be nil, in which case all platforms of to are added.
+func DiffManifests(from, to *Manifests) *ManifestsDiff {
+	d := &ManifestsDiff{}
+	if from != nil {
+		d.OldMediaType = from.MediaType
+	}
+	if to != nil {
+		d.NewMediaType = to.MediaType
+	}
+
+	oldKeys, oldEntries := platformEntries(from)
+	newKeys, newEntries := platformEntries(to)
+	for _, key := range newKeys {
+		n := newEntries[key]
+		o, ok := oldEntries[key]
+		if !ok {
+			d.Added = append(d.Added, &PlatformDiff{
+				Platform:     key,
+				NewDigest:    n.digest,
+				NewSize:      n.size,
+				NewOSVersion: n.osVersion,
+			})
+			continue
+		}
+		if o.digest != n.digest {
+			d.Changed = append(d.Changed, &PlatformDiff{
+				Platform:     key,
+				OldDigest:    o.digest,
+				NewDigest:    n.digest,
+				OldSize:      o.size,
+				NewSize:      n.size,
+				OldOSVersion: o.osVersion,
+				NewOSVersion: n.osVersion,
+			})
+		}
+	}
+	for _, key := range oldKeys {
+		if _, ok := newEntries[key]; ok {
+			continue
+		}
+		o := oldEntries[key]
+		d.Removed = append(d.Removed, &PlatformDiff{
+			Platform:     key,
+			OldDigest:    o.digest,
+			OldSize:      o.size,
+			OldOSVersion: o.osVersion,
+		})
+	}
+
+	for _, list := range [][]*PlatformDiff{d.Added, d.Removed, d.Changed} {
+		sort.Slice(list, func(i, j int) bool { return list[i].Platform < list[j].Platform })
+	}
+	return d
+}
+
+// UnchangedPlatforms returns the platforms whose digests are the same in both manifests.
+// With DiffManifests, it tells a partial update of a multi-platform image, e.g. during staggered rebuilds.
+func UnchangedPlatforms(from, to *Manifests) []string {
+	_, oldEntries := platformEntries(from)
+	newKeys, newEntries := platformEntries(to)
+	var platforms []string
+	for _, key := range newKeys {
+		if o, ok := oldEntries[key]; ok && o.digest == newEntries[key].digest {
+			platforms = append(platforms, key)
+		}
+	}
+	sort.Strings(platforms)
+	return platforms
+}
@@ -0,0 +1,139 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffManifests(t *testing.T) {
+	old := &Manifests{
+		SchemaVersion: 2,
+		MediaType:     "application/vnd.docker.distribution.manifest.list.v2+json",
+		Manifests: []*Manifest{
+			{Digest: "sha256:amd64", Size: 100, Platform: &Platform{OS: "linux", Architecture: "amd64"}},
+			{Digest: "sha256:armv7", Size: 100, Platform: &Platform{OS: "linux", Architecture: "arm", Variant: "v7"}},
+			{Digest: "sha256:s390x", Size: 100, Platform: &Platform{OS: "linux", Architecture: "s390x"}},
+			{Digest: "sha256:att1", Size: 100, Platform: &Platform{OS: "unknown", Architecture: "unknown"}},
+		},
+	}
+	new := &Manifests{
+		SchemaVersion: 2,
+		MediaType:     "application/vnd.oci.image.index.v1+json",
+		Manifests: []*Manifest{
+			{Digest: "sha256:amd64-new", Size: 120, Platform: &Platform{OS: "linux", Architecture: "amd64"}},
+			{Digest: "sha256:armv7", Size: 100, Platform: &Platform{OS: "linux", Architecture: "arm", Variant: "v7"}},
+			{Digest: "sha256:riscv64", Size: 100, Platform: &Platform{OS: "linux", Architecture: "riscv64"}},
+			{Digest: "sha256:att2", Size: 100, Platform: &Platform{OS: "unknown", Architecture: "unknown"}},
+		},
+	}
+
+	got := DiffManifests(old, new)
+	want := &ManifestsDiff{
+		OldMediaType: "application/vnd.docker.distribution.manifest.list.v2+json",
+		NewMediaType: "application/vnd.oci.image.index.v1+json",
+		Added: []*PlatformDiff{
+			{Platform: "linux/riscv64", NewDigest: "sha256:riscv64", NewSize: 100},
+		},
+		Removed: []*PlatformDiff{
+			{Platform: "linux/s390x", OldDigest: "sha256:s390x", OldSize: 100},
+		},
+		Changed: []*PlatformDiff{
+			{Platform: "linux/amd64", OldDigest: "sha256:amd64", NewDigest: "sha256:amd64-new", OldSize: 100, NewSize: 120},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %#v, got %#v", want, got)
+	}
+
+	if d := DiffManifests(new, new); !d.Empty() {
+		t.Errorf("want empty diff, got %#v", d)
+	}
+}
+
+func TestDiffManifests_Single(t *testing.T) {
+	old := &Manifests{
+		SchemaVersion: 2,
+		MediaType:     "application/vnd.docker.distribution.manifest.v2+json",
+		Config:        &Config{Digest: "sha256:old"},
+		Layers:        []*Layer{{Size: 10}, {Size: 20}},
+	}
+	new := &Manifests{
+		SchemaVersion: 2,
+		MediaType:     "application/vnd.docker.distribution.manifest.v2+json",
+		Config:        &Config{Digest: "sha256:new"},
+		Layers:        []*Layer{{Size: 10}, {Size: 25}},
+	}
+	got := DiffManifests(old, new)
+	if len(got.Changed) != 1 || got.Changed[0].OldSize != 30 || got.Changed[0].NewSize != 35 {
+		t.Errorf("unexpected diff: %#v", got)
+	}
+}
+
+func TestUnchangedPlatforms(t *testing.T) {
+	old := &Manifests{
+		SchemaVersion: 2,
+		MediaType:     "application/vnd.oci.image.index.v1+json",
+		Manifests: []*Manifest{
+			{Digest: "sha256:amd64", Size: 100, Platform: &Platform{OS: "linux", Architecture: "amd64"}},
+			{Digest: "sha256:arm64", Size: 100, Platform: &Platform{OS: "linux", Architecture: "arm64"}},
+			{Digest: "sha256:s390x", Size: 100, Platform: &Platform{OS: "linux", Architecture: "s390x"}},
+		},
+	}
+	new := &Manifests{
+		SchemaVersion: 2,
+		MediaType:     "application/vnd.oci.image.index.v1+json",
+		Manifests: []*Manifest{
+			{Digest: "sha256:amd64-new", Size: 100, Platform: &Platform{OS: "linux", Architecture: "amd64"}},
+			{Digest: "sha256:s390x", Size: 100, Platform: &Platform{OS: "linux", Architecture: "s390x"}},
+			{Digest: "sha256:arm64", Size: 100, Platform: &Platform{OS: "linux", Architecture: "arm64"}},
+		},
+	}
+	got := UnchangedPlatforms(old, new)
+	want := []string{"linux/arm64", "linux/s390x"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	if got := UnchangedPlatforms(nil, new); len(got) != 0 {
+		t.Errorf("want no platforms, got %v", got)
+	}
+}
+
+func TestDiffManifests_Windows(t *testing.T) {
+	old := &Manifests{
+		SchemaVersion: 2,
+		MediaType:     "application/vnd.docker.distribution.manifest.list.v2+json",
+		Manifests: []*Manifest{
+			{Digest: "sha256:ltsc2019", Size: 100, Platform: &Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.17763.5122"}},
+			{Digest: "sha256:ltsc2022", Size: 100, Platform: &Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.20348.2113"}},
+		},
+	}
+	new := &Manifests{
+		SchemaVersion: 2,
+		MediaType:     "application/vnd.docker.distribution.manifest.list.v2+json",
+		Manifests: []*Manifest{
+			{Digest: "sha256:ltsc2019", Size: 100, Platform: &Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.17763.5122"}},
+			{Digest: "sha256:ltsc2022-new", Size: 100, Platform: &Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.20348.2159"}},
+		},
+	}
+
+	got := DiffManifests(old, new)
+	want := &ManifestsDiff{
+		OldMediaType: "application/vnd.docker.distribution.manifest.list.v2+json",
+		NewMediaType: "application/vnd.docker.distribution.manifest.list.v2+json",
+		Changed: []*PlatformDiff{
+			{
+				Platform:     "windows/amd64:10.0.20348",
+				OldDigest:    "sha256:ltsc2022",
+				NewDigest:    "sha256:ltsc2022-new",
+				OldSize:      100,
+				NewSize:      100,
+				OldOSVersion: "10.0.20348.2113",
+				NewOSVersion: "10.0.20348.2159",
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %#v, got %#v", want, got)
+	}
+}
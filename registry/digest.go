@@ -0,0 +1,54 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// ErrDigestMismatch is returned when the content received from the registry
+// does not match the expected digest.
+var ErrDigestMismatch = errors.New("digest mismatch")
+
+// isDigest reports whether ref looks like a digest (e.g. "sha256:...") rather than a tag.
+func isDigest(ref string) bool {
+	return strings.ContainsRune(ref, ':')
+}
+
+func newDigestHash(digest string) (hash.Hash, string, error) {
+	idx := strings.IndexRune(digest, ':')
+	if idx < 0 {
+		return nil, "", fmt.Errorf("invalid digest: %q", digest)
+	}
+	algo, encoded := digest[:idx], digest[idx+1:]
+	switch algo {
+	case "sha256":
+		return sha256.New(), encoded, nil
+	case "sha512":
+		return sha512.New(), encoded, nil
+	}
+	return nil, "", fmt.Errorf("unsupported digest algorithm: %s", algo)
+}
+
+// verifyDigest checks that data matches the digest.
+func verifyDigest(digest string, data []byte) error {
+	h, encoded, err := newDigestHash(digest)
+	if err != nil {
+		return err
+	}
+	h.Write(data)
+	if got := hex.EncodeToString(h.Sum(nil)); got != strings.ToLower(encoded) {
+		return fmt.Errorf("%w: expected %s, got %s", ErrDigestMismatch, digest, digest[:len(digest)-len(encoded)]+got)
+	}
+	return nil
+}
+
+// computeDigest returns the sha256 digest of data.
+func computeDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,105 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testManifest = `{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{"mediaType":"application/vnd.docker.container.image.v1+json","size":1,"digest":"sha256:0000000000000000000000000000000000000000000000000000000000000000"}}`
+
+func sha256Digest(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// newTestServer starts a test server, and returns a client that sends all requests to it.
+// The returned host is a fake registry host, but the client accepts any host names.
+func newTestServer(t *testing.T, handler http.Handler) (*Client, string) {
+	t.Helper()
+	ts := httptest.NewTLSServer(handler)
+	t.Cleanup(ts.Close)
+
+	transport := ts.Client().Transport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, network, ts.Listener.Addr().String())
+	}
+	// the certificate of the test server is valid for example.com.
+	transport.TLSClientConfig.ServerName = "example.com"
+	c := New()
+	c.client.Transport = transport
+	return c, "registry.example.com"
+}
+
+func TestVerifyDigest(t *testing.T) {
+	digest := sha256Digest(testManifest)
+	if err := verifyDigest(digest, []byte(testManifest)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := verifyDigest(digest, []byte(testManifest[:10])); !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("want ErrDigestMismatch, got %v", err)
+	}
+	if err := verifyDigest("md5:d41d8cd98f00b204e9800998ecf8427e", nil); err == nil {
+		t.Error("want error for unsupported algorithm, got nil")
+	}
+}
+
+func TestGetManifests_DigestMismatch(t *testing.T) {
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Docker-Content-Digest", sha256Digest(testManifest))
+		// truncated response
+		w.Write([]byte(testManifest[:len(testManifest)-1]))
+	}))
+
+	_, err := c.getManifests(context.Background(), host, "foo/bar", "latest")
+	if !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("want ErrDigestMismatch, got %v", err)
+	}
+}
+
+func TestGetManifests_ByDigest(t *testing.T) {
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testManifest))
+	}))
+
+	if _, err := c.getManifests(context.Background(), host, "foo/bar", sha256Digest(testManifest)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	other := sha256Digest("other")
+	if _, err := c.getManifests(context.Background(), host, "foo/bar", other); !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("want ErrDigestMismatch, got %v", err)
+	}
+}
+
+func TestGetManifestsResponse(t *testing.T) {
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json; charset=utf-8")
+		w.Header().Set("Docker-Content-Digest", sha256Digest(testManifest))
+		w.Write([]byte(testManifest))
+	}))
+	c.staticTokens = map[string]string{host: "secret"}
+
+	resp, err := c.GetManifestsResponse(context.Background(), host+"/foo/bar:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Digest != sha256Digest(testManifest) {
+		t.Errorf("want %s, got %s", sha256Digest(testManifest), resp.Digest)
+	}
+	if resp.MediaType != "application/vnd.docker.distribution.manifest.v2+json" {
+		t.Errorf("unexpected media type: %s", resp.MediaType)
+	}
+	if resp.Manifests.Config == nil {
+		t.Error("want the manifest")
+	}
+	if string(resp.Raw) != testManifest {
+		t.Errorf("want the body as served, got %s", resp.Raw)
+	}
+}
@@ -0,0 +1,215 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DockerConfig is a CredentialProvider that has the credentials in the format of the Docker config file.
+// It is the same format as the .dockerconfigjson of Kubernetes secrets.
+type DockerConfig struct {
+	auths StaticCredentials
+}
+
+type dockerConfigAuth struct {
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	Auth          string `json:"auth,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+}
+
+// ParseDockerConfig parses the credentials in the Docker config file format.
+// It also accepts a Kubernetes secret of the type kubernetes.io/dockerconfigjson in JSON,
+// e.g. the output of `kubectl get secret -o json`.
+func ParseDockerConfig(data []byte) (*DockerConfig, error) {
+	var raw struct {
+		// the Docker config file format
+		Auths map[string]*dockerConfigAuth `json:"auths"`
+
+		// the Kubernetes secret format
+		Kind       string            `json:"kind"`
+		Data       map[string]string `json:"data"`
+		StringData map[string]string `json:"stringData"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse the docker config: %w", err)
+	}
+
+	if raw.Kind == "Secret" {
+		if v, ok := raw.StringData[".dockerconfigjson"]; ok {
+			return ParseDockerConfig([]byte(v))
+		}
+		if v, ok := raw.Data[".dockerconfigjson"]; ok {
+			decoded, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode .dockerconfigjson: %w", err)
+			}
+			return ParseDockerConfig(decoded)
+		}
+		return nil, errors.New("the secret doesn't have .dockerconfigjson")
+	}
+
+	auths := StaticCredentials{}
+	for key, auth := range raw.Auths {
+		if auth == nil {
+			continue
+		}
+		username, password := auth.Username, auth.Password
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode the auth of %s: %w", key, err)
+			}
+			idx := strings.IndexRune(string(decoded), ':')
+			if idx < 0 {
+				return nil, fmt.Errorf("invalid auth of %s", key)
+			}
+			username, password = string(decoded[:idx]), string(decoded[idx+1:])
+		}
+		if auth.IdentityToken != "" {
+			username, password = identityTokenUsername, auth.IdentityToken
+		}
+		if username == "" && password == "" {
+			continue
+		}
+		auths.Set(normalizeAuthKey(key), username, password)
+	}
+	return &DockerConfig{auths: auths}, nil
+}
+
+// LoadDockerConfigFile loads the credentials from the file in the Docker config file format.
+func LoadDockerConfigFile(path string) (*DockerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDockerConfig(data)
+}
+
+// NewDockerConfig returns an empty DockerConfig.
+func NewDockerConfig() *DockerConfig {
+	return &DockerConfig{auths: StaticCredentials{}}
+}
+
+// Set sets the credentials for the host.
+func (c *DockerConfig) Set(host, username, password string) {
+	c.auths.Set(normalizeHost(host), username, password)
+}
+
+// Delete deletes the credentials for the host, and reports whether they existed.
+func (c *DockerConfig) Delete(host string) bool {
+	host = strings.ToLower(normalizeHost(host))
+	_, ok := c.auths[host]
+	delete(c.auths, host)
+	return ok
+}
+
+// MarshalJSON encodes the credentials in the Docker config file format.
+func (c *DockerConfig) MarshalJSON() ([]byte, error) {
+	auths := make(map[string]*dockerConfigAuth, len(c.auths))
+	for host, cred := range c.auths {
+		if cred.Username == identityTokenUsername {
+			auths[host] = &dockerConfigAuth{IdentityToken: cred.Password}
+			continue
+		}
+		auths[host] = &dockerConfigAuth{
+			Auth: base64.StdEncoding.EncodeToString([]byte(cred.Username + ":" + cred.Password)),
+		}
+	}
+	return json.Marshal(struct {
+		Auths map[string]*dockerConfigAuth `json:"auths"`
+	}{auths})
+}
+
+// SaveFile writes the credentials into the file in the Docker config file format.
+// The file is readable only by the owner.
+func (c *DockerConfig) SaveFile(path string) error {
+	data, err := json.MarshalIndent(c, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".auth-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// GetCredentials implements CredentialProvider.
+func (c *DockerConfig) GetCredentials(ctx context.Context, host string) (username, password string, err error) {
+	return c.auths.GetCredentials(ctx, normalizeHost(host))
+}
+
+// normalizeAuthKey converts the key of auths into a host.
+// The keys may be URLs, e.g. "https://index.docker.io/v1/".
+func normalizeAuthKey(key string) string {
+	key = strings.TrimPrefix(key, "https://")
+	key = strings.TrimPrefix(key, "http://")
+	if idx := strings.IndexRune(key, '/'); idx >= 0 {
+		key = key[:idx]
+	}
+	return normalizeHost(key)
+}
+
+// containersAuthFiles returns the paths of containers-auth.json(5) files in the order of precedence.
+func containersAuthFiles() []string {
+	var paths []string
+	if path := os.Getenv("REGISTRY_AUTH_FILE"); path != "" {
+		paths = append(paths, path)
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		paths = append(paths, filepath.Join(dir, "containers", "auth.json"))
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		paths = append(paths, filepath.Join(dir, "containers", "auth.json"))
+	} else if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".config", "containers", "auth.json"))
+	}
+	paths = append(paths, filepath.FromSlash("/etc/containers/auth.json"))
+	return paths
+}
+
+// LoadContainersAuth loads the credentials from the containers-auth.json(5) files used by podman, skopeo and buildah.
+// It looks up $REGISTRY_AUTH_FILE, ${XDG_RUNTIME_DIR}/containers/auth.json, ${XDG_CONFIG_HOME}/containers/auth.json,
+// and /etc/containers/auth.json. The credentials in the earlier files take precedence.
+// The keys scoped to namespaces, e.g. "quay.io/foo", are applied to the whole registry.
+func LoadContainersAuth() (*DockerConfig, error) {
+	ret := &DockerConfig{auths: StaticCredentials{}}
+	paths := containersAuthFiles()
+	for i := len(paths) - 1; i >= 0; i-- {
+		config, err := LoadDockerConfigFile(paths[i])
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", paths[i], err)
+		}
+		for host, cred := range config.auths {
+			ret.auths[host] = cred
+		}
+	}
+	return ret, nil
+}
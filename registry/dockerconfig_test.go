@@ -0,0 +1,139 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseDockerConfig(t *testing.T) {
+	config := fmt.Sprintf(`{
+		"auths": {
+			"https://index.docker.io/v1/": {"auth": %q},
+			"ghcr.io": {"username": "octocat", "password": "ghp_secret"},
+			"registry.example.com": {"identitytoken": "identity"}
+		}
+	}`, base64.StdEncoding.EncodeToString([]byte("user:pass")))
+
+	secret := fmt.Sprintf(`{
+		"apiVersion": "v1",
+		"kind": "Secret",
+		"type": "kubernetes.io/dockerconfigjson",
+		"data": {".dockerconfigjson": %q}
+	}`, base64.StdEncoding.EncodeToString([]byte(config)))
+
+	for name, data := range map[string]string{"config": config, "secret": secret} {
+		c, err := ParseDockerConfig([]byte(data))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		tests := []struct {
+			host     string
+			username string
+			password string
+		}{
+			{dockerHubHost, "user", "pass"},
+			{"ghcr.io", "octocat", "ghp_secret"},
+			{"registry.example.com", identityTokenUsername, "identity"},
+			{"quay.io", "", ""},
+		}
+		for _, tt := range tests {
+			username, password, err := c.GetCredentials(context.Background(), tt.host)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if username != tt.username || password != tt.password {
+				t.Errorf("%s: %s: want %q, %q, got %q, %q", name, tt.host, tt.username, tt.password, username, password)
+			}
+		}
+	}
+}
+
+func TestLoadContainersAuth(t *testing.T) {
+	dir := t.TempDir()
+	runtimeDir := filepath.Join(dir, "run")
+	configDir := filepath.Join(dir, "config")
+	writeAuth := func(dir, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, "containers"), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "containers", "auth.json"), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeAuth(runtimeDir, `{"auths":{"quay.io/foo":{"username":"runtime","password":"runtime"}}}`)
+	writeAuth(configDir, `{"auths":{"quay.io":{"username":"config","password":"config"},"docker.io":{"username":"user","password":"pass"}}}`)
+	setenvForTest(t, "REGISTRY_AUTH_FILE", filepath.Join(dir, "not-found.json"))
+	setenvForTest(t, "XDG_RUNTIME_DIR", runtimeDir)
+	setenvForTest(t, "XDG_CONFIG_HOME", configDir)
+
+	c, err := LoadContainersAuth()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the runtime directory takes precedence.
+	username, password, err := c.GetCredentials(context.Background(), "quay.io")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if username != "runtime" || password != "runtime" {
+		t.Errorf("unexpected credentials: %q, %q", username, password)
+	}
+
+	username, password, err = c.GetCredentials(context.Background(), dockerHubHost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if username != "user" || password != "pass" {
+		t.Errorf("unexpected credentials: %q, %q", username, password)
+	}
+}
+
+func TestDockerConfig_SaveFile(t *testing.T) {
+	config := NewDockerConfig()
+	config.Set("docker.io", "user", "pass")
+	config.Set("ghcr.io", identityTokenUsername, "identity")
+	config.Set("quay.io", "foo", "bar")
+	if !config.Delete("quay.io") {
+		t.Error("want quay.io deleted")
+	}
+
+	path := filepath.Join(t.TempDir(), "auth.json")
+	if err := config.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := stat.Mode().Perm(); perm != 0600 {
+		t.Errorf("want the permission 0600, got %o", perm)
+	}
+
+	loaded, err := LoadDockerConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		host, username, password string
+	}{
+		{dockerHubHost, "user", "pass"},
+		{"ghcr.io", identityTokenUsername, "identity"},
+		{"quay.io", "", ""},
+	}
+	for _, tt := range tests {
+		username, password, err := loaded.GetCredentials(context.Background(), tt.host)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if username != tt.username || password != tt.password {
+			t.Errorf("%s: want %s:%s, got %s:%s", tt.host, tt.username, tt.password, username, password)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"github.com/aws/aws-sdk-go-v2/service/ecrpublic"
+)
+
+const ecrPublicHost = "public.ecr.aws"
+
+// e.g. 123456789012.dkr.ecr.us-east-1.amazonaws.com
+var ecrHostRegexp = regexp.MustCompile(`^([0-9]{12})\.dkr\.ecr(-fips)?\.([a-z0-9-]+)\.amazonaws\.com(?:\.cn)?$`)
+
+// ECRCredentialProvider is a CredentialProvider for Amazon ECR and Amazon ECR Public.
+// It gets authorization tokens with the AWS credentials from the default credential chain of the AWS SDK,
+// and caches them until they expire.
+type ECRCredentialProvider struct {
+	mu sync.Mutex
+
+	// cfg is the configuration of the AWS SDK, loaded on the first use.
+	cfg    *aws.Config
+	tokens map[string]*ecrToken
+}
+
+type ecrToken struct {
+	username  string
+	password  string
+	expiresAt time.Time
+}
+
+// NewECRCredentialProvider returns a new ECRCredentialProvider.
+func NewECRCredentialProvider() *ECRCredentialProvider {
+	return &ECRCredentialProvider{}
+}
+
+// GetCredentials implements CredentialProvider.
+func (p *ECRCredentialProvider) GetCredentials(ctx context.Context, host string) (username, password string, err error) {
+	host = strings.ToLower(host)
+	if host != ecrPublicHost && !ecrHostRegexp.MatchString(host) {
+		return "", "", nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if token, ok := p.tokens[host]; ok && time.Now().Add(time.Minute).Before(token.expiresAt) {
+		return token.username, token.password, nil
+	}
+
+	if p.cfg == nil {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to load the AWS configuration: %w", err)
+		}
+		p.cfg = &cfg
+	}
+
+	var token *ecrToken
+	if host == ecrPublicHost {
+		if _, err := p.cfg.Credentials.Retrieve(ctx); err != nil {
+			// ECR Public allows anonymous access.
+			return "", "", nil
+		}
+		token, err = p.getPublicToken(ctx)
+	} else {
+		token, err = p.getPrivateToken(ctx, host)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	if p.tokens == nil {
+		p.tokens = make(map[string]*ecrToken)
+	}
+	p.tokens[host] = token
+	return token.username, token.password, nil
+}
+
+func newECRToken(authorizationToken *string, expiresAt *time.Time) (*ecrToken, error) {
+	if authorizationToken == nil {
+		return nil, errors.New("empty authorization token")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(*authorizationToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the authorization token: %w", err)
+	}
+	idx := strings.IndexRune(string(decoded), ':')
+	if idx < 0 {
+		return nil, errors.New("invalid authorization token")
+	}
+	token := &ecrToken{
+		username: string(decoded[:idx]),
+		password: string(decoded[idx+1:]),
+	}
+	if expiresAt != nil {
+		token.expiresAt = *expiresAt
+	}
+	return token, nil
+}
+
+// getPrivateToken calls ecr:GetAuthorizationToken in the region of the registry.
+func (p *ECRCredentialProvider) getPrivateToken(ctx context.Context, host string) (*ecrToken, error) {
+	m := ecrHostRegexp.FindStringSubmatch(host)
+	account, fips, region := m[1], m[2], m[3]
+	client := ecr.NewFromConfig(*p.cfg, func(o *ecr.Options) {
+		o.Region = region
+		if fips != "" {
+			o.EndpointOptions.UseFIPSEndpoint = aws.FIPSEndpointStateEnabled
+		}
+	})
+	out, err := client.GetAuthorizationToken(ctx, &ecr.GetAuthorizationTokenInput{
+		RegistryIds: []string{account},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the authorization token of %s: %w", host, err)
+	}
+	if len(out.AuthorizationData) == 0 {
+		return nil, fmt.Errorf("failed to get the authorization token of %s: empty response", host)
+	}
+	data := out.AuthorizationData[0]
+	return newECRToken(data.AuthorizationToken, data.ExpiresAt)
+}
+
+// getPublicToken calls ecr-public:GetAuthorizationToken.
+func (p *ECRCredentialProvider) getPublicToken(ctx context.Context) (*ecrToken, error) {
+	client := ecrpublic.NewFromConfig(*p.cfg, func(o *ecrpublic.Options) {
+		// the API of ECR Public is only available in us-east-1.
+		o.Region = "us-east-1"
+	})
+	out, err := client.GetAuthorizationToken(ctx, &ecrpublic.GetAuthorizationTokenInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the authorization token of %s: %w", ecrPublicHost, err)
+	}
+	if out.AuthorizationData == nil {
+		return nil, fmt.Errorf("failed to get the authorization token of %s: empty response", ecrPublicHost)
+	}
+	return newECRToken(out.AuthorizationData.AuthorizationToken, out.AuthorizationData.ExpiresAt)
+}
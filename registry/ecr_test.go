@@ -0,0 +1,69 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+func TestECRCredentialProvider(t *testing.T) {
+	var calls int
+	expiresAt := time.Now().Add(12 * time.Hour)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if got := r.Header.Get("X-Amz-Target"); got != "AmazonEC2ContainerRegistry_V20150921.GetAuthorizationToken" {
+			t.Errorf("unexpected target: %q", got)
+		}
+		if got := r.Header.Get("Authorization"); !strings.Contains(got, "/ap-northeast-1/ecr/aws4_request") {
+			t.Errorf("unexpected authorization: %q", got)
+		}
+		var in struct {
+			RegistryIDs []string `json:"registryIds"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			t.Fatal(err)
+		}
+		if len(in.RegistryIDs) != 1 || in.RegistryIDs[0] != "123456789012" {
+			t.Errorf("unexpected registry ids: %v", in.RegistryIDs)
+		}
+		fmt.Fprintf(w, `{"authorizationData":[{"authorizationToken":%q,"expiresAt":%d.5,"proxyEndpoint":"https://123456789012.dkr.ecr.ap-northeast-1.amazonaws.com"}]}`,
+			base64.StdEncoding.EncodeToString([]byte("AWS:password")), expiresAt.Unix())
+	}))
+	defer ts.Close()
+
+	p := NewECRCredentialProvider()
+	p.cfg = &aws.Config{
+		Region:       "us-east-1",
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		BaseEndpoint: aws.String(ts.URL),
+	}
+
+	for i := 0; i < 2; i++ {
+		username, password, err := p.GetCredentials(context.Background(), "123456789012.dkr.ecr.ap-northeast-1.amazonaws.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if username != "AWS" || password != "password" {
+			t.Errorf("unexpected credentials: %q, %q", username, password)
+		}
+	}
+	// the token is cached.
+	if calls != 1 {
+		t.Errorf("want 1 call, got %d", calls)
+	}
+
+	// other registries are not handled.
+	username, password, err := p.GetCredentials(context.Background(), "ghcr.io")
+	if err != nil || username != "" || password != "" {
+		t.Errorf("want no credentials, got %q, %q, %v", username, password, err)
+	}
+}
@@ -0,0 +1,80 @@
+package registry
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+// EnvCredentials is a CredentialProvider that reads the credentials from environment values.
+// The names of the values are <prefix><HOST>_USERNAME and <prefix><HOST>_PASSWORD,
+// where HOST is the upper-cased host name with non-alphanumeric characters replaced with underscores.
+// e.g. DIUC_AUTH_GHCR_IO_USERNAME and DIUC_AUTH_GHCR_IO_PASSWORD for ghcr.io with the prefix "DIUC_AUTH_".
+// The credentials for Docker Hub can be given with the host DOCKER_IO.
+type EnvCredentials struct {
+	creds map[string]*Credential
+}
+
+// NewEnvCredentials reads the environment values with the prefix.
+// The values are resolved at the time of the call.
+func NewEnvCredentials(prefix string) *EnvCredentials {
+	usernames := map[string]string{}
+	passwords := map[string]string{}
+	for _, env := range os.Environ() {
+		idx := strings.IndexRune(env, '=')
+		if idx < 0 {
+			continue
+		}
+		name, value := env[:idx], env[idx+1:]
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		name = name[len(prefix):]
+		switch {
+		case strings.HasSuffix(name, "_USERNAME"):
+			usernames[strings.TrimSuffix(name, "_USERNAME")] = value
+		case strings.HasSuffix(name, "_PASSWORD"):
+			passwords[strings.TrimSuffix(name, "_PASSWORD")] = value
+		}
+	}
+
+	creds := map[string]*Credential{}
+	for key, username := range usernames {
+		creds[key] = &Credential{
+			Username: username,
+			Password: passwords[key],
+		}
+	}
+	for key, password := range passwords {
+		if _, ok := creds[key]; !ok {
+			creds[key] = &Credential{Password: password}
+		}
+	}
+	return &EnvCredentials{creds: creds}
+}
+
+// GetCredentials implements CredentialProvider.
+func (e *EnvCredentials) GetCredentials(ctx context.Context, host string) (username, password string, err error) {
+	host = normalizeHost(host)
+	cred, ok := e.creds[envKey(host)]
+	if !ok && host == dockerHubHost {
+		cred, ok = e.creds[envKey("docker.io")]
+	}
+	if !ok {
+		return "", "", nil
+	}
+	return cred.Username, cred.Password, nil
+}
+
+// envKey converts the host into a part of environment value names.
+func envKey(host string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z':
+			return r - 'a' + 'A'
+		case 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+			return r
+		}
+		return '_'
+	}, host)
+}
@@ -0,0 +1,36 @@
+package registry
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEnvCredentials(t *testing.T) {
+	setenvForTest(t, "TEST_AUTH_GHCR_IO_USERNAME", "octocat")
+	setenvForTest(t, "TEST_AUTH_GHCR_IO_PASSWORD", "ghp_secret")
+	setenvForTest(t, "TEST_AUTH_REGISTRY_LOCAL_5000_USERNAME", "user")
+	setenvForTest(t, "TEST_AUTH_REGISTRY_LOCAL_5000_PASSWORD", "pass")
+	setenvForTest(t, "TEST_AUTH_DOCKER_IO_USERNAME", "hub")
+	setenvForTest(t, "TEST_AUTH_DOCKER_IO_PASSWORD", "dckr_pat")
+	e := NewEnvCredentials("TEST_AUTH_")
+
+	tests := []struct {
+		host     string
+		username string
+		password string
+	}{
+		{"ghcr.io", "octocat", "ghp_secret"},
+		{"registry.local:5000", "user", "pass"},
+		{dockerHubHost, "hub", "dckr_pat"},
+		{"quay.io", "", ""},
+	}
+	for _, tt := range tests {
+		username, password, err := e.GetCredentials(context.Background(), tt.host)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if username != tt.username || password != tt.password {
+			t.Errorf("%s: want %q, %q, got %q, %q", tt.host, tt.username, tt.password, username, password)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+)
+
+const githubContainerRegistryHost = "ghcr.io"
+
+// WithGitHubToken authenticates requests to GitHub Container Registry (ghcr.io) with the GitHub token,
+// e.g. GITHUB_TOKEN in GitHub Actions or a personal access token with the read:packages scope.
+// GitHub Container Registry accepts base64 encoded GitHub tokens as bearer tokens.
+// The token takes precedence over the credentials of the providers. Use NewGitHubTokenCredentials for a fallback.
+func WithGitHubToken(token string) Option {
+	if token == "" {
+		return func(c *Client) {}
+	}
+	return WithStaticToken(githubContainerRegistryHost, base64.StdEncoding.EncodeToString([]byte(token)))
+}
+
+// NewGitHubTokenCredentials returns the credential provider of GitHub Container Registry (ghcr.io) with the GitHub token.
+// The token is exchanged for a bearer token by the challenge flow, as the other credentials are.
+// Add it after the other providers, so that it is used only if none of them has the credentials for ghcr.io.
+func NewGitHubTokenCredentials(token string) CredentialProvider {
+	return CredentialProviderFunc(func(ctx context.Context, host string) (string, string, error) {
+		if token == "" || normalizeHost(host) != githubContainerRegistryHost {
+			return "", "", nil
+		}
+		// ghcr.io accepts any username with the token.
+		return "x-access-token", token, nil
+	})
+}
@@ -0,0 +1,112 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestWithGitHubToken(t *testing.T) {
+	c, _ := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "ghcr.io" {
+			http.NotFound(w, r)
+			return
+		}
+		want := "Bearer " + base64.StdEncoding.EncodeToString([]byte("ghp_secret"))
+		if r.Header.Get("Authorization") != want {
+			t.Errorf("unexpected authorization: %q", r.Header.Get("Authorization"))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(testManifest))
+	}))
+	WithGitHubToken("ghp_secret")(c)
+
+	if _, err := c.GetManifests(context.Background(), "ghcr.io/foo/bar:latest"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewGitHubTokenCredentials(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []CredentialProvider
+		want      string
+	}{
+		{
+			name:      "fallback",
+			providers: []CredentialProvider{NewGitHubTokenCredentials("ghp_secret")},
+			want:      "ghp_secret",
+		},
+		{
+			name: "explicit credentials",
+			providers: []CredentialProvider{
+				StaticCredentials{"ghcr.io": {Username: "octocat", Password: "ghp_explicit"}},
+				NewGitHubTokenCredentials("ghp_secret"),
+			},
+			want: "ghp_explicit",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var password string
+			c, _ := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/token" {
+					var ok bool
+					if _, password, ok = r.BasicAuth(); !ok {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+					fmt.Fprint(w, `{"token":"secret"}`)
+					return
+				}
+				if r.Header.Get("Authorization") != "Bearer secret" {
+					w.Header().Set("Www-Authenticate", `Bearer realm="https://ghcr.io/token",service="ghcr.io"`)
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.Write([]byte(testManifest))
+			}))
+			for _, p := range tt.providers {
+				WithCredentialProvider(p)(c)
+			}
+
+			if _, err := c.GetManifests(context.Background(), "ghcr.io/foo/bar:latest"); err != nil {
+				t.Fatal(err)
+			}
+			if password != tt.want {
+				t.Errorf("want %q, got %q", tt.want, password)
+			}
+		})
+	}
+
+	// the other registries are not sent the token.
+	username, password, err := NewGitHubTokenCredentials("ghp_secret").GetCredentials(context.Background(), "registry.example.com")
+	if err != nil || username != "" || password != "" {
+		t.Errorf("want no credentials, got %q, %q, %v", username, password, err)
+	}
+}
+
+func TestWithStaticToken(t *testing.T) {
+	var requests int
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "Bearer long-lived-token" {
+			w.Header().Set("Www-Authenticate", `Bearer realm="https://registry.example.com/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(testManifest))
+	}))
+	WithStaticToken(host, "long-lived-token")(c)
+
+	if _, err := c.GetManifests(context.Background(), host+"/foo/bar:latest"); err != nil {
+		t.Fatal(err)
+	}
+	// no challenge flow.
+	if requests != 1 {
+		t.Errorf("want 1 request, got %d", requests)
+	}
+}
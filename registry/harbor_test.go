@@ -0,0 +1,98 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// newHarborServer emulates the challenge and the token service of Harbor.
+// The robot account has the pull permission of the project "proj" only.
+func newHarborServer(t *testing.T) (*Client, string) {
+	const robot = "robot$proj+ci"
+	const secret = "s3cr3t"
+	return newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/service/token" {
+			username, password, ok := r.BasicAuth()
+			if ok && (username != robot || password != secret) {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"errors":[{"code":"UNAUTHORIZED","message":"authentication required"}]}`)
+				return
+			}
+			// Harbor issues tokens even for the scopes that the account doesn't have,
+			// but the tokens don't grant the access.
+			var granted []string
+			for _, scope := range r.URL.Query()["scope"] {
+				if ok && strings.HasPrefix(scope, "repository:proj/") && strings.HasSuffix(scope, ":pull") {
+					granted = append(granted, scope)
+				}
+			}
+			token := "granted:" + strings.Join(granted, " ")
+			fmt.Fprintf(w, `{"token":%q,"access_token":%q,"expires_in":1800,"issued_at":"2023-01-01T00:00:00Z"}`, token, token)
+			return
+		}
+
+		var scope string
+		switch {
+		case r.URL.Path == "/v2/":
+			w.Header().Set("Www-Authenticate", `Bearer realm="https://harbor.example.com/service/token",service="harbor-registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		case r.URL.Path == "/v2/_catalog":
+			scope = "registry:catalog:*"
+		case strings.HasPrefix(r.URL.Path, "/v2/"):
+			repo := strings.TrimPrefix(r.URL.Path, "/v2/")
+			repo = repo[:strings.Index(repo, "/manifests/")]
+			scope = "repository:" + repo + ":pull"
+		}
+		auth := r.Header.Get("Authorization")
+		if auth == "Bearer granted:"+scope {
+			w.Write([]byte(testManifest))
+			return
+		}
+		challenge := fmt.Sprintf(`Bearer realm="https://harbor.example.com/service/token",service="harbor-registry",scope=%q`, scope)
+		if auth != "" {
+			challenge += `,error="insufficient_scope"`
+		}
+		w.Header().Set("Www-Authenticate", challenge)
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"errors":[{"code":"UNAUTHORIZED","message":"unauthorized to access repository"}]}`)
+	}))
+}
+
+func TestHarbor_RobotAccount(t *testing.T) {
+	c, host := newHarborServer(t)
+	ctx := context.Background()
+	if err := c.Login(ctx, host, "robot$proj+ci", "s3cr3t"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.GetManifests(ctx, host+"/proj/app:v1"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// the robot account doesn't have the permission of the other projects.
+	if _, err := c.GetManifests(ctx, host+"/other/app:v1"); !errors.Is(err, ErrInsufficientScope) {
+		t.Errorf("want ErrInsufficientScope, got %v", err)
+	}
+
+	// robot accounts can't list the catalog.
+	if _, err := c.Catalog(ctx, host); !errors.Is(err, ErrInsufficientScope) {
+		t.Errorf("want ErrInsufficientScope, got %v", err)
+	}
+}
+
+func TestHarbor_WithValidation(t *testing.T) {
+	c, host := newHarborServer(t)
+	ctx := context.Background()
+
+	if err := c.Login(ctx, host, "robot$proj+ci", "wrong", WithValidation("proj/app")); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("want ErrInvalidCredentials, got %v", err)
+	}
+	if err := c.Login(ctx, host, "robot$proj+ci", "s3cr3t", WithValidation("proj/app")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package registry
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Products of registries detected by Ping.
+const (
+	ProductArtifactory = "artifactory"
+	ProductNexus       = "nexus"
+)
+
+// HostConfig is the per-host configuration for registries that deviate from the distribution spec,
+// such as JFrog Artifactory and Sonatype Nexus Repository.
+type HostConfig struct {
+	// PathPrefix is inserted before /v2/ of the API paths.
+	// e.g. "/repository/docker-hosted" for the path based routing of Nexus,
+	// or "/artifactory/api/docker/docker-local" for the repository path method of Artifactory.
+	PathPrefix string
+
+	// RepositoryPrefix is prepended to repository names.
+	// e.g. "docker-local" for the repository path method of Artifactory (/v2/docker-local/<image>/...).
+	RepositoryPrefix string
+}
+
+// WithHostConfig sets the compatibility configuration for the host.
+func WithHostConfig(host string, config HostConfig) Option {
+	return func(c *Client) {
+		if c.hostConfigs == nil {
+			c.hostConfigs = make(map[string]HostConfig)
+		}
+		config.PathPrefix = "/" + strings.Trim(config.PathPrefix, "/")
+		if config.PathPrefix == "/" {
+			config.PathPrefix = ""
+		}
+		config.RepositoryPrefix = strings.Trim(config.RepositoryPrefix, "/")
+		c.hostConfigs[normalizeHost(host)] = config
+	}
+}
+
+// url returns the URL of the API path on the host.
+func (c *Client) url(host, path string) string {
+	prefix := c.hostConfigs[normalizeHost(host)].PathPrefix
+	if prefix != "" && !strings.HasPrefix(path, prefix+"/") {
+		// the links of pagination may already contain the prefix.
+		path = prefix + path
+	}
+	return "https://" + host + path
+}
+
+// repository returns the repository name on the host.
+func (c *Client) repository(host, repo string) string {
+	if prefix := c.hostConfigs[normalizeHost(host)].RepositoryPrefix; prefix != "" {
+		return prefix + "/" + repo
+	}
+	return repo
+}
+
+// detectProduct detects the product of the registry from the response header.
+func detectProduct(header http.Header) string {
+	if header.Get("X-Artifactory-Id") != "" || header.Get("X-Jfrog-Version") != "" {
+		return ProductArtifactory
+	}
+	if strings.Contains(strings.ToLower(header.Get("Server")), "nexus") {
+		return ProductNexus
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestWithHostConfig_Nexus(t *testing.T) {
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "Nexus/3.45.0-01 (OSS)")
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "user" || password != "pass" {
+			w.Header().Set("Www-Authenticate", `BASIC realm="Sonatype Nexus Repository Manager"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/repository/docker-hosted/v2/":
+			w.Write([]byte("{}"))
+		case "/repository/docker-hosted/v2/foo/bar/manifests/latest":
+			w.Write([]byte(testManifest))
+		case "/repository/docker-hosted/v2/foo/bar/tags/list":
+			if r.URL.Query().Get("last") == "" {
+				w.Header().Set("Link", `</repository/docker-hosted/v2/foo/bar/tags/list?last=a&n=1000>; rel="next"`)
+				fmt.Fprint(w, `{"tags":["a"]}`)
+				return
+			}
+			fmt.Fprint(w, `{"tags":["b"]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	WithHostConfig(host, HostConfig{PathPrefix: "repository/docker-hosted/"})(c)
+	ctx := context.Background()
+	if err := c.Login(ctx, host, "user", "pass"); err != nil {
+		t.Fatal(err)
+	}
+
+	caps, err := c.Ping(ctx, host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caps.Product != ProductNexus {
+		t.Errorf("want %q, got %q", ProductNexus, caps.Product)
+	}
+	if _, err := c.GetManifests(ctx, host+"/foo/bar:latest"); err != nil {
+		t.Fatal(err)
+	}
+	tags, err := c.ListTags(ctx, host+"/foo/bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("want %v, got %v", want, tags)
+	}
+}
+
+func TestWithHostConfig_Artifactory(t *testing.T) {
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Artifactory-Id", "abc")
+		if r.URL.Path == "/artifactory/api/docker/docker-local/v2/token" {
+			if got := r.URL.Query().Get("scope"); got != "repository:docker-local/foo/bar:pull" {
+				t.Errorf("unexpected scope: %q", got)
+			}
+			fmt.Fprint(w, `{"token":"secret"}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("Www-Authenticate", `Bearer realm="https://registry.example.com/artifactory/api/docker/docker-local/v2/token",service="registry.example.com"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/v2/docker-local/foo/bar/manifests/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(testManifest))
+	}))
+	WithHostConfig(host, HostConfig{RepositoryPrefix: "docker-local"})(c)
+
+	caps, err := c.Ping(context.Background(), host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caps.Product != ProductArtifactory {
+		t.Errorf("want %q, got %q", ProductArtifactory, caps.Product)
+	}
+	if _, err := c.GetManifests(context.Background(), host+"/foo/bar:latest"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,100 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const dockerHubAPIEndpoint = "https://hub.docker.com"
+
+// HubTag is the metadata of a tag, provided by the Docker Hub API.
+type HubTag struct {
+	Name        string      `json:"name"`
+	Digest      string      `json:"digest"`
+	LastUpdated time.Time   `json:"last_updated"`
+	Images      []*HubImage `json:"images"`
+}
+
+// HubImage is an image of the tag, provided by the Docker Hub API.
+type HubImage struct {
+	Architecture string    `json:"architecture"`
+	OS           string    `json:"os"`
+	Variant      string    `json:"variant"`
+	Digest       string    `json:"digest"`
+	Size         int64     `json:"size"`
+	LastPushed   time.Time `json:"last_pushed"`
+}
+
+// GetHubTag gets the metadata of the tag from the Docker Hub API.
+// It doesn't consume the pull rate limit of the registry.
+func (c *Client) GetHubTag(ctx context.Context, image string) (*HubTag, error) {
+	host, repo, tag := GetRepository(image)
+	if host != dockerHubHost {
+		return nil, fmt.Errorf("%s is not an image on Docker Hub", image)
+	}
+
+	endpoint := c.hubEndpoint
+	if endpoint == "" {
+		endpoint = dockerHubAPIEndpoint
+	}
+	u := fmt.Sprintf("%s/v2/repositories/%s/tags/%s", endpoint, repo, tag)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &registryError{
+			statusCode: resp.StatusCode,
+			header:     resp.Header,
+		}
+	}
+
+	var hubTag *HubTag
+	dec := json.NewDecoder(resp.Body)
+	if err := dec.Decode(&hubTag); err != nil {
+		return nil, err
+	}
+	return hubTag, nil
+}
+
+// Matches reports whether the tag still points to the manifests of the digest, e.g. the digest of the stored manifests.
+// If either digest is unknown, it compares the digests of the platforms of the manifests instead,
+// and returns false if it can't determine, e.g. the manifests is not an index.
+func (t *HubTag) Matches(digest string, m *Manifests) bool {
+	if t == nil {
+		return false
+	}
+	if t.Digest != "" && digest != "" {
+		return t.Digest == digest
+	}
+	if m == nil || len(t.Images) == 0 || len(m.Manifests) == 0 {
+		return false
+	}
+
+	digests := make(map[string]struct{}, len(m.Manifests))
+	for _, manifest := range m.Manifests {
+		if isAttestation(manifest) {
+			// skip attestations; Docker Hub doesn't list them.
+			continue
+		}
+		digests[manifest.Digest] = struct{}{}
+	}
+	if len(digests) != len(t.Images) {
+		return false
+	}
+	for _, img := range t.Images {
+		if _, ok := digests[img.Digest]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestGetHubTag(t *testing.T) {
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/repositories/library/alpine/tags/3.17" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{
+			"name": "3.17",
+			"digest": "sha256:f71a5f071694a785e064f05fed657bf8277f1b2113a8ed70c90ad486d6ee54dc",
+			"last_updated": "2023-02-11T04:46:42.449083Z",
+			"images": [
+				{"architecture": "amd64", "os": "linux", "digest": "sha256:93d5a28ff72d288d69b5997b8ba47396d2cbb62a72b5d87cd3351094b5d578a0"},
+				{"architecture": "arm", "variant": "v7", "os": "linux", "digest": "sha256:c67de8e2f6e6c5d8f4a9a5d9b3bc6c9e2ad5b84cbdb6b0c4fdbac1b7d1c0b63e"}
+			]
+		}`))
+	}))
+	c.hubEndpoint = "https://" + host
+
+	tag, err := c.GetHubTag(context.Background(), "alpine:3.17")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.LastUpdated.IsZero() {
+		t.Error("want last_updated, got zero")
+	}
+
+	m := &Manifests{
+		Manifests: []*Manifest{
+			{Digest: "sha256:93d5a28ff72d288d69b5997b8ba47396d2cbb62a72b5d87cd3351094b5d578a0", Platform: &Platform{OS: "linux", Architecture: "amd64"}},
+			{Digest: "sha256:c67de8e2f6e6c5d8f4a9a5d9b3bc6c9e2ad5b84cbdb6b0c4fdbac1b7d1c0b63e", Platform: &Platform{OS: "linux", Architecture: "arm", Variant: "v7"}},
+			{Digest: "sha256:0000000000000000000000000000000000000000000000000000000000000000", Platform: &Platform{OS: "unknown", Architecture: "unknown"}},
+		},
+	}
+	if !tag.Matches("", m) {
+		t.Error("want match, got mismatch")
+	}
+
+	m.Manifests[0].Digest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	if tag.Matches("", m) {
+		t.Error("want mismatch, got match")
+	}
+
+	if _, err := c.GetHubTag(context.Background(), "ghcr.io/foo/bar:latest"); err == nil {
+		t.Error("want error for non Docker Hub images, got nil")
+	}
+}
+
+func TestHubTagMatches(t *testing.T) {
+	const (
+		index  = "sha256:f71a5f071694a785e064f05fed657bf8277f1b2113a8ed70c90ad486d6ee54dc"
+		amd64  = "sha256:93d5a28ff72d288d69b5997b8ba47396d2cbb62a72b5d87cd3351094b5d578a0"
+		single = "sha256:c67de8e2f6e6c5d8f4a9a5d9b3bc6c9e2ad5b84cbdb6b0c4fdbac1b7d1c0b63e"
+		other  = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	)
+	singleManifest := &Manifests{
+		SchemaVersion: 2,
+		MediaType:     "application/vnd.oci.image.manifest.v1+json",
+		Config:        &Config{MediaType: "application/vnd.oci.image.config.v1+json", Digest: other},
+	}
+	indexManifest := &Manifests{
+		Manifests: []*Manifest{
+			{Digest: amd64, Platform: &Platform{OS: "linux", Architecture: "amd64"}},
+		},
+	}
+	tests := []struct {
+		name   string
+		tag    *HubTag
+		digest string
+		m      *Manifests
+		want   bool
+	}{
+		{
+			name:   "single manifest",
+			tag:    &HubTag{Digest: single, Images: []*HubImage{{OS: "linux", Architecture: "amd64", Digest: single}}},
+			digest: single,
+			m:      singleManifest,
+			want:   true,
+		},
+		{
+			name:   "single manifest updated",
+			tag:    &HubTag{Digest: other, Images: []*HubImage{{OS: "linux", Architecture: "amd64", Digest: other}}},
+			digest: single,
+			m:      singleManifest,
+			want:   false,
+		},
+		{
+			name:   "single manifest without the stored digest",
+			tag:    &HubTag{Digest: single, Images: []*HubImage{{OS: "linux", Architecture: "amd64", Digest: single}}},
+			digest: "",
+			m:      singleManifest,
+			want:   false,
+		},
+		{
+			name:   "index",
+			tag:    &HubTag{Digest: index, Images: []*HubImage{{OS: "linux", Architecture: "amd64", Digest: amd64}}},
+			digest: index,
+			m:      indexManifest,
+			want:   true,
+		},
+		{
+			name:   "index updated",
+			tag:    &HubTag{Digest: other, Images: []*HubImage{{OS: "linux", Architecture: "amd64", Digest: amd64}}},
+			digest: index,
+			m:      indexManifest,
+			want:   false,
+		},
+		{
+			name:   "no tag digest",
+			tag:    &HubTag{Images: []*HubImage{{OS: "linux", Architecture: "amd64", Digest: amd64}}},
+			digest: index,
+			m:      indexManifest,
+			want:   true,
+		},
+		{
+			name:   "no tag digest, platform updated",
+			tag:    &HubTag{Images: []*HubImage{{OS: "linux", Architecture: "amd64", Digest: other}}},
+			digest: index,
+			m:      indexManifest,
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tag.Matches(tt.digest, tt.m); got != tt.want {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package registry
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WithLogger logs the method, URL, status and duration of every request to the registries.
+// It is useful for debugging the authentication to private registries.
+func WithLogger(logger *log.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithLogHeaders logs the request and response headers in addition to WithLogger.
+// The credentials in the headers, such as Authorization, are redacted if redact is true.
+func WithLogHeaders(redact bool) Option {
+	return func(c *Client) {
+		c.logHeaders = true
+		c.logRedact = redact
+	}
+}
+
+// sensitiveHeaders are the headers that contain credentials.
+var sensitiveHeaders = map[string]struct{}{
+	"Authorization":        {},
+	"Proxy-Authorization":  {},
+	"Cookie":               {},
+	"Set-Cookie":           {},
+	"X-Amz-Security-Token": {},
+}
+
+// loggingTransport is an http.RoundTripper that logs the requests.
+type loggingTransport struct {
+	base    http.RoundTripper
+	logger  *log.Logger
+	headers bool
+	redact  bool
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	if t.headers {
+		t.logHeader("> ", req.Header)
+	}
+	resp, err := t.base.RoundTrip(req)
+	duration := time.Since(start)
+	if err != nil {
+		t.logger.Printf("%s %s: %v (%s)", req.Method, req.URL.Redacted(), err, duration)
+		return nil, err
+	}
+	t.logger.Printf("%s %s: %s (%s)", req.Method, req.URL.Redacted(), resp.Status, duration)
+	if t.headers {
+		t.logHeader("< ", resp.Header)
+	}
+	return resp, nil
+}
+
+func (t *loggingTransport) logHeader(prefix string, header http.Header) {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := strings.Join(header[k], ", ")
+		if _, ok := sensitiveHeaders[k]; ok && t.redact {
+			v = redactHeader(v)
+		}
+		t.logger.Printf("%s%s: %s", prefix, k, v)
+	}
+}
+
+// redactHeader hides the credentials, but keeps the authentication scheme for debugging.
+func redactHeader(v string) string {
+	if idx := strings.IndexRune(v, ' '); idx >= 0 {
+		return v[:idx] + " REDACTED"
+	}
+	return "REDACTED"
+}
@@ -0,0 +1,38 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWithLogger(t *testing.T) {
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testManifest))
+	}))
+	var buf bytes.Buffer
+	c.client.Transport = &loggingTransport{
+		base:    c.client.Transport,
+		logger:  log.New(&buf, "", 0),
+		headers: true,
+		redact:  true,
+	}
+	c.staticTokens = map[string]string{host: "secret"}
+
+	if _, err := c.GetManifests(context.Background(), host+"/foo:latest"); err != nil {
+		t.Fatal(err)
+	}
+	logs := buf.String()
+	if !strings.Contains(logs, "GET https://registry.example.com/v2/foo/manifests/latest: 200 OK") {
+		t.Errorf("the request is not logged: %s", logs)
+	}
+	if !strings.Contains(logs, "> Authorization: Bearer REDACTED") {
+		t.Errorf("the header is not logged: %s", logs)
+	}
+	if strings.Contains(logs, "secret") {
+		t.Errorf("the credentials are logged: %s", logs)
+	}
+}
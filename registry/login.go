@@ -0,0 +1,129 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrInvalidCredentials is returned when the registry rejects the credentials.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// ErrInsufficientScope is returned when the credentials are valid but don't grant the required access.
+var ErrInsufficientScope = errors.New("insufficient scope")
+
+// LoginOption is an option for Login.
+type LoginOption func(o *loginOptions)
+
+type loginOptions struct {
+	validate   bool
+	repository string
+}
+
+// WithValidation validates the credentials immediately by requesting a token.
+// If repo is not empty, it also checks that the credentials grant the pull access to the repository.
+// It is useful for Docker Hub personal access tokens and organization access tokens,
+// which may lack the pull scope of the repository.
+func WithValidation(repo string) LoginOption {
+	return func(o *loginOptions) {
+		o.validate = true
+		o.repository = repo
+	}
+}
+
+// normalizeHost converts the aliases of Docker Hub into the host of its registry.
+func normalizeHost(host string) string {
+	host = strings.ToLower(host)
+	switch host {
+	case "docker.io", "index.docker.io":
+		return dockerHubHost
+	}
+	return host
+}
+
+func (c *Client) validateCredentials(ctx context.Context, host, username, password, repo string) error {
+	caps, err := c.Ping(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to ping %s: %w", host, err)
+	}
+
+	switch caps.AuthScheme {
+	case AuthSchemeBearer:
+		scope := ""
+		if repo != "" {
+			scope = "repository:" + repo + ":pull"
+		}
+		resp, err := c.requestToken(ctx, caps.Realm, caps.Service, scope, username, password)
+		if err != nil {
+			var repoErr *registryError
+			if errors.As(err, &repoErr) && (repoErr.statusCode == http.StatusUnauthorized || repoErr.statusCode == http.StatusForbidden) {
+				return fmt.Errorf("%w for %s", ErrInvalidCredentials, host)
+			}
+			return fmt.Errorf("failed to validate the credentials for %s: %w", host, err)
+		}
+		if repo != "" {
+			if actions, ok := tokenActions(resp.token(), "repository", repo); ok && !contains(actions, "pull") {
+				return fmt.Errorf("%w: the credentials for %s don't grant the pull access to %s", ErrInsufficientScope, host, repo)
+			}
+		}
+	case AuthSchemeBasic:
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(host, "/v2/"), nil)
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(username, password)
+		resp, err := c.do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			return fmt.Errorf("%w for %s", ErrInvalidCredentials, host)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("failed to validate the credentials for %s: unexpected status code: %d", host, resp.StatusCode)
+		}
+	}
+	return nil
+}
+
+// tokenActions returns the actions granted to the resource by the token.
+// ok is false if the token is not a JWT, e.g. an opaque token.
+func tokenActions(token, typ, name string) (actions []string, ok bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	payload, err := decodeBase64URL(parts[1])
+	if err != nil {
+		return nil, false
+	}
+	var claims struct {
+		Access *[]struct {
+			Type    string   `json:"type"`
+			Name    string   `json:"name"`
+			Actions []string `json:"actions"`
+		} `json:"access"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Access == nil {
+		return nil, false
+	}
+	for _, access := range *claims.Access {
+		if access.Type == typ && access.Name == name {
+			actions = append(actions, access.Actions...)
+		}
+	}
+	return actions, true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func fakeJWT(access string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"access":%s}`, access)))
+	return header + "." + payload + ".signature"
+}
+
+func TestLogin_WithValidation(t *testing.T) {
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			username, password, _ := r.BasicAuth()
+			switch {
+			case username == "user" && password == "pat":
+				fmt.Fprintf(w, `{"token":%q}`, fakeJWT(`[{"type":"repository","name":"foo/bar","actions":["pull"]}]`))
+			case username == "user" && password == "no-scope":
+				fmt.Fprintf(w, `{"token":%q}`, fakeJWT(`[]`))
+			default:
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+			return
+		}
+		w.Header().Set("Www-Authenticate", `Bearer realm="https://registry.example.com/token",service="test"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+
+	ctx := context.Background()
+	if err := c.Login(ctx, host, "user", "pat", WithValidation("foo/bar")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := c.Login(ctx, host, "user", "wrong", WithValidation("foo/bar")); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("want ErrInvalidCredentials, got %v", err)
+	}
+	if err := c.Login(ctx, host, "user", "no-scope", WithValidation("foo/bar")); !errors.Is(err, ErrInsufficientScope) {
+		t.Errorf("want ErrInsufficientScope, got %v", err)
+	}
+
+	// the invalid credentials are not stored.
+	username, password, err := c.getCredentials(ctx, host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if username != "user" || password != "pat" {
+		t.Errorf("unexpected credentials: %q, %q", username, password)
+	}
+}
+
+func TestNormalizeHost(t *testing.T) {
+	for _, host := range []string{"docker.io", "index.docker.io", "Registry-1.Docker.io"} {
+		if got := normalizeHost(host); got != dockerHubHost {
+			t.Errorf("%s: want %s, got %s", host, dockerHubHost, got)
+		}
+	}
+	if got := normalizeHost("ghcr.io"); got != "ghcr.io" {
+		t.Errorf("want ghcr.io, got %s", got)
+	}
+}
@@ -0,0 +1,37 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+)
+
+// WithMirror configures the mirrors of the host, e.g. mirror.gcr.io or a pull-through cache for Docker Hub.
+// The requests to the host are sent to the mirrors in order first,
+// and fall back to the next mirror or the host itself when the mirror doesn't have the content or fails.
+// The mirrors must serve the repositories at the same paths as the host.
+func WithMirror(host string, mirrors ...string) Option {
+	return func(c *Client) {
+		if c.mirrors == nil {
+			c.mirrors = make(map[string][]string)
+		}
+		host = normalizeHost(host)
+		for _, mirror := range mirrors {
+			c.mirrors[host] = append(c.mirrors[host], normalizeHost(mirror))
+		}
+	}
+}
+
+// getFromMirrors tries the mirrors of the host.
+// It returns nil if no mirror has the content.
+func (c *Client) getFromMirrors(ctx context.Context, host, path, scope string, header http.Header) *http.Response {
+	for _, mirror := range c.mirrors[host] {
+		resp, err := c.getFromHost(ctx, mirror, path, scope, header)
+		if err == nil {
+			return resp
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+	return nil
+}
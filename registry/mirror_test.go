@@ -0,0 +1,34 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestWithMirror(t *testing.T) {
+	var served []string
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host == "mirror.example.com" && r.URL.Path != "/v2/cached/manifests/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		served = append(served, r.Host)
+		w.Write([]byte(testManifest))
+	}))
+	WithMirror(host, "mirror.example.com")(c)
+
+	// the mirror has the image.
+	if _, err := c.GetManifests(context.Background(), host+"/cached:latest"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the mirror doesn't have the image, fall back to the origin.
+	if _, err := c.GetManifests(context.Background(), host+"/uncached:latest"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(served) != 2 || served[0] != "mirror.example.com" || served[1] != host {
+		t.Errorf("unexpected hosts: %v", served)
+	}
+}
@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// tagWildcard is the wildcard in the tag patterns, which matches any sequence of characters.
+const tagWildcard = "*"
+
+// constraintChars are the characters that appear in the version constraints but not in the tags.
+const constraintChars = "<>=!~^|, "
+
+// IsTagPattern reports whether the tag of the image is a pattern:
+// a tag with wildcards, e.g. "alpine:3.*" or "debian:*-slim",
+// or a version constraint, e.g. "golang:>=1.21 <1.23".
+func IsTagPattern(image string) bool {
+	ref, _ := splitReference(image)
+	return ref.Digest == "" && (strings.Contains(ref.Tag, tagWildcard) || isTagConstraint(ref.Tag))
+}
+
+func isTagConstraint(tag string) bool {
+	return strings.ContainsAny(tag, constraintChars)
+}
+
+// ParseReferencePattern parses the image reference whose tag may have wildcards.
+// The tag of the result is the pattern.
+func ParseReferencePattern(image string) (*Reference, error) {
+	if !IsTagPattern(image) {
+		return ParseReference(image)
+	}
+
+	idx := strings.LastIndex(image, ":")
+	name, pattern := image[:idx], image[idx+1:]
+	if isTagConstraint(pattern) {
+		if _, err := ParseConstraint(pattern); err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidReference, image, err)
+		}
+		if _, err := ParseReference(name); err != nil {
+			return nil, err
+		}
+		ref, _ := splitReference(image)
+		return ref, nil
+	}
+
+	// validate the reference with the wildcards replaced by a valid character.
+	if _, err := ParseReference(name + ":" + strings.ReplaceAll(pattern, tagWildcard, "x")); err != nil {
+		return nil, fmt.Errorf("%w: %q: invalid tag pattern", ErrInvalidReference, image)
+	}
+	ref, _ := splitReference(image)
+	return ref, nil
+}
+
+// MatchTag reports whether the tag matches the pattern.
+// If the pattern is a version constraint, only the versions without suffixes match,
+// e.g. ">=1.21" matches "1.21.5" but not "1.21.5-alpine".
+func MatchTag(pattern, tag string) bool {
+	if isTagConstraint(pattern) {
+		c, err := ParseConstraint(pattern)
+		if err != nil {
+			return false
+		}
+		v, err := ParseVersion(tag)
+		return err == nil && v.Suffix == "" && c.Check(v)
+	}
+
+	// the tags don't contain "/", so path.Match works as a simple glob.
+	ok, err := path.Match(pattern, tag)
+	return err == nil && ok
+}
+
+// ExpandTagPattern returns the images of the tags that match the tag pattern of the image, sorted by version.
+// The name part of the image is kept as is, e.g. "alpine:3.*" is expanded to "alpine:3.17", "alpine:3.18" and so on.
+func ExpandTagPattern(image string, tags []string) []string {
+	idx := strings.LastIndex(image, ":")
+	name, pattern := image[:idx], image[idx+1:]
+	var matched []string
+	for _, tag := range tags {
+		if MatchTag(pattern, tag) {
+			matched = append(matched, tag)
+		}
+	}
+	SortTags(matched)
+
+	images := make([]string, 0, len(matched))
+	for _, tag := range matched {
+		images = append(images, name+":"+tag)
+	}
+	return images
+}
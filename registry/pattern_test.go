@@ -0,0 +1,70 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseReferencePattern(t *testing.T) {
+	ref, err := ParseReferencePattern("alpine:3.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Reference{Host: "registry-1.docker.io", Repository: "library/alpine", Tag: "3.*"}
+	if !reflect.DeepEqual(ref, want) {
+		t.Errorf("want %#v, got %#v", want, ref)
+	}
+
+	ref, err = ParseReferencePattern("golang:>=1.21 <1.23")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = &Reference{Host: "registry-1.docker.io", Repository: "library/golang", Tag: ">=1.21 <1.23"}
+	if !reflect.DeepEqual(ref, want) {
+		t.Errorf("want %#v, got %#v", want, ref)
+	}
+
+	for _, image := range []string{"alpine:3.[0-9]", "alpine:*/foo", "ALPINE:*", "alpine:-*", "golang:>=foo", "GOLANG:>=1.21"} {
+		if _, err := ParseReferencePattern(image); err == nil {
+			t.Errorf("%q: want error, got nil", image)
+		}
+	}
+}
+
+func TestIsTagPattern(t *testing.T) {
+	tests := []struct {
+		image string
+		want  bool
+	}{
+		{"alpine:3.*", true},
+		{"debian:*-slim", true},
+		{"localhost:5000/foo:*", true},
+		{"golang:>=1.21 <1.23", true},
+		{"golang:~1.21", true},
+		{"alpine:3.17", false},
+		{"localhost:5000/foo", false},
+	}
+	for _, tt := range tests {
+		if got := IsTagPattern(tt.image); got != tt.want {
+			t.Errorf("%s: want %t, got %t", tt.image, tt.want, got)
+		}
+	}
+}
+
+func TestExpandTagPattern(t *testing.T) {
+	tags := []string{"3.17", "3.18", "edge", "bookworm-slim", "bookworm", "latest"}
+	got := ExpandTagPattern("alpine:3.*", tags)
+	if want := []string{"alpine:3.17", "alpine:3.18"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	got = ExpandTagPattern("localhost:5000/debian:*-slim", tags)
+	if want := []string{"localhost:5000/debian:bookworm-slim"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	goTags := []string{"1.23.0", "1.20.14", "1.21.0", "1.22.1-alpine", "1.22.1", "1.21", "latest"}
+	got = ExpandTagPattern("golang:>=1.21 <1.23", goTags)
+	if want := []string{"golang:1.21", "golang:1.21.0", "golang:1.22.1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
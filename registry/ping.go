@@ -0,0 +1,95 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Authentication schemes of Docker registries.
+const (
+	AuthSchemeBearer = "bearer"
+	AuthSchemeBasic  = "basic"
+)
+
+// Capabilities describes what the registry supports.
+type Capabilities struct {
+	// APIVersion is the value of the Docker-Distribution-API-Version header, e.g. "registry/2.0".
+	APIVersion string
+
+	// AuthScheme is the authentication scheme that the registry requires.
+	// It is AuthSchemeBearer, AuthSchemeBasic, or empty if the registry doesn't require authentication.
+	AuthScheme string
+
+	// Realm and Service are the parameters of the authentication challenge.
+	Realm   string
+	Service string
+
+	// Product is the product of the registry that needs HostConfig, e.g. ProductArtifactory or ProductNexus.
+	// It is empty if the registry is not known to deviate from the distribution spec.
+	Product string
+}
+
+// Ping checks the /v2/ endpoint of the registry, and returns its capabilities.
+// The result is cached, and subsequent requests use it to pick the authentication strategy up front.
+func (c *Client) Ping(ctx context.Context, host string) (*Capabilities, error) {
+	resp, err := c.doGet(ctx, host, "/v2/", "", nil)
+	if err == nil {
+		resp.Body.Close()
+		return c.updateCapabilities(host, resp.Header)
+	}
+
+	var repoErr *registryError
+	if !errors.As(err, &repoErr) {
+		return nil, err
+	}
+	if repoErr.statusCode != http.StatusUnauthorized {
+		return nil, err
+	}
+	return c.updateCapabilities(host, repoErr.header)
+}
+
+// updateCapabilities records the capabilities of the registry from the response header.
+func (c *Client) updateCapabilities(host string, header http.Header) (*Capabilities, error) {
+	host = strings.ToLower(host)
+
+	caps := &Capabilities{
+		APIVersion: header.Get("Docker-Distribution-API-Version"),
+		Product:    detectProduct(header),
+	}
+	if h := header.Get("Www-Authenticate"); h != "" {
+		scheme, params, err := parseWWWAuthenticate(h)
+		if err != nil {
+			return nil, err
+		}
+		caps.AuthScheme = scheme
+		caps.Realm = params["realm"]
+		caps.Service = params["service"]
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.capabilities == nil {
+		c.capabilities = make(map[string]*Capabilities)
+	}
+	if old := c.capabilities[host]; old != nil {
+		if caps.APIVersion == "" {
+			caps.APIVersion = old.APIVersion
+		}
+		if caps.Product == "" {
+			caps.Product = old.Product
+		}
+	}
+	c.capabilities[host] = caps
+
+	ret := *caps
+	return &ret, nil
+}
+
+func (c *Client) getCapabilities(host string) *Capabilities {
+	host = strings.ToLower(host)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.capabilities[host]
+}
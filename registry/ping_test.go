@@ -0,0 +1,86 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	var tokenRequests int
+	var ts *httptest.Server
+	ts = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			tokenRequests++
+			if got := r.URL.Query().Get("scope"); got != "repository:foo/bar:pull" {
+				t.Errorf("unexpected scope: %q", got)
+			}
+			fmt.Fprint(w, `{"token":"secret"}`)
+			return
+		}
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("Www-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test"`, ts.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(testManifest))
+	}))
+	defer ts.Close()
+
+	c := New()
+	c.client = ts.Client()
+	host := strings.TrimPrefix(ts.URL, "https://")
+
+	caps, err := c.Ping(context.Background(), host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caps.APIVersion != "registry/2.0" {
+		t.Errorf("unexpected api version: %q", caps.APIVersion)
+	}
+	if caps.AuthScheme != AuthSchemeBearer {
+		t.Errorf("unexpected auth scheme: %q", caps.AuthScheme)
+	}
+	if caps.Realm != ts.URL+"/token" || caps.Service != "test" {
+		t.Errorf("unexpected challenge: %#v", caps)
+	}
+
+	// the token is fetched up front, with the scope of the repository.
+	if _, err := c.getManifests(context.Background(), host, "foo/bar", "latest"); err != nil {
+		t.Fatal(err)
+	}
+	if tokenRequests != 1 {
+		t.Errorf("want 1 token request, got %d", tokenRequests)
+	}
+}
+
+func TestPing_Basic(t *testing.T) {
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "user" || password != "pass" {
+			w.Header().Set("Www-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(testManifest))
+	}))
+	if err := c.Login(context.Background(), host, "user", "pass"); err != nil {
+		t.Fatal(err)
+	}
+
+	caps, err := c.Ping(context.Background(), host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caps.AuthScheme != AuthSchemeBasic {
+		t.Errorf("unexpected auth scheme: %q", caps.AuthScheme)
+	}
+	if _, err := c.getManifests(context.Background(), host, "foo/bar", "latest"); err != nil {
+		t.Fatal(err)
+	}
+}
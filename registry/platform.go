@@ -0,0 +1,115 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+)
+
+// attestationReferenceAnnotation is the annotation of the attestation manifests
+// that refers to the digest of the image manifest they describe.
+const attestationReferenceAnnotation = "vnd.docker.reference.digest"
+
+// ParsePlatform parses the platform in the form of os/arch[/variant][:os.version], e.g. "linux/arm/v7" or "windows/amd64:10.0.20348".
+func ParsePlatform(s string) (*Platform, error) {
+	var osVersion string
+	if idx := strings.IndexByte(s, ':'); idx >= 0 {
+		osVersion = s[idx+1:]
+		if osVersion == "" {
+			return nil, fmt.Errorf("invalid platform %q: empty os.version", s)
+		}
+		s = s[:idx]
+	}
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("invalid platform %q: want os/arch[/variant]", s)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("invalid platform %q: want os/arch[/variant]", s)
+		}
+	}
+	p := &Platform{
+		OS:           strings.ToLower(parts[0]),
+		Architecture: strings.ToLower(parts[1]),
+	}
+	if len(parts) == 3 {
+		p.Variant = strings.ToLower(parts[2])
+	}
+	p.OSVersion = osVersion
+	return p, nil
+}
+
+// defaultVariants are the variants assumed for the platforms without them, as containerd does.
+var defaultVariants = map[string]string{
+	"arm":   "v7",
+	"arm64": "v8",
+}
+
+// variant returns the variant of the platform, or the default one of the architecture.
+func (p *Platform) variant() string {
+	if p.Variant != "" {
+		return strings.ToLower(p.Variant)
+	}
+	return defaultVariants[p.Architecture]
+}
+
+// Matches reports whether the platform matches the filter.
+// The variant is compared only if the filter has it, so "linux/arm64" matches "linux/arm64/v8".
+// The platforms without variants have the default ones, so "linux/arm/v7" matches "linux/arm" but "linux/arm/v6" doesn't.
+// The os.version of the filter is a prefix of the version components, so "windows/amd64:10.0.20348" matches "10.0.20348.2113".
+func (p *Platform) Matches(filter *Platform) bool {
+	if p == nil || filter == nil {
+		return false
+	}
+	if p.OS != filter.OS || p.Architecture != filter.Architecture {
+		return false
+	}
+	if filter.OSVersion != "" && p.OSVersion != filter.OSVersion && !strings.HasPrefix(p.OSVersion, filter.OSVersion+".") {
+		return false
+	}
+	return filter.Variant == "" || p.variant() == filter.variant()
+}
+
+// HasPlatform reports whether the manifest list has a manifest matching the filter.
+// The manifests that are not lists are assumed to have any platforms.
+func HasPlatform(m *Manifests, filter *Platform) bool {
+	if m == nil || len(m.Manifests) == 0 {
+		return true
+	}
+	for _, manifest := range m.Manifests {
+		if manifest.Platform.Matches(filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterPlatforms returns a copy of the manifest list with only the manifests of the platforms matching any of the filters.
+// The attestations are kept if the manifests they refer to are kept.
+// The manifests that are not lists are returned as is, because they have no platforms to choose.
+func FilterPlatforms(m *Manifests, filters []*Platform) *Manifests {
+	if m == nil || len(m.Manifests) == 0 || len(filters) == 0 {
+		return m
+	}
+
+	kept := map[string]bool{}
+	var manifests []*Manifest
+	for _, manifest := range m.Manifests {
+		for _, filter := range filters {
+			if manifest.Platform.Matches(filter) {
+				kept[manifest.Digest] = true
+				manifests = append(manifests, manifest)
+				break
+			}
+		}
+	}
+	for _, manifest := range m.Manifests {
+		if ref, ok := manifest.Annotations[attestationReferenceAnnotation]; ok && kept[ref] {
+			manifests = append(manifests, manifest)
+		}
+	}
+
+	ret := *m
+	ret.Manifests = manifests
+	return &ret
+}
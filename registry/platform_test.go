@@ -0,0 +1,103 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePlatform(t *testing.T) {
+	p, err := ParsePlatform("linux/arm/v7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&Platform{OS: "linux", Architecture: "arm", Variant: "v7"}); !reflect.DeepEqual(p, want) {
+		t.Errorf("want %#v, got %#v", want, p)
+	}
+
+	p, err = ParsePlatform("windows/amd64:10.0.20348")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.20348"}); !reflect.DeepEqual(p, want) {
+		t.Errorf("want %#v, got %#v", want, p)
+	}
+
+	for _, s := range []string{"linux", "linux/", "linux/arm/v7/extra", "/amd64", "windows/amd64:"} {
+		if _, err := ParsePlatform(s); err == nil {
+			t.Errorf("%q: want error, got nil", s)
+		}
+	}
+}
+
+func TestFilterPlatforms(t *testing.T) {
+	m := &Manifests{
+		MediaType: "application/vnd.oci.image.index.v1+json",
+		Manifests: []*Manifest{
+			{Digest: "sha256:amd64", Platform: &Platform{OS: "linux", Architecture: "amd64"}},
+			{Digest: "sha256:arm64", Platform: &Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}},
+			{Digest: "sha256:riscv64", Platform: &Platform{OS: "linux", Architecture: "riscv64"}},
+			{
+				Digest:      "sha256:amd64-attestation",
+				Platform:    &Platform{OS: "unknown", Architecture: "unknown"},
+				Annotations: map[string]string{attestationReferenceAnnotation: "sha256:amd64"},
+			},
+			{
+				Digest:      "sha256:riscv64-attestation",
+				Platform:    &Platform{OS: "unknown", Architecture: "unknown"},
+				Annotations: map[string]string{attestationReferenceAnnotation: "sha256:riscv64"},
+			},
+		},
+	}
+	amd64, _ := ParsePlatform("linux/amd64")
+	arm64, _ := ParsePlatform("linux/arm64")
+	got := FilterPlatforms(m, []*Platform{amd64, arm64})
+
+	var digests []string
+	for _, manifest := range got.Manifests {
+		digests = append(digests, manifest.Digest)
+	}
+	if want := []string{"sha256:amd64", "sha256:arm64", "sha256:amd64-attestation"}; !reflect.DeepEqual(digests, want) {
+		t.Errorf("want %v, got %v", want, digests)
+	}
+	if len(m.Manifests) != 5 {
+		t.Error("the original manifests are modified")
+	}
+
+	// the riscv64 rebuild is ignored.
+	rebuilt := *m
+	rebuilt.Manifests = append([]*Manifest{}, m.Manifests...)
+	rebuilt.Manifests[2] = &Manifest{Digest: "sha256:riscv64-new", Platform: &Platform{OS: "linux", Architecture: "riscv64"}}
+	if !reflect.DeepEqual(FilterPlatforms(&rebuilt, []*Platform{amd64, arm64}), got) {
+		t.Error("want the same filtered manifests")
+	}
+}
+
+func TestPlatformMatches(t *testing.T) {
+	tests := []struct {
+		platform Platform
+		filter   string
+		want     bool
+	}{
+		{Platform{OS: "linux", Architecture: "arm", Variant: "v7"}, "linux/arm/v7", true},
+		{Platform{OS: "linux", Architecture: "arm", Variant: "v6"}, "linux/arm/v7", false},
+		{Platform{OS: "linux", Architecture: "arm", Variant: "v6"}, "linux/arm", true},
+		{Platform{OS: "linux", Architecture: "arm"}, "linux/arm/v7", true},
+		{Platform{OS: "linux", Architecture: "arm"}, "linux/arm/v6", false},
+		{Platform{OS: "linux", Architecture: "arm64"}, "linux/arm64/v8", true},
+		{Platform{OS: "linux", Architecture: "amd64"}, "linux/arm64", false},
+		{Platform{OS: "windows", Architecture: "amd64"}, "linux/amd64", false},
+		{Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.20348.2113"}, "windows/amd64", true},
+		{Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.20348.2113"}, "windows/amd64:10.0.20348", true},
+		{Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.17763.5122"}, "windows/amd64:10.0.20348", false},
+		{Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.203481.1"}, "windows/amd64:10.0.20348", false},
+	}
+	for _, tt := range tests {
+		filter, err := ParsePlatform(tt.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tt.platform.Matches(filter); got != tt.want {
+			t.Errorf("%s matches %s: want %t, got %t", tt.platform.String(), tt.filter, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const quayHost = "quay.io"
+
+// QuayTag is the metadata of a tag, provided by the Quay API.
+type QuayTag struct {
+	Name           string
+	ManifestDigest string
+	LastModified   time.Time
+
+	// Expiration is the time when the tag will be removed.
+	// It is zero if the tag doesn't expire.
+	Expiration time.Time
+}
+
+// GetQuayTag gets the metadata of the tag from the Quay API.
+func (c *Client) GetQuayTag(ctx context.Context, image string) (*QuayTag, error) {
+	host, repo, tag := GetRepository(image)
+	if host != quayHost {
+		return nil, fmt.Errorf("%s is not an image on Quay", image)
+	}
+
+	endpoint := c.quayEndpoint
+	if endpoint == "" {
+		endpoint = "https://" + quayHost
+	}
+	q := url.Values{}
+	q.Set("specificTag", tag)
+	q.Set("onlyActiveTags", "true")
+	u := fmt.Sprintf("%s/api/v1/repository/%s/tag/?%s", endpoint, repo, q.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &registryError{
+			statusCode: resp.StatusCode,
+			header:     resp.Header,
+		}
+	}
+
+	var body struct {
+		Tags []struct {
+			Name           string `json:"name"`
+			ManifestDigest string `json:"manifest_digest"`
+			LastModified   string `json:"last_modified"`
+			Expiration     string `json:"expiration"`
+		} `json:"tags"`
+	}
+	dec := json.NewDecoder(resp.Body)
+	if err := dec.Decode(&body); err != nil {
+		return nil, err
+	}
+	for _, t := range body.Tags {
+		if t.Name != tag {
+			continue
+		}
+		ret := &QuayTag{
+			Name:           t.Name,
+			ManifestDigest: t.ManifestDigest,
+		}
+		if t.LastModified != "" {
+			if ret.LastModified, err = time.Parse(time.RFC1123Z, t.LastModified); err != nil {
+				return nil, fmt.Errorf("failed to parse last_modified: %w", err)
+			}
+		}
+		if t.Expiration != "" {
+			if ret.Expiration, err = time.Parse(time.RFC1123Z, t.Expiration); err != nil {
+				return nil, fmt.Errorf("failed to parse expiration: %w", err)
+			}
+		}
+		return ret, nil
+	}
+	return nil, fmt.Errorf("tag not found: %s", image)
+}
@@ -0,0 +1,48 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestGetQuayTag(t *testing.T) {
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/repository/foo/bar/tag/" || r.URL.Query().Get("specificTag") != "v1" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{
+			"tags": [
+				{
+					"name": "v1",
+					"manifest_digest": "sha256:f71a5f071694a785e064f05fed657bf8277f1b2113a8ed70c90ad486d6ee54dc",
+					"last_modified": "Mon, 02 Jan 2023 15:04:05 -0000",
+					"expiration": "Wed, 01 Feb 2023 00:00:00 -0000"
+				}
+			],
+			"page": 1,
+			"has_additional": false
+		}`))
+	}))
+	c.quayEndpoint = "https://" + host
+
+	tag, err := c.GetQuayTag(context.Background(), "quay.io/foo/bar:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.ManifestDigest != "sha256:f71a5f071694a785e064f05fed657bf8277f1b2113a8ed70c90ad486d6ee54dc" {
+		t.Errorf("unexpected digest: %q", tag.ManifestDigest)
+	}
+	if want := time.Date(2023, time.January, 2, 15, 4, 5, 0, time.UTC); !tag.LastModified.Equal(want) {
+		t.Errorf("want %s, got %s", want, tag.LastModified)
+	}
+	if want := time.Date(2023, time.February, 1, 0, 0, 0, 0, time.UTC); !tag.Expiration.Equal(want) {
+		t.Errorf("want %s, got %s", want, tag.Expiration)
+	}
+
+	if _, err := c.GetQuayTag(context.Background(), "quay.io/foo/bar:v2"); err == nil {
+		t.Error("want error for unknown tags, got nil")
+	}
+}
@@ -0,0 +1,25 @@
+package registry
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// maxRedirects is the maximum number of redirects followed, which is the same as the default of net/http.
+const maxRedirects = 10
+
+// checkRedirect is the redirect policy of the client.
+// Blobs and manifests are often redirected to CDNs, e.g. Amazon S3 and Cloudflare.
+// Sending the registry credentials to them leaks the credentials, and some CDNs reject the requests with unexpected Authorization.
+// net/http keeps the Authorization header for the subdomains, so it strips the credentials whenever the host changes.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return errors.New("stopped after 10 redirects")
+	}
+	if !strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+		req.Header.Del("Authorization")
+		req.Header.Del("Cookie")
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestCheckRedirect(t *testing.T) {
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Host {
+		case "registry.example.com":
+			if r.URL.Path == "/token" {
+				w.Write([]byte(`{"token":"secret"}`))
+				return
+			}
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				w.Header().Set("Www-Authenticate", `Bearer realm="https://registry.example.com/token",service="test"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			switch r.URL.Path {
+			case "/v2/foo/manifests/latest":
+				// redirect to the same host keeps the credentials.
+				http.Redirect(w, r, "/v2/foo/manifests/redirected", http.StatusTemporaryRedirect)
+			case "/v2/foo/manifests/redirected":
+				// net/http keeps the credentials for the subdomains, but the client doesn't.
+				http.Redirect(w, r, "https://cdn.registry.example.com/manifest", http.StatusTemporaryRedirect)
+			default:
+				http.NotFound(w, r)
+			}
+		case "cdn.registry.example.com":
+			if r.Header.Get("Authorization") != "" {
+				t.Error("the credentials leaked to the CDN")
+			}
+			w.Write([]byte(testManifest))
+		}
+	}))
+
+	if _, err := c.GetManifests(context.Background(), host+"/foo:latest"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,141 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidReference is returned when the image reference doesn't conform to the distribution reference grammar.
+var ErrInvalidReference = errors.New("invalid reference")
+
+// Reference is a parsed image reference.
+type Reference struct {
+	// Host is the registry host, including the port if any. e.g. "registry-1.docker.io", "localhost:5000"
+	Host string
+
+	// Repository is the repository name. e.g. "library/alpine"
+	Repository string
+
+	// Tag is the tag of the image. It may be empty if Digest is given.
+	Tag string
+
+	// Digest is the digest of the image. e.g. "sha256:..."
+	Digest string
+}
+
+// the grammar of the references.
+// See https://github.com/distribution/distribution/blob/main/reference/reference.go
+var (
+	domainRegexp        = regexp.MustCompile(`^(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?$`)
+	pathComponentRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*$`)
+	tagRegexp           = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	digestRegexp        = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}$`)
+)
+
+// nameTotalLengthMax is the maximum length of the name, including the host.
+const nameTotalLengthMax = 255
+
+// ParseReference parses the image reference, e.g. "alpine:3.17", "ghcr.io/foo/bar@sha256:...", "localhost:5000/foo".
+// The images without a host are on Docker Hub, and the tag defaults to "latest" if neither tag nor digest is given.
+// The aliases of Docker Hub, docker.io and index.docker.io, are normalized to registry-1.docker.io,
+// so "alpine", "docker.io/alpine" and "index.docker.io/library/alpine" have the same result.
+func ParseReference(image string) (*Reference, error) {
+	ref, name := splitReference(image)
+	if ref.Host == "" || ref.Repository == "" {
+		return nil, fmt.Errorf("%w: %q: the repository name is empty", ErrInvalidReference, image)
+	}
+	if len(name) > nameTotalLengthMax {
+		return nil, fmt.Errorf("%w: %q: the repository name must not be more than %d characters", ErrInvalidReference, image, nameTotalLengthMax)
+	}
+	if !domainRegexp.MatchString(ref.Host) {
+		return nil, fmt.Errorf("%w: %q: invalid host %q", ErrInvalidReference, image, ref.Host)
+	}
+	for _, component := range strings.Split(ref.Repository, "/") {
+		if !pathComponentRegexp.MatchString(component) {
+			return nil, fmt.Errorf("%w: %q: invalid repository name %q", ErrInvalidReference, image, ref.Repository)
+		}
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		return nil, fmt.Errorf("%w: %q: the tag is empty", ErrInvalidReference, image)
+	}
+	if ref.Tag != "" && !tagRegexp.MatchString(ref.Tag) {
+		return nil, fmt.Errorf("%w: %q: invalid tag %q", ErrInvalidReference, image, ref.Tag)
+	}
+	if ref.Digest != "" && !digestRegexp.MatchString(ref.Digest) {
+		return nil, fmt.Errorf("%w: %q: invalid digest %q", ErrInvalidReference, image, ref.Digest)
+	}
+	return ref, nil
+}
+
+// splitReference splits the image reference without validation.
+// It also returns the name part of the reference, which is used for checking the length.
+func splitReference(image string) (*Reference, string) {
+	ref := &Reference{}
+	if idx := strings.IndexRune(image, '@'); idx >= 0 {
+		ref.Digest = image[idx+1:]
+		image = image[:idx]
+	}
+
+	// the colon after the last slash is the separator of the tag.
+	// the colons before it are the separator of the port.
+	if idx := strings.LastIndexByte(image, ':'); idx >= 0 && idx > strings.LastIndexByte(image, '/') {
+		ref.Tag = image[idx+1:]
+		image = image[:idx]
+	} else if ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+
+	name := image
+	idx := strings.IndexRune(image, '/')
+	if idx >= 0 && isDomain(image[:idx]) {
+		// Docker registry v2 API
+		ref.Host = normalizeHost(image[:idx])
+		ref.Repository = image[idx+1:]
+		if ref.Host == dockerHubHost && !strings.ContainsRune(ref.Repository, '/') {
+			// docker.io/alpine is the same as docker.io/library/alpine.
+			ref.Repository = "library/" + ref.Repository
+		}
+	} else if idx >= 0 {
+		// Third party image on DockerHub
+		ref.Host = dockerHubHost
+		ref.Repository = image
+	} else {
+		// Official Image on DockerHub
+		ref.Host = dockerHubHost
+		ref.Repository = "library/" + image
+	}
+	return ref, name
+}
+
+// isDomain reports whether the first component of the name is a host.
+func isDomain(s string) bool {
+	return strings.ContainsAny(s, ".:") || s == "localhost"
+}
+
+// Reference returns the tag or the digest to get the manifest.
+// The digest takes precedence if both are given.
+func (ref *Reference) Reference() string {
+	if ref.Digest != "" {
+		return ref.Digest
+	}
+	return ref.Tag
+}
+
+// String returns the full reference including the host.
+func (ref *Reference) String() string {
+	var b strings.Builder
+	b.WriteString(ref.Host)
+	b.WriteByte('/')
+	b.WriteString(ref.Repository)
+	if ref.Tag != "" {
+		b.WriteByte(':')
+		b.WriteString(ref.Tag)
+	}
+	if ref.Digest != "" {
+		b.WriteByte('@')
+		b.WriteString(ref.Digest)
+	}
+	return b.String()
+}
@@ -0,0 +1,61 @@
+package registry
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	tests := []struct {
+		in   string
+		want Reference
+	}{
+		{"alpine", Reference{dockerHubHost, "library/alpine", "latest", ""}},
+		{"alpine:3.17", Reference{dockerHubHost, "library/alpine", "3.17", ""}},
+		{"amazon/aws-lambda-provided:al2", Reference{dockerHubHost, "amazon/aws-lambda-provided", "al2", ""}},
+		{"ghcr.io/foo/bar:v1", Reference{"ghcr.io", "foo/bar", "v1", ""}},
+		{"alpine@" + digest, Reference{dockerHubHost, "library/alpine", "", digest}},
+		{"alpine:3.17@" + digest, Reference{dockerHubHost, "library/alpine", "3.17", digest}},
+		{"registry.local:5000/foo", Reference{"registry.local:5000", "foo", "latest", ""}},
+		{"registry.local:5000/foo:v1", Reference{"registry.local:5000", "foo", "v1", ""}},
+		{"localhost/foo", Reference{"localhost", "foo", "latest", ""}},
+		{"docker.io/alpine", Reference{dockerHubHost, "library/alpine", "latest", ""}},
+		{"docker.io/library/alpine:3.17", Reference{dockerHubHost, "library/alpine", "3.17", ""}},
+		{"index.docker.io/library/alpine:3.17", Reference{dockerHubHost, "library/alpine", "3.17", ""}},
+		{"docker.io/amazon/aws-lambda-provided:al2", Reference{dockerHubHost, "amazon/aws-lambda-provided", "al2", ""}},
+		{"GHCR.io/foo/bar:v1", Reference{"ghcr.io", "foo/bar", "v1", ""}},
+		{"localhost:5000/foo/bar@" + digest, Reference{"localhost:5000", "foo/bar", "", digest}},
+	}
+	for _, tt := range tests {
+		got, err := ParseReference(tt.in)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.in, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("%q: want %+v, got %+v", tt.in, tt.want, *got)
+		}
+	}
+
+	for _, in := range []string{
+		"",
+		"Alpine",
+		"alpine:",
+		"alpine@sha256:xyz",
+		"foo//bar",
+		"registry.local:5000/",
+		"../../etc:tag",
+	} {
+		if _, err := ParseReference(in); !errors.Is(err, ErrInvalidReference) {
+			t.Errorf("%q: want ErrInvalidReference, got %v", in, err)
+		}
+	}
+}
+
+func TestGetRepository(t *testing.T) {
+	host, repo, tag := GetRepository("registry.local:5000/foo@sha256:abc")
+	if host != "registry.local:5000" || repo != "foo" || tag != "sha256:abc" {
+		t.Errorf("unexpected result: %s, %s, %s", host, repo, tag)
+	}
+}
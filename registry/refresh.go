@@ -0,0 +1,76 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// clientID is the client_id sent to the token endpoints.
+const clientID = "docker-image-update-checker"
+
+// identityTokenUsername is the username that indicates the password is an identity token.
+// It is the same convention as the docker credential helpers.
+const identityTokenUsername = "<token>"
+
+// WithRefreshToken sets the refresh token for the host.
+// The client gets tokens with the OAuth2 refresh_token grant instead of the username and the password.
+func WithRefreshToken(host, refreshToken string) Option {
+	return func(c *Client) {
+		c.setRefreshToken(host, refreshToken)
+	}
+}
+
+func (c *Client) getRefreshToken(host string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.refreshTokens[normalizeHost(host)]
+}
+
+func (c *Client) setRefreshToken(host, refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.refreshTokens == nil {
+		c.refreshTokens = make(map[string]string)
+	}
+	c.refreshTokens[normalizeHost(host)] = refreshToken
+}
+
+// refreshTokenGrant gets a new token with the refresh token.
+// See https://github.com/distribution/distribution/blob/main/docs/spec/auth/oauth.md
+func (c *Client) refreshTokenGrant(ctx context.Context, host, endpoint, service, scope, refreshToken string) (*tokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refreshToken)
+	form.Set("service", service)
+	form.Set("client_id", clientID)
+	if scope != "" {
+		form.Set("scope", scope)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.doTokenRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.RefreshToken != "" && resp.RefreshToken != refreshToken {
+		// the token endpoint may rotate the refresh token.
+		c.setRefreshToken(host, resp.RefreshToken)
+	}
+	return resp, nil
+}
+
+// isGrantUnsupported reports whether the token endpoint doesn't support the OAuth2 token flow.
+func isGrantUnsupported(err error) bool {
+	var repoErr *registryError
+	if !errors.As(err, &repoErr) {
+		return false
+	}
+	return repoErr.statusCode == http.StatusNotFound || repoErr.statusCode == http.StatusMethodNotAllowed
+}
@@ -0,0 +1,89 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestRefreshToken(t *testing.T) {
+	var passwordGrants, refreshGrants int
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			switch r.Method {
+			case http.MethodGet:
+				passwordGrants++
+				username, password, _ := r.BasicAuth()
+				if username != "user" || password != "pass" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				if r.URL.Query().Get("offline_token") != "true" {
+					t.Error("want offline_token=true")
+				}
+				fmt.Fprint(w, `{"token":"secret","refresh_token":"refresh"}`)
+			case http.MethodPost:
+				refreshGrants++
+				if err := r.ParseForm(); err != nil {
+					t.Fatal(err)
+				}
+				if r.PostForm.Get("grant_type") != "refresh_token" || r.PostForm.Get("refresh_token") != "refresh" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				if _, _, ok := r.BasicAuth(); ok {
+					t.Error("the password is sent on the token refresh")
+				}
+				fmt.Fprint(w, `{"access_token":"secret"}`)
+			}
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("Www-Authenticate", `Bearer realm="https://registry.example.com/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(testManifest))
+	}))
+	if err := c.Login(context.Background(), host, "user", "pass"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, repo := range []string{"foo", "bar", "baz"} {
+		if _, err := c.GetManifests(context.Background(), host+"/"+repo+":latest"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if passwordGrants != 1 {
+		t.Errorf("want 1 password grant, got %d", passwordGrants)
+	}
+	if refreshGrants != 2 {
+		t.Errorf("want 2 refresh grants, got %d", refreshGrants)
+	}
+}
+
+func TestRefreshToken_IdentityToken(t *testing.T) {
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.Method != http.MethodPost || r.FormValue("refresh_token") != "identity" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"access_token":"secret"}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("Www-Authenticate", `Bearer realm="https://registry.example.com/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(testManifest))
+	}))
+	if err := c.Login(context.Background(), host, identityTokenUsername, "identity"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetManifests(context.Background(), host+"/foo:latest"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -19,34 +21,97 @@ const dockerHubHost = "registry-1.docker.io"
 type Client struct {
 	client *http.Client
 
-	mu        sync.RWMutex
-	tokens    map[string]*registryToken
-	loginInfo map[string]*loginInfo
+	// the endpoints of the Docker Hub API and the Quay API. they are overwritten in tests.
+	hubEndpoint  string
+	quayEndpoint string
+
+	// logins are the credentials given by Login.
+	// They take precedence over the credentials of the providers.
+	logins    StaticCredentials
+	providers []CredentialProvider
+
+	// staticTokens are bearer tokens attached to requests directly, bypassing the challenge flow.
+	staticTokens map[string]string
+
+	hostConfigs map[string]HostConfig
+
+	// accept is the Accept header of the manifest requests. DefaultAccept is used if it is empty.
+	accept string
+
+	breaker *circuitBreaker
+
+	// hostConcurrency is the maximum number of requests to each host in GetManifestsBatch. 0 means no limit.
+	hostConcurrency int
+
+	stats     stats
+	userAgent string
+
+	logger     *log.Logger
+	logHeaders bool
+	logRedact  bool
+
+	// mirrors are the mirrors of the hosts, which are tried before the hosts.
+	mirrors map[string][]string
+
+	// tokenCachePath is the path to the file that persists the bearer tokens.
+	tokenCachePath string
+
+	mu            sync.RWMutex
+	tokens        map[string]*registryToken
+	refreshTokens map[string]string
+	capabilities  map[string]*Capabilities
 }
 
 type Manifests struct {
 	SchemaVersion int    `json:"schemaVersion"`
 	MediaType     string `json:"mediaType"`
 
+	// application/vnd.oci.image.index.v1+json and application/vnd.oci.image.manifest.v1+json
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Subject      *Descriptor       `json:"subject,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+
 	// application/vnd.docker.distribution.manifest.list.v2+json
 	Manifests []*Manifest `json:"manifests,omitempty"`
 
 	// application/vnd.docker.distribution.manifest.v2+json
 	Config *Config  `json:"config,omitempty"`
 	Layers []*Layer `json:"layers,omitempty"`
+
+	// application/vnd.docker.distribution.manifest.v1+prettyjws
+	Name         string     `json:"name,omitempty"`
+	Tag          string     `json:"tag,omitempty"`
+	Architecture string     `json:"architecture,omitempty"`
+	FSLayers     []*FSLayer `json:"fsLayers,omitempty"`
 }
 
 type Manifest struct {
-	Digest    string    `json:"digest"`
-	MediaType string    `json:"mediaType"`
-	Platform  *Platform `json:"platform"`
-	Size      int64     `json:"size"`
+	Digest       string            `json:"digest"`
+	MediaType    string            `json:"mediaType"`
+	Platform     *Platform         `json:"platform"`
+	Size         int64             `json:"size"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// Descriptor is a reference to a content, e.g. the subject of an OCI artifact.
+type Descriptor struct {
+	MediaType    string            `json:"mediaType"`
+	Digest       string            `json:"digest"`
+	Size         int64             `json:"size"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
 }
 
 type Platform struct {
 	Architecture string `json:"architecture"`
 	OS           string `json:"os"`
 	Variant      string `json:"variant,omitempty"`
+
+	// OSVersion is the version of the OS, e.g. "10.0.20348.2113" for Windows Server 2022.
+	// Windows images bump the revision on every patch Tuesday.
+	OSVersion  string   `json:"os.version,omitempty"`
+	OSFeatures []string `json:"os.features,omitempty"`
 }
 
 type Config struct {
@@ -61,15 +126,16 @@ type Layer struct {
 	Digest    string `json:"digest"`
 }
 
-type loginInfo struct {
-	username string
-	password string
+// FSLayer is a layer of the image manifest schema version 1.
+type FSLayer struct {
+	BlobSum string `json:"blobSum"`
 }
 
 type registryToken struct {
 	mu        sync.RWMutex
 	token     string
 	updatedAt time.Time
+	expiresAt time.Time
 }
 
 type registryError struct {
@@ -81,107 +147,274 @@ func (err *registryError) Error() string {
 	return fmt.Sprintf("unexpected status code: %d", err.statusCode)
 }
 
-func New() *Client {
-	return &Client{
-		client: &http.Client{},
+// ErrNotFound is returned when the registry responds 404 Not Found,
+// e.g. MANIFEST_UNKNOWN for the deleted tags, or NAME_UNKNOWN for the deleted repositories.
+var ErrNotFound = errors.New("not found")
+
+// Is reports whether the error matches the target.
+func (err *registryError) Is(target error) bool {
+	return target == ErrNotFound && err.statusCode == http.StatusNotFound
+}
+
+// Option is an option for New.
+type Option func(c *Client)
+
+// WithCredentialProvider adds the credential provider.
+// The providers are consulted in the order that they are added.
+func WithCredentialProvider(p CredentialProvider) Option {
+	return func(c *Client) {
+		c.providers = append(c.providers, p)
+	}
+}
+
+// WithStaticToken attaches the bearer token to requests for the host directly,
+// bypassing the challenge flow. It is for registries that issue long-lived tokens out of band.
+func WithStaticToken(host, token string) Option {
+	return func(c *Client) {
+		if c.staticTokens == nil {
+			c.staticTokens = make(map[string]string)
+		}
+		c.staticTokens[normalizeHost(host)] = token
+	}
+}
+
+func New(opts ...Option) *Client {
+	c := &Client{
+		client: &http.Client{
+			Transport:     newTransport(TransportConfig{}),
+			CheckRedirect: checkRedirect,
+		},
+		logins: StaticCredentials{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.logger != nil {
+		c.client.Transport = &loggingTransport{
+			base:    c.client.Transport,
+			logger:  c.logger,
+			headers: c.logHeaders,
+			redact:  c.logRedact,
+		}
 	}
+	c.loadTokenCache()
+	return c
 }
 
 // Login logins to the Docker registry.
-func (c *Client) Login(ctx context.Context, host, username, password string) error {
-	host = strings.ToLower(host)
+// By default, the credentials are stored without validation.
+// Use WithValidation to validate them immediately.
+func (c *Client) Login(ctx context.Context, host, username, password string, opts ...LoginOption) error {
+	host = normalizeHost(host)
+
+	var o loginOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.validate {
+		if err := c.validateCredentials(ctx, host, username, password, o.repository); err != nil {
+			return err
+		}
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	if c.loginInfo == nil {
-		c.loginInfo = make(map[string]*loginInfo)
+	c.logins.Set(host, username, password)
+	return nil
+}
+
+// getCredentials returns the credentials for the host.
+// It returns empty username and password for anonymous access.
+func (c *Client) getCredentials(ctx context.Context, host string) (username, password string, err error) {
+	c.mu.RLock()
+	username, password, err = c.logins.GetCredentials(ctx, host)
+	c.mu.RUnlock()
+	if err != nil || username != "" || password != "" {
+		return
 	}
-	c.loginInfo[host] = &loginInfo{
-		username: username,
-		password: password,
+
+	for _, p := range c.providers {
+		username, password, err = p.GetCredentials(ctx, host)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get credentials for %s: %w", host, err)
+		}
+		if username != "" || password != "" {
+			return
+		}
 	}
-	return nil
+	return "", "", nil
 }
 
 // get a new authentication token
-func (c *Client) getToken(ctx context.Context, endpoint, service, scope string) (string, error) {
+func (c *Client) getToken(ctx context.Context, host, endpoint, service, scope string) (*tokenResponse, error) {
+	if refreshToken := c.getRefreshToken(host); refreshToken != "" {
+		resp, err := c.refreshTokenGrant(ctx, host, endpoint, service, scope, refreshToken)
+		if err == nil {
+			return resp, nil
+		}
+		if !isGrantUnsupported(err) {
+			return nil, err
+		}
+	}
+
+	username, password, err := c.getCredentials(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if username == identityTokenUsername {
+		// the password is an identity token, i.e. a refresh token.
+		return c.refreshTokenGrant(ctx, host, endpoint, service, scope, password)
+	}
+
+	resp, err := c.requestToken(ctx, endpoint, service, scope, username, password)
+	if err != nil {
+		return nil, err
+	}
+	if resp.RefreshToken != "" {
+		c.setRefreshToken(host, resp.RefreshToken)
+	}
+	return resp, nil
+}
+
+type tokenResponse struct {
+	Token        string `json:"token"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// expiresAt returns the time when the token expires.
+// The token service spec says that tokens are valid for 60 seconds if expires_in is not given.
+func (resp *tokenResponse) expiresAt(now time.Time) time.Time {
+	expiresIn := resp.ExpiresIn
+	if expiresIn <= 0 {
+		expiresIn = 60
+	}
+	return now.Add(time.Duration(expiresIn) * time.Second)
+}
+
+func (resp *tokenResponse) token() string {
+	if resp.Token != "" {
+		return resp.Token
+	}
+	return resp.AccessToken
+}
+
+// requestToken requests a new token to the token endpoint with the credentials.
+func (c *Client) requestToken(ctx context.Context, endpoint, service, scope, username, password string) (*tokenResponse, error) {
 	u, err := url.Parse(endpoint)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	q := u.Query()
 	q.Set("service", service)
-	q.Set("scope", scope)
+	if scope != "" {
+		q.Set("scope", scope)
+	}
+	if username != "" || password != "" {
+		// ask for a refresh token, so that we don't need to send the password on every token refresh.
+		q.Set("offline_token", "true")
+		q.Set("client_id", clientID)
+	}
 	u.RawQuery = q.Encode()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if username != "" || password != "" {
+		req.SetBasicAuth(username, password)
 	}
-	resp, err := c.client.Do(req)
+	return c.doTokenRequest(req)
+}
+
+func (c *Client) doTokenRequest(req *http.Request) (*tokenResponse, error) {
+	resp, err := c.do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", &registryError{
+		return nil, &registryError{
 			statusCode: resp.StatusCode,
 			header:     resp.Header,
 		}
 	}
 
-	var body struct {
-		Token string `json:"Token"`
-	}
+	var body *tokenResponse
 	dec := json.NewDecoder(resp.Body)
 	if err := dec.Decode(&body); err != nil {
-		return "", err
+		return nil, err
 	}
-	if body.Token == "" {
-		return "", errors.New("response does not contains token")
+	if body == nil || body.token() == "" {
+		return nil, errors.New("response does not contains token")
 	}
-	return body.Token, nil
+	return body, nil
+}
+
+// tokenKey returns the key of the token cache.
+// Tokens are cached per scope, because most registries issue tokens that are valid only for the requested repository.
+func tokenKey(host, scope string) string {
+	return strings.ToLower(host) + " " + scope
 }
 
-func (c *Client) refreshToken(ctx context.Context, host, endpoint, service, scope string) (string, error) {
+// refreshToken gets a new token for the scope, and caches it.
+// requestScope is the scope sent to the token endpoint. It is the same as scope if empty.
+func (c *Client) refreshToken(ctx context.Context, host, scope, endpoint, service, requestScope string) (string, error) {
 	lastUpdatedAt := time.Now()
-	host = strings.ToLower(host)
+	key := tokenKey(host, scope)
+	if requestScope == "" {
+		requestScope = scope
+	}
 
 	c.mu.Lock()
 	if c.tokens == nil {
 		c.tokens = make(map[string]*registryToken)
 	}
-	token := c.tokens[host]
+	token := c.tokens[key]
 	if token == nil {
 		token = &registryToken{}
-		c.tokens[host] = token
+		c.tokens[key] = token
 	}
 	c.mu.Unlock()
 
 	token.mu.Lock()
-	defer token.mu.Unlock()
 	if token.updatedAt.After(lastUpdatedAt) {
+		defer token.mu.Unlock()
 		return token.token, nil
 	}
 
-	newToken, err := c.getToken(ctx, endpoint, service, scope)
+	resp, err := c.getToken(ctx, host, endpoint, service, requestScope)
 	if err != nil {
+		token.mu.Unlock()
 		return "", fmt.Errorf("failed to get token: %w", err)
 	}
-	token.token = newToken
+	token.token = resp.token()
 	token.updatedAt = time.Now()
-	return newToken, nil
+	token.expiresAt = resp.expiresAt(token.updatedAt)
+	token.mu.Unlock()
+
+	// the cache is only an optimization, so the token is used even if it is not saved.
+	if err := c.saveTokenCache(); err != nil {
+		logger := c.logger
+		if logger == nil {
+			logger = log.Default()
+		}
+		logger.Printf("WARNING: failed to save the token cache: %v", err)
+	}
+	return resp.token(), nil
 }
 
-func (c *Client) getCachedToken(host string) string {
-	host = strings.ToLower(host)
+func (c *Client) getCachedToken(host, scope string) string {
+	key := tokenKey(host, scope)
 
 	c.mu.RLock()
 	if c.tokens == nil {
 		c.mu.RUnlock()
 		return ""
 	}
-	token := c.tokens[host]
+	token := c.tokens[key]
 	c.mu.RUnlock()
 
 	if token == nil {
@@ -189,119 +422,297 @@ func (c *Client) getCachedToken(host string) string {
 	}
 	token.mu.RLock()
 	defer token.mu.RUnlock()
+	if !token.expiresAt.IsZero() && time.Now().After(token.expiresAt) {
+		return ""
+	}
 	return token.token
 }
 
-func (c *Client) getManifests(ctx context.Context, host, repo, tag string) (*Manifests, error) {
-	url := fmt.Sprintf("https://%s/v2/%s/manifests/%s", host, repo, tag)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+func (c *Client) getStaticToken(host string) string {
+	return c.staticTokens[normalizeHost(host)]
+}
+
+// get sends a GET request to the registry.
+// If the registry requires authentication, it gets a new token and retries the request.
+// The caller must close the body of the response.
+func (c *Client) get(ctx context.Context, host, path, scope string, header http.Header) (*http.Response, error) {
+	if resp := c.getFromMirrors(ctx, host, path, scope, header); resp != nil {
+		return resp, nil
+	}
+	return c.getFromHost(ctx, host, path, scope, header)
+}
+
+func (c *Client) getFromHost(ctx context.Context, host, path, scope string, header http.Header) (*http.Response, error) {
+	if err := c.breaker.allow(host); err != nil {
+		return nil, err
+	}
+	resp, err := c.getWithAuth(ctx, host, path, scope, header)
+	c.breaker.record(host, err)
+	return resp, err
+}
+
+func (c *Client) getWithAuth(ctx context.Context, host, path, scope string, header http.Header) (*http.Response, error) {
+	if c.getStaticToken(host) != "" {
+		return c.doGet(ctx, host, path, scope, header)
+	}
+
+	if caps := c.getCapabilities(host); caps != nil && caps.AuthScheme == AuthSchemeBearer && c.getCachedToken(host, scope) == "" {
+		// we already know that the registry requires a token.
+		// get it up front instead of relying on 401 bounce.
+		if _, err := c.refreshToken(ctx, host, scope, caps.Realm, caps.Service, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.doGet(ctx, host, path, scope, header)
+	if err == nil {
+		return resp, nil
+	}
+
+	var repoErr *registryError
+	if !errors.As(err, &repoErr) {
+		return nil, err
+	}
+	if repoErr.statusCode != http.StatusUnauthorized {
+		return nil, err
+	}
+
+	h := repoErr.header.Get("Www-Authenticate")
+	if h != "" {
+		caps, err := c.updateCapabilities(host, repoErr.header)
+		if err != nil {
+			return nil, err
+		}
+		if caps.AuthScheme == AuthSchemeBearer {
+			_, params, _ := parseWWWAuthenticate(h)
+			_, err = c.refreshToken(ctx, host, scope, params["realm"], params["service"], params["scope"])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	resp, err = c.doGet(ctx, host, path, scope, header)
+	if err != nil {
+		return nil, insufficientScope(err, host, scope)
+	}
+	return resp, nil
+}
+
+// insufficientScope converts the error into ErrInsufficientScope
+// if the registry still rejects the request with the new token.
+// It happens when the credentials don't grant the scope, e.g. Harbor robot accounts without the permission.
+func insufficientScope(err error, host, scope string) error {
+	var repoErr *registryError
+	if !errors.As(err, &repoErr) {
+		return err
+	}
+	if repoErr.statusCode != http.StatusUnauthorized && repoErr.statusCode != http.StatusForbidden {
+		return err
+	}
+	if repoErr.statusCode == http.StatusUnauthorized {
+		h := repoErr.header.Get("Www-Authenticate")
+		if h == "" {
+			return err
+		}
+		if _, params, perr := parseWWWAuthenticate(h); perr != nil || (params["error"] != "" && params["error"] != "insufficient_scope") {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: the credentials for %s don't grant %q: %v", ErrInsufficientScope, host, scope, err)
+}
+
+func (c *Client) doGet(ctx context.Context, host, path, scope string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(host, path), nil)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Accept", "application/vnd.docker.distribution.manifest.list.v2+json, application/vnd.docker.distribution.manifest.v2+json;q=0.9")
-	if token := c.getCachedToken(host); token != "" {
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if token := c.getStaticToken(host); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if token := c.getCachedToken(host, scope); token != "" {
 		req.Header.Set("Authorization", "Bearer "+token)
+	} else if caps := c.getCapabilities(host); caps != nil && caps.AuthScheme == AuthSchemeBasic {
+		username, password, err := c.getCredentials(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		if username != "" || password != "" {
+			req.SetBasicAuth(username, password)
+		}
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
 		return nil, &registryError{
 			statusCode: resp.StatusCode,
 			header:     resp.Header,
 		}
 	}
+	return resp, nil
+}
 
-	dec := json.NewDecoder(resp.Body)
-	var manifests *Manifests
-	if err := dec.Decode(&manifests); err != nil {
-		return nil, err
+func (c *Client) getManifests(ctx context.Context, host, repo, tag string, opts ...RequestOption) (*Manifests, error) {
+	m, _, err := c.getManifestsWithDigest(ctx, host, repo, tag, opts...)
+	return m, err
+}
+
+// getManifestsWithDigest gets the manifests and their digest.
+// The digest is Docker-Content-Digest, or the sha256 digest of the response if the registry doesn't send it.
+func (c *Client) getManifestsWithDigest(ctx context.Context, host, repo, tag string, opts ...RequestOption) (*Manifests, string, error) {
+	resp, err := c.getManifestsResponse(ctx, host, repo, tag, opts...)
+	if err != nil {
+		return nil, "", err
 	}
-	return manifests, nil
+	return resp.Manifests, resp.Digest, nil
 }
 
-func (c *Client) GetManifests(ctx context.Context, image string) (*Manifests, error) {
-	host, repo, tag := GetRepository(image)
+// ManifestsResponse is the manifests with the metadata of the response.
+type ManifestsResponse struct {
+	Manifests *Manifests
 
-	var manifests *Manifests
-	var err error
-	if manifests, err = c.getManifests(ctx, host, repo, tag); err == nil {
-		return manifests, nil
-	}
+	// Digest is Docker-Content-Digest, or the sha256 digest of the response if the registry doesn't send it.
+	Digest string
 
-	var repoErr *registryError
-	if !errors.As(err, &repoErr) {
+	// MediaType is the Content-Type of the response without the parameters.
+	MediaType string
+
+	// Raw is the body of the response as the registry served it.
+	// Its digest is Digest, except for the signed manifests of the schema version 1.
+	Raw []byte
+}
+
+// getManifestsResponse gets the manifests and the metadata of the response.
+func (c *Client) getManifestsResponse(ctx context.Context, host, repo, tag string, opts ...RequestOption) (*ManifestsResponse, error) {
+	o := c.newRequestOptions(opts)
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+	header := http.Header{}
+	header.Set("Accept", o.accept)
+	repo = c.repository(host, repo)
+	resp, err := c.get(ctx, host, fmt.Sprintf("/v2/%s/manifests/%s", repo, tag), "repository:"+repo+":pull", header)
+	if err != nil {
 		return nil, err
 	}
-	if repoErr.statusCode != http.StatusUnauthorized {
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
 		return nil, err
 	}
 
-	h := repoErr.header.Get("Www-Authenticate")
-	if h != "" {
-		params, err := parseWWWAuthenticate(h)
+	mediaType := resp.Header.Get("Content-Type")
+	if idx := strings.IndexRune(mediaType, ';'); idx >= 0 {
+		mediaType = mediaType[:idx]
+	}
+	mediaType = strings.TrimSpace(mediaType)
+
+	// verify the integrity of the response,
+	// to avoid treating truncated or corrupted responses as updates.
+	payload := data
+	if mediaType == mediaTypeManifestV1Signed {
+		// the digest of signed manifests is calculated without the signatures.
+		payload, err = v1Payload(data)
 		if err != nil {
 			return nil, err
 		}
-		_, err = c.refreshToken(ctx, host, params["realm"], params["service"], params["scope"])
-		if err != nil {
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest != "" {
+		if err := verifyDigest(digest, payload); err != nil {
+			return nil, err
+		}
+	} else {
+		digest = computeDigest(payload)
+	}
+	if isDigest(tag) {
+		if err := verifyDigest(tag, payload); err != nil {
 			return nil, err
 		}
 	}
 
-	return c.getManifests(ctx, host, repo, tag)
+	var manifests *Manifests
+	if err := json.Unmarshal(data, &manifests); err != nil {
+		return nil, err
+	}
+	if manifests == nil {
+		return nil, errors.New("empty manifest")
+	}
+	if manifests.MediaType == "" && manifests.SchemaVersion == 1 {
+		// schema version 1 manifests don't contain their media type.
+		manifests.MediaType = mediaType
+	}
+	return &ManifestsResponse{Manifests: manifests, Digest: digest, MediaType: mediaType, Raw: data}, nil
+}
+
+// GetManifests gets the manifests of the image.
+func (c *Client) GetManifests(ctx context.Context, image string, opts ...RequestOption) (*Manifests, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+	return c.getManifests(ctx, ref.Host, ref.Repository, ref.Reference(), opts...)
+}
+
+// GetManifestsResponse gets the manifests of the image, and the metadata of the response.
+func (c *Client) GetManifestsResponse(ctx context.Context, image string, opts ...RequestOption) (*ManifestsResponse, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+	return c.getManifestsResponse(ctx, ref.Host, ref.Repository, ref.Reference(), opts...)
+}
+
+// GetManifestsWithDigest gets the manifests of the image, and the digest of them.
+func (c *Client) GetManifestsWithDigest(ctx context.Context, image string, opts ...RequestOption) (*Manifests, string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return nil, "", err
+	}
+	return c.getManifestsWithDigest(ctx, ref.Host, ref.Repository, ref.Reference(), opts...)
 }
 
 // GetRepository splits the image name to host, repository, and tag.
+// If the image has a digest, tag is the digest.
+// It doesn't validate the image name; use ParseReference to validate it.
 func GetRepository(image string) (host, repo, tag string) {
-	if idx := strings.IndexRune(image, ':'); idx >= 0 {
-		tag = image[idx+1:]
-		image = image[:idx]
-	} else {
-		tag = "latest"
-	}
-
-	if idx := strings.IndexRune(image, '/'); idx >= 0 {
-		if strings.ContainsRune(image[:idx], '.') {
-			// Docker registry v2 API
-			host = image[:idx]
-			repo = image[idx+1:]
-		} else {
-			// Third party image on DockerHub
-			host = dockerHubHost
-			repo = image
-		}
-	} else {
-		// Official Image on DockerHub
-		host = dockerHubHost
-		repo = "library/" + image
-	}
-	return
+	ref, _ := splitReference(image)
+	return ref.Host, ref.Repository, ref.Reference()
 }
 
 var partRegexp = regexp.MustCompile(`[a-zA-Z0-9_]+="[^"]*"`)
 
-func parseWWWAuthenticate(value string) (map[string]string, error) {
+func parseWWWAuthenticate(value string) (scheme string, params map[string]string, err error) {
 	idx := strings.IndexRune(value, ' ')
 	if idx < 0 {
-		return nil, errors.New("authenticate type not found")
+		return "", nil, errors.New("authenticate type not found")
 	}
 	authType := value[:idx]
-	if authType != "Bearer" {
-		return nil, fmt.Errorf("unknown authenticate type: %s", authType)
+	switch strings.ToLower(authType) {
+	case AuthSchemeBearer:
+		scheme = AuthSchemeBearer
+	case AuthSchemeBasic:
+		scheme = AuthSchemeBasic
+	default:
+		return "", nil, fmt.Errorf("unknown authenticate type: %s", authType)
 	}
 
 	// TODO: follow https://openid-foundation-japan.github.io/draft-ietf-oauth-v2-bearer-draft11.ja.html
-	result := map[string]string{}
-	params := value[idx+1:]
-	for _, part := range partRegexp.FindAllString(params, -1) {
+	params = map[string]string{}
+	for _, part := range partRegexp.FindAllString(value[idx+1:], -1) {
 		kv := strings.SplitN(part, "=", 2)
-		result[kv[0]] = kv[1][1 : len(kv[1])-1]
+		params[kv[0]] = kv[1][1 : len(kv[1])-1]
 	}
 
-	return result, nil
+	return scheme, params, nil
 }
@@ -2,6 +2,8 @@ package registry
 
 import (
 	"context"
+	"encoding/json"
+	"reflect"
 	"testing"
 )
 
@@ -20,3 +22,55 @@ func TestGetManifests(t *testing.T) {
 		}
 	}
 }
+
+func TestManifests_OCIFields(t *testing.T) {
+	const data = `{
+		"schemaVersion": 2,
+		"mediaType": "application/vnd.oci.image.index.v1+json",
+		"artifactType": "application/vnd.example+type",
+		"subject": {
+			"mediaType": "application/vnd.oci.image.manifest.v1+json",
+			"digest": "sha256:5b0bcabd1ed22e9fb1310cf6c2dec7cdef19f0ad69efa1f392e94a4333501270",
+			"size": 1234
+		},
+		"annotations": {"org.opencontainers.image.revision": "abc123"},
+		"manifests": [
+			{
+				"mediaType": "application/vnd.oci.image.manifest.v1+json",
+				"digest": "sha256:e692418e4cbaf90ca69d05a66403747baa33ee08806650b51fab815ad7fc331f",
+				"size": 7143,
+				"platform": {"architecture": "amd64", "os": "linux"},
+				"annotations": {"org.opencontainers.image.created": "2023-01-01T00:00:00Z"}
+			}
+		]
+	}`
+	var m *Manifests
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.ArtifactType != "application/vnd.example+type" {
+		t.Errorf("unexpected artifactType: %q", m.ArtifactType)
+	}
+	if m.Subject == nil || m.Subject.Size != 1234 {
+		t.Errorf("unexpected subject: %#v", m.Subject)
+	}
+	if got := m.Annotations["org.opencontainers.image.revision"]; got != "abc123" {
+		t.Errorf("unexpected annotation: %q", got)
+	}
+	if got := m.Manifests[0].Annotations["org.opencontainers.image.created"]; got != "2023-01-01T00:00:00Z" {
+		t.Errorf("unexpected annotation: %q", got)
+	}
+
+	// the fields round-trip into the stored JSON.
+	out, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m2 *Manifests
+	if err := json.Unmarshal(out, &m2); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m, m2) {
+		t.Errorf("round trip mismatch: %s", out)
+	}
+}
@@ -0,0 +1,57 @@
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const mediaTypeManifestV1Signed = "application/vnd.docker.distribution.manifest.v1+prettyjws"
+
+// v1Payload returns the payload of the signed manifest of schema version 1.
+// The registry calculates the digest of the signed manifest from the payload, not the whole body.
+// See https://github.com/distribution/distribution/blob/main/docs/spec/deprecated-schema-v1.md#signed-manifests
+func v1Payload(data []byte) ([]byte, error) {
+	var manifest struct {
+		Signatures []struct {
+			Protected string `json:"protected"`
+		} `json:"signatures"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	if len(manifest.Signatures) == 0 {
+		return nil, errors.New("signed manifest has no signatures")
+	}
+
+	protected, err := decodeBase64URL(manifest.Signatures[0].Protected)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the protected header: %w", err)
+	}
+	var header struct {
+		FormatLength int    `json:"formatLength"`
+		FormatTail   string `json:"formatTail"`
+	}
+	if err := json.Unmarshal(protected, &header); err != nil {
+		return nil, fmt.Errorf("failed to parse the protected header: %w", err)
+	}
+	tail, err := decodeBase64URL(header.FormatTail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the format tail: %w", err)
+	}
+	if header.FormatLength < 0 || header.FormatLength > len(data) {
+		return nil, fmt.Errorf("invalid format length: %d", header.FormatLength)
+	}
+
+	payload := make([]byte, 0, header.FormatLength+len(tail))
+	payload = append(payload, data[:header.FormatLength]...)
+	payload = append(payload, tail...)
+	return payload, nil
+}
+
+// decodeBase64URL decodes base64url encoded string with or without padding.
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,61 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGetManifests_SchemaV1(t *testing.T) {
+	payload := `{
+   "schemaVersion": 1,
+   "name": "foo/bar",
+   "tag": "latest",
+   "architecture": "amd64",
+   "fsLayers": [
+      {
+         "blobSum": "sha256:a3ed95caeb02ffe68cdd9fd84406680ae93d633cb16422d00e8a7c22955b46d4"
+      },
+      {
+         "blobSum": "sha256:cc8567d70002e957612902a8e985ea129d831ebe04057d88fb644857caa45d11"
+      }
+   ]
+}`
+	// the signature is inserted before the closing brace of the payload.
+	formatLength := len(payload) - 2
+	formatTail := payload[formatLength:]
+	protected := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(
+		`{"formatLength":%d,"formatTail":"%s"}`,
+		formatLength, base64.RawURLEncoding.EncodeToString([]byte(formatTail)),
+	)))
+	body := payload[:formatLength] + fmt.Sprintf(`,
+   "signatures": [
+      {
+         "header": {"alg": "ES256"},
+         "signature": "dummy",
+         "protected": "%s"
+      }
+   ]`, protected) + formatTail
+
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", mediaTypeManifestV1Signed)
+		w.Header().Set("Docker-Content-Digest", sha256Digest(payload))
+		w.Write([]byte(body))
+	}))
+
+	m, err := c.getManifests(context.Background(), host, "foo/bar", "latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.MediaType != mediaTypeManifestV1Signed {
+		t.Errorf("unexpected media type: %q", m.MediaType)
+	}
+	if len(m.FSLayers) != 2 {
+		t.Fatalf("want 2 layers, got %d", len(m.FSLayers))
+	}
+	if m.FSLayers[1].BlobSum != "sha256:cc8567d70002e957612902a8e985ea129d831ebe04057d88fb644857caa45d11" {
+		t.Errorf("unexpected layer: %q", m.FSLayers[1].BlobSum)
+	}
+}
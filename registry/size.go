@@ -0,0 +1,52 @@
+package registry
+
+import (
+	"context"
+)
+
+// isAttestation reports whether the manifest in a list is an attestation, e.g. the provenance of BuildKit.
+func isAttestation(manifest *Manifest) bool {
+	p := manifest.Platform
+	return p != nil && p.OS == "unknown" && p.Architecture == "unknown"
+}
+
+// IsAttestation reports whether the manifest in a list is an attestation, e.g. the provenance of BuildKit.
+func (manifest *Manifest) IsAttestation() bool {
+	return isAttestation(manifest)
+}
+
+// layersSize returns the total size of the layers.
+func layersSize(m *Manifests) int64 {
+	var size int64
+	for _, layer := range m.Layers {
+		size += layer.Size
+	}
+	return size
+}
+
+// ImageSizes returns the total compressed sizes of the layers per platform of the manifests of the image.
+// The keys are the platforms in the form of Platform.String(), or "(single platform)" if the manifests are not a list.
+// It fetches the manifests of the platforms from the registry if the manifests are a list.
+func (c *Client) ImageSizes(ctx context.Context, image string, m *Manifests, opts ...RequestOption) (map[string]int64, error) {
+	sizes := map[string]int64{}
+	if len(m.Manifests) == 0 {
+		sizes[singleManifestPlatform] = layersSize(m)
+		return sizes, nil
+	}
+
+	ref, err := ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+	for _, manifest := range m.Manifests {
+		if isAttestation(manifest) {
+			continue
+		}
+		platform, err := c.getManifests(ctx, ref.Host, ref.Repository, manifest.Digest, opts...)
+		if err != nil {
+			return nil, err
+		}
+		sizes[manifest.Platform.String()] = layersSize(platform)
+	}
+	return sizes, nil
+}
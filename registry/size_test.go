@@ -0,0 +1,51 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestImageSizes(t *testing.T) {
+	amd64 := `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","layers":[{"size":100},{"size":20}]}`
+	arm64 := `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","layers":[{"size":90}]}`
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/foo/manifests/" + sha256Digest(amd64):
+			w.Write([]byte(amd64))
+		case "/v2/foo/manifests/" + sha256Digest(arm64):
+			w.Write([]byte(arm64))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	c.staticTokens = map[string]string{host: "secret"}
+
+	m := &Manifests{
+		SchemaVersion: 2,
+		MediaType:     "application/vnd.oci.image.index.v1+json",
+		Manifests: []*Manifest{
+			{Digest: sha256Digest(amd64), Platform: &Platform{OS: "linux", Architecture: "amd64"}},
+			{Digest: sha256Digest(arm64), Platform: &Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}},
+			{Digest: "sha256:attestation", Platform: &Platform{OS: "unknown", Architecture: "unknown"}},
+		},
+	}
+	got, err := c.ImageSizes(context.Background(), host+"/foo:latest", m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int64{"linux/amd64": 120, "linux/arm64/v8": 90}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	single := &Manifests{SchemaVersion: 2, Layers: []*Layer{{Size: 10}, {Size: 20}}}
+	got, err = c.ImageSizes(context.Background(), host+"/foo:latest", single)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int64{singleManifestPlatform: 30}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
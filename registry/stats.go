@@ -0,0 +1,136 @@
+package registry
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds of the buckets of HostStats.Histogram.
+// The last bucket of the histogram counts the requests slower than the last bound.
+var LatencyBuckets = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// HostStats is the statistics of the requests to a host.
+type HostStats struct {
+	// Requests is the number of the requests.
+	Requests int
+
+	// Errors is the number of the requests that failed without responses, e.g. network errors and timeouts.
+	Errors int
+
+	// StatusClasses is the number of the responses by status class, e.g. "2xx", "4xx".
+	StatusClasses map[string]int
+
+	// RateLimited is the number of the 429 Too Many Requests responses.
+	// They are also counted in StatusClasses["4xx"].
+	RateLimited int
+
+	// TotalLatency is the sum of the latencies of all requests.
+	TotalLatency time.Duration
+
+	// MaxLatency is the latency of the slowest request.
+	MaxLatency time.Duration
+
+	// Histogram is the number of the requests in each bucket of LatencyBuckets.
+	// It has len(LatencyBuckets)+1 elements.
+	Histogram []int
+}
+
+// MeanLatency returns the mean latency of the requests.
+func (s *HostStats) MeanLatency() time.Duration {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Requests)
+}
+
+// ErrorRate returns the ratio of the failed requests, including 5xx and 429 responses.
+func (s *HostStats) ErrorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	failed := s.Errors + s.StatusClasses["5xx"] + s.RateLimited
+	return float64(failed) / float64(s.Requests)
+}
+
+type stats struct {
+	mu    sync.Mutex
+	hosts map[string]*HostStats
+}
+
+func (s *stats) record(host string, resp *http.Response, err error, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.hosts == nil {
+		s.hosts = make(map[string]*HostStats)
+	}
+	h, ok := s.hosts[host]
+	if !ok {
+		h = &HostStats{
+			StatusClasses: make(map[string]int),
+			Histogram:     make([]int, len(LatencyBuckets)+1),
+		}
+		s.hosts[host] = h
+	}
+
+	h.Requests++
+	if err != nil {
+		h.Errors++
+	} else {
+		h.StatusClasses[strconv.Itoa(resp.StatusCode/100)+"xx"]++
+		if resp.StatusCode == http.StatusTooManyRequests {
+			h.RateLimited++
+		}
+	}
+	h.TotalLatency += latency
+	if latency > h.MaxLatency {
+		h.MaxLatency = latency
+	}
+	i := 0
+	for i < len(LatencyBuckets) && latency > LatencyBuckets[i] {
+		i++
+	}
+	h.Histogram[i]++
+}
+
+// Stats returns the snapshot of the statistics of the requests per host.
+func (c *Client) Stats() map[string]HostStats {
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+	ret := make(map[string]HostStats, len(c.stats.hosts))
+	for host, h := range c.stats.hosts {
+		s := *h
+		s.StatusClasses = make(map[string]int, len(h.StatusClasses))
+		for k, v := range h.StatusClasses {
+			s.StatusClasses[k] = v
+		}
+		s.Histogram = append([]int(nil), h.Histogram...)
+		ret[host] = s
+	}
+	return ret
+}
+
+// do sends the request with the User-Agent, and records the statistics.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		ua := c.userAgent
+		if ua == "" {
+			ua = defaultUserAgent
+		}
+		req.Header.Set("User-Agent", ua)
+	}
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	c.stats.record(req.URL.Host, resp, err, time.Since(start))
+	return resp, err
+}
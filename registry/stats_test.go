@@ -0,0 +1,49 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/foo/manifests/latest":
+			w.Write([]byte(testManifest))
+		case "/v2/limited/manifests/latest":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	c.staticTokens = map[string]string{host: "secret"}
+
+	for _, image := range []string{"foo:latest", "foo:latest", "missing:latest", "limited:latest"} {
+		c.GetManifests(context.Background(), host+"/"+image)
+	}
+
+	s, ok := c.Stats()[host]
+	if !ok {
+		t.Fatalf("no stats for %s", host)
+	}
+	if s.Requests != 4 {
+		t.Errorf("want 4 requests, got %d", s.Requests)
+	}
+	if s.StatusClasses["2xx"] != 2 || s.StatusClasses["4xx"] != 2 {
+		t.Errorf("unexpected status classes: %v", s.StatusClasses)
+	}
+	if s.RateLimited != 1 {
+		t.Errorf("want 1 rate limited, got %d", s.RateLimited)
+	}
+	if got := s.ErrorRate(); got != 0.25 {
+		t.Errorf("want error rate 0.25, got %f", got)
+	}
+	total := 0
+	for _, n := range s.Histogram {
+		total += n
+	}
+	if total != 4 {
+		t.Errorf("want 4 requests in the histogram, got %d", total)
+	}
+}
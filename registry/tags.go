@@ -0,0 +1,108 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// tagsPageSize is the number of tags requested per page.
+const tagsPageSize = 1000
+
+// ListTags returns the list of tags of the repository.
+// The tag of the image is ignored.
+func (c *Client) ListTags(ctx context.Context, image string) ([]string, error) {
+	host, repo, _ := GetRepository(image)
+	return c.listTags(ctx, host, repo)
+}
+
+func (c *Client) listTags(ctx context.Context, host, repo string) ([]string, error) {
+	repo = c.repository(host, repo)
+	var tags []string
+	path := "/v2/" + repo + "/tags/list?n=" + strconv.Itoa(tagsPageSize)
+	for path != "" {
+		resp, err := c.get(ctx, host, path, "repository:"+repo+":pull", nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var body struct {
+			Tags []string `json:"tags"`
+		}
+		dec := json.NewDecoder(resp.Body)
+		err = dec.Decode(&body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, body.Tags...)
+
+		path, err = nextLink(resp.Header.Get("Link"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tags, nil
+}
+
+// FilterTags returns the tags that match re, sorted by version.
+func FilterTags(tags []string, re *regexp.Regexp) []string {
+	ret := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if re.MatchString(tag) {
+			ret = append(ret, tag)
+		}
+	}
+	SortTags(ret)
+	return ret
+}
+
+// FilterTagsByConstraint returns the tags that satisfy the version constraint, sorted by version.
+// Tags that are not versions are ignored.
+func FilterTagsByConstraint(tags []string, constraint string) ([]string, error) {
+	c, err := ParseConstraint(constraint)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		v, err := ParseVersion(tag)
+		if err != nil {
+			continue
+		}
+		if c.Check(v) {
+			ret = append(ret, tag)
+		}
+	}
+	SortTags(ret)
+	return ret, nil
+}
+
+// SortTags sorts the tags by version in ascending order.
+// Tags that are not versions are sorted lexically after versions.
+func SortTags(tags []string) {
+	versions := make(map[string]*Version, len(tags))
+	for _, tag := range tags {
+		if v, err := ParseVersion(tag); err == nil {
+			versions[tag] = v
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		vi, vj := versions[tags[i]], versions[tags[j]]
+		switch {
+		case vi != nil && vj != nil:
+			if c := vi.Compare(vj); c != 0 {
+				return c < 0
+			}
+			return tags[i] < tags[j]
+		case vi != nil:
+			return true
+		case vj != nil:
+			return false
+		}
+		return tags[i] < tags[j]
+	})
+}
@@ -0,0 +1,35 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestListTags(t *testing.T) {
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/foo/bar/tags/list" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.URL.Query().Get("last") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`</v2/foo/bar/tags/list?last=3.14&n=%d>; rel="next"`, tagsPageSize))
+			fmt.Fprint(w, `{"name":"foo/bar","tags":["3.13","3.14"]}`)
+		case "3.14":
+			fmt.Fprint(w, `{"name":"foo/bar","tags":["3.15","latest"]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	tags, err := c.listTags(context.Background(), host, "foo/bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"3.13", "3.14", "3.15", "latest"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("want %v, got %v", want, tags)
+	}
+}
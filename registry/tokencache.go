@@ -0,0 +1,110 @@
+package registry
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WithTokenCache persists the bearer tokens in the file at path,
+// so that short-lived runs such as CI jobs can reuse them across invocations.
+// Only access tokens are stored; refresh tokens and passwords never touch the disk.
+func WithTokenCache(path string) Option {
+	return func(c *Client) {
+		c.tokenCachePath = path
+	}
+}
+
+type tokenCacheFile struct {
+	Tokens map[string]tokenCacheEntry `json:"tokens"`
+}
+
+type tokenCacheEntry struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// loadTokenCache loads the tokens from the cache file.
+// The cache is only an optimization, so a missing or broken file is ignored.
+func (c *Client) loadTokenCache() {
+	if c.tokenCachePath == "" {
+		return
+	}
+	data, err := os.ReadFile(c.tokenCachePath)
+	if err != nil {
+		return
+	}
+	var file tokenCacheFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tokens == nil {
+		c.tokens = make(map[string]*registryToken)
+	}
+	for key, entry := range file.Tokens {
+		if entry.Token == "" || !now.Before(entry.ExpiresAt) {
+			continue
+		}
+		c.tokens[key] = &registryToken{
+			token:     entry.Token,
+			expiresAt: entry.ExpiresAt,
+		}
+	}
+}
+
+// saveTokenCache writes the unexpired tokens into the cache file.
+func (c *Client) saveTokenCache() error {
+	if c.tokenCachePath == "" {
+		return nil
+	}
+
+	now := time.Now()
+	file := tokenCacheFile{
+		Tokens: make(map[string]tokenCacheEntry),
+	}
+	c.mu.RLock()
+	for key, token := range c.tokens {
+		token.mu.RLock()
+		if token.token != "" && now.Before(token.expiresAt) {
+			file.Tokens[key] = tokenCacheEntry{
+				Token:     token.token,
+				ExpiresAt: token.expiresAt,
+			}
+		}
+		token.mu.RUnlock()
+	}
+	c.mu.RUnlock()
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file and rename it, so that concurrent runs never see a partial file.
+	dir := filepath.Dir(c.tokenCachePath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".token-cache-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), c.tokenCachePath)
+}
@@ -0,0 +1,118 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenCache(t *testing.T) {
+	var tokenRequests int
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			tokenRequests++
+			fmt.Fprint(w, `{"token":"secret","expires_in":300}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("Www-Authenticate", `Bearer realm="https://registry.example.com/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(testManifest))
+	})
+	path := filepath.Join(t.TempDir(), "tokens.json")
+
+	// the first run gets a new token, and saves it.
+	c, host := newTestServer(t, handler)
+	c.tokenCachePath = path
+	c.loadTokenCache()
+	if _, err := c.GetManifests(context.Background(), host+"/foo:latest"); err != nil {
+		t.Fatal(err)
+	}
+	if tokenRequests != 1 {
+		t.Errorf("want 1 token request, got %d", tokenRequests)
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := stat.Mode().Perm(); perm != 0600 {
+		t.Errorf("want the permission 0600, got %o", perm)
+	}
+
+	// the second run reuses the saved token.
+	c, host = newTestServer(t, handler)
+	c.tokenCachePath = path
+	c.loadTokenCache()
+	if _, err := c.GetManifests(context.Background(), host+"/foo:latest"); err != nil {
+		t.Fatal(err)
+	}
+	if tokenRequests != 1 {
+		t.Errorf("want 1 token request, got %d", tokenRequests)
+	}
+}
+
+func TestTokenCache_SaveFailed(t *testing.T) {
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			fmt.Fprint(w, `{"token":"secret","expires_in":300}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("Www-Authenticate", `Bearer realm="https://registry.example.com/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(testManifest))
+	}))
+	var buf bytes.Buffer
+	c.logger = log.New(&buf, "", 0)
+
+	// the directory of the cache can't be created, because its parent is a file.
+	parent := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(parent, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	c.tokenCachePath = filepath.Join(parent, "cache", "tokens.json")
+
+	if _, err := c.GetManifests(context.Background(), host+"/foo:latest"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "failed to save the token cache") {
+		t.Errorf("want the warning, got %q", buf.String())
+	}
+}
+
+func TestTokenCache_Expired(t *testing.T) {
+	c := New()
+	c.tokenCachePath = filepath.Join(t.TempDir(), "tokens.json")
+	c.tokens = map[string]*registryToken{
+		tokenKey("registry.example.com", "repository:foo:pull"): {
+			token:     "expired",
+			expiresAt: time.Now().Add(-time.Minute),
+		},
+		tokenKey("registry.example.com", "repository:bar:pull"): {
+			token:     "valid",
+			expiresAt: time.Now().Add(time.Minute),
+		},
+	}
+	if err := c.saveTokenCache(); err != nil {
+		t.Fatal(err)
+	}
+
+	c = New(WithTokenCache(c.tokenCachePath))
+	if got := c.getCachedToken("registry.example.com", "repository:foo:pull"); got != "" {
+		t.Errorf("want no token, got %q", got)
+	}
+	if got := c.getCachedToken("registry.example.com", "repository:bar:pull"); got != "valid" {
+		t.Errorf("want %q, got %q", "valid", got)
+	}
+}
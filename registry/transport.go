@@ -0,0 +1,57 @@
+package registry
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+)
+
+// defaultMaxIdleConnsPerHost is the default of TransportConfig.MaxIdleConnsPerHost.
+// net/http keeps only 2 idle connections per host, which is too small for concurrent checks.
+const defaultMaxIdleConnsPerHost = 16
+
+// TransportConfig is the configuration of the HTTP transport.
+// The zero values mean the defaults.
+type TransportConfig struct {
+	// MaxIdleConnsPerHost is the maximum number of idle connections kept per registry host.
+	// It should be at least the concurrency of the requests to reuse the connections.
+	MaxIdleConnsPerHost int
+
+	// IdleConnTimeout is the maximum amount of time an idle connection is kept.
+	IdleConnTimeout time.Duration
+
+	// DisableHTTP2 disables HTTP/2. HTTP/2 is attempted by default.
+	DisableHTTP2 bool
+
+	// DisableKeepAlives disables reusing the connections.
+	DisableKeepAlives bool
+}
+
+// WithTransportConfig configures the HTTP transport of the client.
+func WithTransportConfig(config TransportConfig) Option {
+	return func(c *Client) {
+		c.client.Transport = newTransport(config)
+	}
+}
+
+func newTransport(config TransportConfig) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ForceAttemptHTTP2 = true
+	t.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	if config.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	}
+	if t.MaxIdleConns < t.MaxIdleConnsPerHost {
+		t.MaxIdleConns = t.MaxIdleConnsPerHost
+	}
+	if config.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = config.IdleConnTimeout
+	}
+	if config.DisableHTTP2 {
+		t.ForceAttemptHTTP2 = false
+		// a non-nil empty map disables HTTP/2.
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	t.DisableKeepAlives = config.DisableKeepAlives
+	return t
+}
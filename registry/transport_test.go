@@ -0,0 +1,41 @@
+package registry
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithTransportConfig(t *testing.T) {
+	c := New()
+	transport := c.client.Transport.(*http.Transport)
+	if transport.MaxIdleConnsPerHost != defaultMaxIdleConnsPerHost {
+		t.Errorf("want %d, got %d", defaultMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	}
+	if !transport.ForceAttemptHTTP2 {
+		t.Error("want HTTP/2 enabled")
+	}
+
+	c = New(WithTransportConfig(TransportConfig{
+		MaxIdleConnsPerHost: 200,
+		IdleConnTimeout:     time.Minute,
+		DisableHTTP2:        true,
+		DisableKeepAlives:   true,
+	}))
+	transport = c.client.Transport.(*http.Transport)
+	if transport.MaxIdleConnsPerHost != 200 {
+		t.Errorf("want 200, got %d", transport.MaxIdleConnsPerHost)
+	}
+	if transport.MaxIdleConns < 200 {
+		t.Errorf("want MaxIdleConns >= 200, got %d", transport.MaxIdleConns)
+	}
+	if transport.IdleConnTimeout != time.Minute {
+		t.Errorf("want %s, got %s", time.Minute, transport.IdleConnTimeout)
+	}
+	if transport.ForceAttemptHTTP2 || transport.TLSNextProto == nil {
+		t.Error("want HTTP/2 disabled")
+	}
+	if !transport.DisableKeepAlives {
+		t.Error("want keep-alives disabled")
+	}
+}
@@ -0,0 +1,11 @@
+package registry
+
+// defaultUserAgent is the User-Agent of the requests if WithUserAgent is not given.
+const defaultUserAgent = "docker-image-update-checker"
+
+// WithUserAgent sets the User-Agent of the requests, so that the registry operators can identify the client.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) {
+		c.userAgent = ua
+	}
+}
@@ -0,0 +1,30 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestWithUserAgent(t *testing.T) {
+	var ua string
+	c, host := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ua = r.Header.Get("User-Agent")
+		w.Write([]byte(testManifest))
+	}))
+
+	if _, err := c.GetManifests(context.Background(), host+"/foo:latest"); err != nil {
+		t.Fatal(err)
+	}
+	if ua != defaultUserAgent {
+		t.Errorf("want %q, got %q", defaultUserAgent, ua)
+	}
+
+	WithUserAgent("diuc/1.2.3")(c)
+	if _, err := c.GetManifests(context.Background(), host+"/foo:latest"); err != nil {
+		t.Fatal(err)
+	}
+	if ua != "diuc/1.2.3" {
+		t.Errorf("want %q, got %q", "diuc/1.2.3", ua)
+	}
+}
@@ -0,0 +1,201 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a version number in a tag, e.g. "3.14", "v1.6.0" or "1.21.0-alpine".
+// Unlike the semantic versioning, the suffix after the hyphen is treated as a variant of the image,
+// and it doesn't affect the precedence of versions.
+type Version struct {
+	Segments []int
+	Suffix   string
+}
+
+// ParseVersion parses a version number.
+func ParseVersion(s string) (*Version, error) {
+	orig := s
+	s = strings.TrimPrefix(s, "v")
+
+	var suffix string
+	if idx := strings.IndexRune(s, '-'); idx >= 0 {
+		s, suffix = s[:idx], s[idx+1:]
+	}
+	if s == "" {
+		return nil, fmt.Errorf("invalid version: %q", orig)
+	}
+
+	parts := strings.Split(s, ".")
+	segments := make([]int, 0, len(parts))
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || part[0] == '+' {
+			return nil, fmt.Errorf("invalid version: %q", orig)
+		}
+		segments = append(segments, n)
+	}
+	return &Version{
+		Segments: segments,
+		Suffix:   suffix,
+	}, nil
+}
+
+// Compare returns -1, 0 or 1 if v is less than, equal to, or greater than w.
+// Missing segments are treated as zero, so "3.14" equals to "3.14.0".
+func (v *Version) Compare(w *Version) int {
+	n := len(v.Segments)
+	if len(w.Segments) > n {
+		n = len(w.Segments)
+	}
+	for i := 0; i < n; i++ {
+		a, b := v.segment(i), w.segment(i)
+		if a < b {
+			return -1
+		}
+		if a > b {
+			return 1
+		}
+	}
+	return 0
+}
+
+func (v *Version) segment(i int) int {
+	if i < len(v.Segments) {
+		return v.Segments[i]
+	}
+	return 0
+}
+
+func (v *Version) String() string {
+	var buf strings.Builder
+	for i, n := range v.Segments {
+		if i > 0 {
+			buf.WriteByte('.')
+		}
+		buf.WriteString(strconv.Itoa(n))
+	}
+	if v.Suffix != "" {
+		buf.WriteByte('-')
+		buf.WriteString(v.Suffix)
+	}
+	return buf.String()
+}
+
+// Constraint is a version constraint, e.g. ">=3.14 <4" or "~1.21 || ^2".
+// Comparisons separated by spaces or commas must all be satisfied,
+// and "||" separates alternatives.
+type Constraint struct {
+	alternatives [][]*comparison
+}
+
+type comparison struct {
+	op      string
+	version *Version
+}
+
+// ParseConstraint parses a version constraint.
+func ParseConstraint(s string) (*Constraint, error) {
+	var c Constraint
+	for _, alt := range strings.Split(s, "||") {
+		fields := strings.FieldsFunc(alt, func(r rune) bool {
+			return r == ' ' || r == ','
+		})
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("invalid constraint: %q", s)
+		}
+
+		var comparisons []*comparison
+		for _, field := range fields {
+			cmps, err := parseComparison(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid constraint %q: %w", s, err)
+			}
+			comparisons = append(comparisons, cmps...)
+		}
+		c.alternatives = append(c.alternatives, comparisons)
+	}
+	return &c, nil
+}
+
+func parseComparison(s string) ([]*comparison, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", "!=", "==", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(s, prefix) {
+			op = prefix
+			break
+		}
+	}
+	v, err := ParseVersion(s[len(op):])
+	if err != nil {
+		return nil, err
+	}
+	if v.Suffix != "" {
+		return nil, errors.New("version suffixes are not allowed in constraints")
+	}
+
+	switch op {
+	case "", "==":
+		op = "="
+	case "~":
+		// ~1.21 means >=1.21 <1.22, and ~1 means >=1 <2.
+		upper := &Version{Segments: append([]int(nil), v.Segments...)}
+		i := len(upper.Segments) - 1
+		if i > 1 {
+			i = 1
+		}
+		upper.Segments = upper.Segments[:i+1]
+		upper.Segments[i]++
+		return []*comparison{{">=", v}, {"<", upper}}, nil
+	case "^":
+		// ^1.21 means >=1.21 <2, and ^0.3 means >=0.3 <0.4.
+		upper := &Version{}
+		for i, n := range v.Segments {
+			if n != 0 || i == len(v.Segments)-1 {
+				upper.Segments = append(upper.Segments, n+1)
+				break
+			}
+			upper.Segments = append(upper.Segments, 0)
+		}
+		return []*comparison{{">=", v}, {"<", upper}}, nil
+	}
+	return []*comparison{{op, v}}, nil
+}
+
+// Check reports whether the version satisfies the constraint.
+func (c *Constraint) Check(v *Version) bool {
+	for _, alt := range c.alternatives {
+		ok := true
+		for _, cmp := range alt {
+			if !cmp.check(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *comparison) check(v *Version) bool {
+	r := v.Compare(c.version)
+	switch c.op {
+	case "=":
+		return r == 0
+	case "!=":
+		return r != 0
+	case ">":
+		return r > 0
+	case ">=":
+		return r >= 0
+	case "<":
+		return r < 0
+	case "<=":
+		return r <= 0
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package registry
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"3.14", "3.14"},
+		{"v1.6.0", "1.6.0"},
+		{"1.21.0-alpine", "1.21.0-alpine"},
+		{"22.04", "22.4"},
+	}
+	for _, tt := range tests {
+		v, err := ParseVersion(tt.in)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.in, err)
+			continue
+		}
+		if v.String() != tt.want {
+			t.Errorf("%q: want %q, got %q", tt.in, tt.want, v.String())
+		}
+	}
+
+	for _, in := range []string{"latest", "", "bookworm-slim", "3.x", "-1"} {
+		if _, err := ParseVersion(in); err == nil {
+			t.Errorf("%q: want error, got nil", in)
+		}
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{">=3.14 <4", "3.14", true},
+		{">=3.14 <4", "3.13", false},
+		{">=3.14 <4", "3.17.1", true},
+		{">=3.14 <4", "4.0", false},
+		{">=3.14, <4", "3.15", true},
+		{"3.14", "3.14.0", true},
+		{"!=3.14", "3.14", false},
+		{"~1.21", "1.21.5", true},
+		{"~1.21", "1.22", false},
+		{"^1.21", "1.99", true},
+		{"^1.21", "2.0", false},
+		{"^0.3", "0.4", false},
+		{"<1 || >=3", "2", false},
+		{"<1 || >=3", "3.1", true},
+	}
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.constraint, err)
+			continue
+		}
+		v, err := ParseVersion(tt.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.Check(v); got != tt.want {
+			t.Errorf("%q.Check(%q): want %t, got %t", tt.constraint, tt.version, tt.want, got)
+		}
+	}
+
+	for _, in := range []string{"", ">=", ">=foo", "<1 ||"} {
+		if _, err := ParseConstraint(in); err == nil {
+			t.Errorf("%q: want error, got nil", in)
+		}
+	}
+}
+
+func TestFilterTags(t *testing.T) {
+	tags := []string{"latest", "3.9", "3.14", "3.10", "edge", "3.15", "4.0", "3.14.1"}
+
+	got := FilterTags(tags, regexp.MustCompile(`^3\.\d+$`))
+	if want := []string{"3.9", "3.10", "3.14", "3.15"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	got, err := FilterTagsByConstraint(tags, ">=3.14 <4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"3.14", "3.14.1", "3.15"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/config"
+)
+
+// defaultTagTemplate is the tag of the update commits if the releases are enabled without the tag.
+const defaultTagTemplate = `updates/{{.Time.Format "2006-01-02T15-04"}}`
+
+// releaseTimeout is the timeout for creating a GitHub release.
+const releaseTimeout = 30 * time.Second
+
+// commitTag and commitRelease are given by the flags. They take precedence over the environment variable and the config.
+var (
+	commitTag     string
+	commitRelease bool
+)
+
+// releaseTag is the tag of the update commit created in this run.
+var releaseTag string
+
+// addReleaseFlags adds the flags about the tags and the releases of the update commits.
+func addReleaseFlags(fs *flag.FlagSet) {
+	fs.StringVar(&commitTag, "tag", "", "tag the update commit with the Go `template` of the name, e.g. \"updates/{{.Time.Unix}}\" (default $DIUC_TAG or the tag in the config)")
+	fs.BoolVar(&commitRelease, "release", false, "create the GitHub release of the tag of the update commit, whose notes list the updated images")
+}
+
+// releaseEnabled reports whether the GitHub releases of the update commits are created.
+func releaseEnabled() bool {
+	return commitRelease || cfg != nil && cfg.Commit != nil && cfg.Commit.Release
+}
+
+// tagTemplate returns the template of the tag of the update commits, or the empty string if they are not tagged.
+func tagTemplate() string {
+	text := commitSetting(commitTag, "DIUC_TAG", func(c *config.Commit) string { return c.Tag })
+	if text == "" && releaseEnabled() {
+		return defaultTagTemplate
+	}
+	return text
+}
+
+// releaseUpdates tags the update commit of the run, and creates the GitHub release of the tag if it is enabled.
+func releaseUpdates() error {
+	text := tagTemplate()
+	if text == "" || len(updated) == 0 || gitNoCommit {
+		return nil
+	}
+	if _, ok := store.(fileStore); !ok {
+		log.Printf("WARNING: the tags are created only for the commits of the files in the repository")
+		return nil
+	}
+
+	data := newCommitData("update", updatedImages(), "")
+	name, err := data.render(text)
+	if err != nil {
+		return fmt.Errorf("failed to render the tag: %w", err)
+	}
+	if name == "" {
+		return errors.New("the tag is empty")
+	}
+	message := data.Subject
+	if data.Body != "" {
+		message += "\n\n" + data.Body
+	}
+	if err := gitTag(name, message); err != nil {
+		return err
+	}
+	releaseTag = name
+	log.Printf("tagged the updates as %s", name)
+
+	if !releaseEnabled() {
+		return nil
+	}
+	if gitNoPush {
+		log.Printf("skip the release: %s", name)
+		return nil
+	}
+	return createRelease(name, data.Subject, releaseNotes(data.Results))
+}
+
+// releaseNotes returns the notes of the release in Markdown: the table of the updated images and their digests.
+func releaseNotes(results []*checkResult) string {
+	var buf strings.Builder
+	buf.WriteString("| Image | Old digest | New digest |\n")
+	buf.WriteString("| ----- | ---------- | ---------- |\n")
+	for _, r := range results {
+		if r.Status != checkUpdated {
+			continue
+		}
+		old := "(unknown)"
+		if r.OldDigest != "" {
+			old = "`" + r.OldDigest + "`"
+		}
+		fmt.Fprintf(&buf, "| `%s` | %s | `%s` |\n", r.Image, old, r.Digest)
+	}
+	return buf.String()
+}
+
+// githubRelease is the request to create a release.
+// https://docs.github.com/en/rest/releases/releases#create-a-release
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	Name    string `json:"name"`
+	Body    string `json:"body"`
+}
+
+// createRelease creates the GitHub release of the pushed tag in $GITHUB_REPOSITORY, with $GITHUB_TOKEN.
+func createRelease(tag, name, notes string) error {
+	repository := os.Getenv("GITHUB_REPOSITORY")
+	token := os.Getenv("GITHUB_TOKEN")
+	if repository == "" || token == "" {
+		return errors.New("GITHUB_REPOSITORY and GITHUB_TOKEN are required to create the release")
+	}
+	api := os.Getenv("GITHUB_API_URL")
+	if api == "" {
+		api = "https://api.github.com"
+	}
+
+	body, err := json.Marshal(&githubRelease{TagName: tag, Name: name, Body: notes})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(api, "/")+"/repos/"+repository+"/releases", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("failed to create the release of %s: unexpected status code %d: %s", tag, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	log.Printf("created the release of %s", tag)
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/storage"
+)
+
+// historySuffix is the suffix of the directory of the previous versions of the status file,
+// e.g. "alpine/3.17+history/20240102T030405Z-<digest>.json".
+const historySuffix = "+history"
+
+// historyTimeFormat is the format of the time in the names of the previous versions, which sorts in the chronological order.
+const historyTimeFormat = "20060102T150405Z"
+
+// historyLimit returns the number of the previous versions kept next to the status files.
+func historyLimit() int {
+	if cfg == nil || cfg.State == nil {
+		return 0
+	}
+	return cfg.State.History
+}
+
+// archiveStatusFile copies the status file into the directory of the previous versions before it is overwritten by next,
+// and removes the oldest ones beyond historyLimit.
+// The name of the copy is the time when it was fetched and its digest, or the time when it is archived if they are unknown.
+func archiveStatusFile(path string, next []byte, now time.Time) error {
+	limit := historyLimit()
+	if limit <= 0 {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(data, next) {
+		return nil
+	}
+	doc, err := storage.DecodeDocument(data)
+	if err != nil {
+		// the corrupted one is not worth keeping.
+		return nil
+	}
+
+	t := now
+	if doc.FetchedAt != nil {
+		t = *doc.FetchedAt
+	}
+	name := t.UTC().Format(historyTimeFormat)
+	if doc.Digest != "" {
+		name += "-" + doc.Digest[strings.IndexByte(doc.Digest, ':')+1:]
+	}
+	dir := sidecarDir(path, historySuffix)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	dst := filepath.Join(dir, name+".json")
+	recordChange(dst)
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		return err
+	}
+
+	files, err := sidecarFiles(path, historySuffix)
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	for len(files) > limit {
+		recordChange(files[0])
+		if err := os.Remove(files[0]); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+	return nil
+}
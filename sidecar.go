@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sidecarSuffixes are appended to the path of the status file without ".json" to name the directories next to it.
+// "+" is escaped in the paths of the status files, so the files in them are never taken as the status files.
+var sidecarSuffixes = []string{childrenSuffix, historySuffix}
+
+// sidecarDir returns the directory with the suffix next to the status file.
+func sidecarDir(path, suffix string) string {
+	return strings.TrimSuffix(path, ".json") + suffix
+}
+
+// sidecarFiles returns the files in the directory with the suffix next to the status file.
+func sidecarFiles(path, suffix string) ([]string, error) {
+	dir := sidecarDir(path, suffix)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	return files, nil
+}
+
+// removeSidecars removes the directories next to the status file.
+func removeSidecars(path string) error {
+	for _, suffix := range sidecarSuffixes {
+		files, err := sidecarFiles(path, suffix)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			recordChange(f)
+			if err := os.Remove(f); err != nil {
+				return err
+			}
+		}
+		if len(files) > 0 {
+			removeEmptyDirs(sidecarDir(path, suffix))
+		}
+	}
+	return nil
+}
+
+// moveSidecars moves the directories next to the status file, together with it.
+// The old ones are removed if the new status file already has them.
+func moveSidecars(src, dst string) error {
+	for _, suffix := range sidecarSuffixes {
+		files, err := sidecarFiles(src, suffix)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			continue
+		}
+		if _, err := os.Stat(sidecarDir(dst, suffix)); err == nil {
+			for _, f := range files {
+				recordChange(f)
+				if err := os.Remove(f); err != nil {
+					return err
+				}
+			}
+			removeEmptyDirs(sidecarDir(src, suffix))
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(sidecarDir(dst, suffix)), 0755); err != nil {
+			return err
+		}
+		for _, f := range files {
+			recordChange(f)
+			recordChange(filepath.Join(sidecarDir(dst, suffix), filepath.Base(f)))
+		}
+		if err := os.Rename(sidecarDir(src, suffix), sidecarDir(dst, suffix)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
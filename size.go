@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// sizeThreshold is the percentage of the growth of the image size that raises a warning. 0 disables the warnings.
+var sizeThreshold float64
+
+// recordSizes records the total compressed sizes of the layers of the updated images,
+// and warns about the images that grow beyond sizeThreshold, e.g. a surprise bloat of a base image.
+func recordSizes(c *registry.Client) {
+	for _, r := range checkResults {
+		if r.Status != checkUpdated {
+			continue
+		}
+		m := registry.FilterPlatforms(status[r.Image], targetPlatforms(r.Image))
+		ctx, cancel := context.WithTimeout(context.Background(), checkTimeout(r.Image))
+		sizes, err := c.ImageSizes(ctx, fetchName(r.Image), m)
+		cancel()
+		if err != nil {
+			log.Printf("failed to get the sizes of %s: %v", r.Image, err)
+			continue
+		}
+		r.Sizes = sizes
+		r.SizeWarnings = sizeWarnings(imageStatusOf(r.Image).Sizes, sizes)
+		for _, w := range r.SizeWarnings {
+			log.Printf("WARNING: %s: %s", r.Image, w)
+		}
+		markSizes(r.Image, sizes)
+	}
+}
+
+// sizeWarnings returns the warnings about the platforms that grow beyond sizeThreshold.
+func sizeWarnings(old, new map[string]int64) []string {
+	if sizeThreshold <= 0 {
+		return nil
+	}
+	platforms := make([]string, 0, len(new))
+	for platform := range new {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+
+	var warnings []string
+	for _, platform := range platforms {
+		o, n := old[platform], new[platform]
+		if o <= 0 {
+			continue
+		}
+		growth := float64(n-o) / float64(o) * 100
+		if growth > sizeThreshold {
+			warnings = append(warnings, fmt.Sprintf("%s grew by %.1f%% (%d -> %d bytes)", platform, growth, o, n))
+		}
+	}
+	return warnings
+}
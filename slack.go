@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// slackNotifier posts the updates and the failures to the incoming webhook of Slack.
+type slackNotifier struct {
+	endpoint string
+}
+
+// slackMessage is the payload of the incoming webhook.
+// https://api.slack.com/messaging/webhooks
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// NotifyUpdates implements Notifier.
+func (n *slackNotifier) NotifyUpdates(ctx context.Context, events []UpdateEvent) error {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "*%d images updated*%s\n", len(events), slackTenant())
+	for _, e := range events {
+		old := "(unknown)"
+		if e.OldDigest != "" {
+			old = "`" + shortDigest(e.OldDigest) + "`"
+		}
+		fmt.Fprintf(&buf, "• `%s`: %s → `%s`", slackEscape(e.Image), old, shortDigest(e.NewDigest))
+		if platforms := changedPlatforms(e.Changes); platforms != "" {
+			fmt.Fprintf(&buf, " (%s)", slackEscape(platforms))
+		}
+		if e.Metadata != nil && e.Metadata.ReleaseNotes != "" {
+			fmt.Fprintf(&buf, " <%s|release notes>", e.Metadata.ReleaseNotes)
+		}
+		if e.Metadata != nil && e.Metadata.Owner != "" {
+			fmt.Fprintf(&buf, " owner: %s", slackEscape(e.Metadata.Owner))
+		}
+		buf.WriteString("\n")
+	}
+	if err := n.post(ctx, buf.String()); err != nil {
+		return err
+	}
+	log.Printf("notified the updates to Slack")
+	return nil
+}
+
+// NotifyFailures implements Notifier.
+func (n *slackNotifier) NotifyFailures(ctx context.Context, errs []CheckError) error {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "*%d images failed*%s\n", len(errs), slackTenant())
+	for _, e := range errs {
+		fmt.Fprintf(&buf, "• `%s`: %s: %s\n", slackEscape(e.Image), e.Status, slackEscape(e.Message))
+	}
+	if err := n.post(ctx, buf.String()); err != nil {
+		return err
+	}
+	log.Printf("notified the failures to Slack")
+	return nil
+}
+
+func (n *slackNotifier) post(ctx context.Context, text string) error {
+	body, err := json.Marshal(&slackMessage{Text: text})
+	if err != nil {
+		return err
+	}
+	if err := postJSON(ctx, n.endpoint, body, nil); err != nil {
+		return fmt.Errorf("slack: %w", err)
+	}
+	return nil
+}
+
+// slackTenant returns the suffix of the title with the selected tenant, if any.
+func slackTenant() string {
+	if tenantName == "" {
+		return ""
+	}
+	return " in " + slackEscape(tenantName)
+}
+
+// slackEscape escapes the control characters of the Slack messages.
+// https://api.slack.com/reference/surfaces/formatting#escaping
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// globalSpread is the window given by the flag. It takes precedence over the config file.
+var globalSpread time.Duration
+
+// spreadWindow returns the window that the checks are spread across. 0 means no spreading.
+func spreadWindow() time.Duration {
+	if globalSpread > 0 {
+		return globalSpread
+	}
+	if cfg != nil && cfg.Spread > 0 {
+		return time.Duration(cfg.Spread)
+	}
+	return 0
+}
+
+// spreadOffsets returns the start times of n checks relative to the start of the window.
+// The window is divided into n slots, and each check starts at a random time in its slot,
+// so the checks are spread evenly without firing at fixed intervals.
+func spreadOffsets(n int, window time.Duration, rnd *rand.Rand) []time.Duration {
+	offsets := make([]time.Duration, n)
+	slot := float64(window) / float64(n)
+	for i := range offsets {
+		offsets[i] = time.Duration(slot * (float64(i) + rnd.Float64()))
+	}
+	return offsets
+}
+
+// getManifestsSpread gets the manifests of the images, spreading the requests across the window.
+// The groups of the request options are fetched concurrently, so that each of them is spread across the whole window.
+func getManifestsSpread(ctx context.Context, c *registry.Client, images []string, window time.Duration) []*registry.BatchResult {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	offsets := spreadOffsets(len(images), window, rnd)
+	start := time.Now()
+	starts := make(map[string]time.Time, len(images))
+	for i, image := range images {
+		starts[image] = start.Add(offsets[i])
+	}
+
+	keys, groups := groupRequests(images)
+	batches := make([][]*registry.BatchResult, len(keys))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		i, key := i, key
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			images := groups[key]
+			at := make([]time.Time, len(images))
+			for j, image := range images {
+				at[j] = starts[image]
+			}
+			batches[i] = c.GetManifestsBatchAt(ctx, fetchNames(images), at, checkConcurrency, key.options()...)
+			for j, r := range batches[i] {
+				r.Image = images[j]
+			}
+		}()
+	}
+	wg.Wait()
+
+	var results []*registry.BatchResult
+	for _, batch := range batches {
+		results = append(results, batch...)
+	}
+	return results
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var statsCommand = &command{
+	name:    "stats",
+	usage:   "stats [options]",
+	summary: "report how often each tracked image is updated, from the git log or the store",
+	run:     runStats,
+}
+
+func runStats(cmd *command, args []string) error {
+	fs := newFlagSet(cmd)
+	addConfigFlags(fs)
+	addTenantFlags(fs)
+	addStoreFlags(fs)
+	days := fs.Int("days", 90, "count the updates in the last `n` days")
+	fs.Parse(args)
+
+	if err := loadConfig(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	now := time.Now()
+	since := now.AddDate(0, 0, -*days)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "IMAGE\tLAST UPDATE\tUPDATES\tAVERAGE INTERVAL\tLAST %d DAYS\n", *days)
+	for _, image := range targets {
+		dates, err := statusCommitDates(image)
+		if err != nil {
+			return err
+		}
+		if len(dates) == 0 {
+			fmt.Fprintf(w, "%s\t-\t0\t-\t0\n", image)
+			continue
+		}
+
+		// dates are sorted from the newest.
+		last := dates[0]
+		interval := "-"
+		if len(dates) > 1 {
+			avg := dates[0].Sub(dates[len(dates)-1]) / time.Duration(len(dates)-1)
+			interval = formatDays(avg)
+		}
+		recent := 0
+		for _, d := range dates {
+			if d.After(since) {
+				recent++
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s (%s ago)\t%d\t%s\t%d\n",
+			image, last.UTC().Format("2006-01-02"), formatDays(now.Sub(last)), len(dates), interval, recent)
+	}
+	return w.Flush()
+}
+
+// statusCommitDates returns the dates of the commits that changed the status file of the image, from the newest.
+// If the store keeps the history, they are the dates of its revisions.
+func statusCommitDates(image string) ([]time.Time, error) {
+	if _, ok := store.(fileStore); !ok {
+		revs, err := statusHistory(image)
+		if err != nil {
+			return nil, err
+		}
+		dates := make([]time.Time, 0, len(revs))
+		for i := len(revs) - 1; i >= 0; i-- {
+			dates = append(dates, revs[i].Date)
+		}
+		return dates, nil
+	}
+
+	path, err := statusFile(image)
+	if err != nil {
+		return nil, err
+	}
+	out, err := gitOutput("log", "--follow", "--format=%cI", "--", "./"+filepath.ToSlash(path))
+	if err != nil {
+		return nil, err
+	}
+	var dates []time.Time
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line == "" {
+			continue
+		}
+		d, err := time.Parse(time.RFC3339, line)
+		if err != nil {
+			return nil, err
+		}
+		dates = append(dates, d)
+	}
+	return dates, nil
+}
+
+// formatDays formats the duration in days, e.g. "3.5d".
+func formatDays(d time.Duration) string {
+	return fmt.Sprintf("%.1fd", d.Hours()/24)
+}
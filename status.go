@@ -0,0 +1,279 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/config"
+	"github.com/shogo82148/docker-image-update-checker/internal/layout"
+	"github.com/shogo82148/docker-image-update-checker/internal/storage"
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+var status map[string]*registry.Manifests
+var updated map[string]struct{}
+
+// fetched are the metadata of the retrievals of the updated images, stored alongside the manifests.
+var fetched map[string]*storage.Document
+
+// statusDir is the directory that stores the status files.
+// It is under the state directory of the tenant if a tenant is selected.
+var statusDir = config.DefaultStateDir
+
+// statusLayout maps the images to the paths of the status files under statusDir.
+var statusLayout = mustParseLayout(layout.Default)
+
+func mustParseLayout(s string) *layout.Layout {
+	l, err := layout.Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+// statusFile returns the path to the file that stores the manifests of the image.
+// It rejects the images that would point outside of statusDir.
+//
+// If the layout has the digest in the file name, it is the stored file of the image,
+// or the path without the digest if the image is not stored. Use statusFileOf to save the manifests.
+func statusFile(image string) (string, error) {
+	path, err := statusFileOf(image, "")
+	if err != nil || !statusLayout.HasDigest() {
+		return path, err
+	}
+	stored, err := findStatusFile(image, path)
+	if err != nil || stored == "" {
+		return path, err
+	}
+	return stored, nil
+}
+
+// statusFileOf returns the path to the file that stores the manifests of the image with the digest.
+// The digest is used only if the layout has the digest in the file name.
+func statusFileOf(image, digest string) (string, error) {
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(statusDir, filepath.FromSlash(statusLayout.Path(ref.Host, ref.Repository, ref.Reference(), digest)))
+
+	// ParseReference rejects "..", but check it again because the path comes from the untrusted input.
+	rel, err := filepath.Rel(statusDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("the status file of %s is outside of %s", image, statusDir)
+	}
+	return path, nil
+}
+
+// findStatusFile returns the stored file of the image in the layout with the digest in the file name,
+// or the empty string if it is not found. The digest is in the file name, so the file is in the directory of path,
+// which is the path without the digest.
+func findStatusFile(image, path string) (string, error) {
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Dir(path)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		file := filepath.Join(dir, e.Name())
+		rel, err := filepath.Rel(statusDir, file)
+		if err != nil {
+			return "", err
+		}
+		host, repository, tag, _, err := statusLayout.Parse(filepath.ToSlash(rel))
+		if err == nil && host == ref.Host && repository == ref.Repository && tag == ref.Reference() {
+			return file, nil
+		}
+	}
+	return "", nil
+}
+
+// documentDigest returns the digest of the stored document, or the digest of its canonical manifests
+// if the registry didn't return the digest.
+func documentDigest(doc *storage.Document) (string, error) {
+	if doc.Digest != "" {
+		return doc.Digest, nil
+	}
+	data, err := json.Marshal(registry.Canonical(doc.Manifest))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// statusImage returns the image reference of the status file from the host, the repository and the tag or the digest.
+func statusImage(host, repository, reference string) string {
+	if strings.Contains(reference, ":") {
+		// the tags never contain colons, but the digests do.
+		return host + "/" + repository + "@" + reference
+	}
+	return host + "/" + repository + ":" + reference
+}
+
+// migrateStatusFiles moves the status files to the paths that statusFile returns.
+// The files are committed together with the next update.
+func migrateStatusFiles() error {
+	moves, err := statusFileMoves()
+	if err != nil {
+		return err
+	}
+	for _, m := range moves {
+		src, dst := m[0], m[1]
+		if _, err := os.Stat(dst); err == nil {
+			// the file at the new path is already tracked. the old one is a duplicate.
+			log.Printf("remove the duplicated status file %s", src)
+			recordChange(src)
+			if err := os.Remove(src); err != nil {
+				return err
+			}
+		} else {
+			log.Printf("move the status file %s to %s", src, dst)
+			if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+				return err
+			}
+			recordChange(src)
+			recordChange(dst)
+			if err := os.Rename(src, dst); err != nil {
+				return err
+			}
+		}
+		if err := moveSidecars(src, dst); err != nil {
+			return err
+		}
+		removeEmptyDirs(filepath.Dir(src))
+	}
+	return nil
+}
+
+// statusFileMoves returns the pairs of the old and new paths of the status files that are not at the paths statusFile returns, e.g.
+//   - the files under the aliases of Docker Hub, e.g. docker.io/alpine/3.17.json,
+//   - the files in the default layout after the layout is configured,
+//   - the files with the names that were written before they were escaped, e.g. localhost:5000/foo/v1.json.
+func statusFileMoves() ([][2]string, error) {
+	if _, err := os.Stat(statusDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+	nested := mustParseLayout(layout.Default)
+	var moves [][2]string
+	err := filepath.Walk(statusDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(statusDir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		host, repository, tag, digest, err := statusLayout.Parse(rel)
+		if err != nil {
+			host, repository, tag, digest, err = nested.Parse(rel)
+		}
+		if err != nil {
+			// it is not a status file.
+			return nil
+		}
+		if statusLayout.HasDigest() && digest == "" {
+			// the file in the layout without the digest. the digest is the one of its content.
+			digest, err = statusFileDigest(path)
+			if err != nil {
+				log.Printf("%s is not a valid status file, skipped: %v", path, err)
+				return nil
+			}
+		}
+		dst, err := statusFileOf(statusImage(host, repository, tag), digest)
+		if err != nil {
+			log.Printf("%s is not a valid status file, skipped: %v", path, err)
+			return nil
+		}
+		if dst != path {
+			moves = append(moves, [2]string{path, dst})
+		}
+		return nil
+	})
+	return moves, err
+}
+
+// statusFileDigest returns the digest of the document in the status file.
+func statusFileDigest(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	doc, err := storage.DecodeDocument(data)
+	if err != nil {
+		return "", err
+	}
+	return documentDigest(doc)
+}
+
+// errCorruptedStatus is returned when the stored manifests can't be parsed.
+var errCorruptedStatus = storage.ErrCorrupted
+
+func loadStatus() error {
+	status = map[string]*registry.Manifests{}
+	for _, image := range targets {
+		manifests, err := store.Load(image)
+		if errors.Is(err, errCorruptedStatus) {
+			// it will be overwritten by the new one.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if manifests != nil {
+			status[image] = manifests
+		}
+	}
+	return nil
+}
+
+// fetchedDocument returns the metadata of the retrieval of the manifests, and the body of the response.
+func fetchedDocument(digest, mediaType string, raw []byte, fetchedAt time.Time) *storage.Document {
+	doc := &storage.Document{Digest: digest, MediaType: mediaType, Raw: raw}
+	if !fetchedAt.IsZero() {
+		t := fetchedAt.UTC()
+		doc.FetchedAt = &t
+	}
+	return doc
+}
+
+// saveStatus stores the manifests of the updated images, with the metadata of the retrievals if the store keeps them.
+func saveStatus() error {
+	for image := range updated {
+		doc := &storage.Document{}
+		if md := fetched[image]; md != nil {
+			*doc = *md
+		}
+		doc.Manifest = status[image]
+
+		var err error
+		if s, ok := store.(documentStore); ok {
+			err = s.SaveDocument(image, doc)
+		} else {
+			err = store.Save(image, doc.Manifest)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
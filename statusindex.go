@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"time"
+)
+
+// statusIndexFile is the file that records the metadata of the checks per image.
+// It is under the state directory of the tenant if a tenant is selected.
+var statusIndexFile = "status.json"
+
+// statusIndex is the content of statusIndexFile.
+type statusIndex struct {
+	Images map[string]*imageStatus `json:"images"`
+}
+
+// imageStatus is the metadata of the checks of an image.
+type imageStatus struct {
+	// LastChecked is the time when the image was checked successfully.
+	LastChecked time.Time `json:"lastChecked"`
+
+	// LastChanged is the time when the image was updated last time,
+	// or when it was checked first time if it hasn't been updated since then.
+	LastChanged time.Time `json:"lastChanged"`
+
+	// ConsecutiveMisses is the number of the consecutive checks that found the tag removed.
+	ConsecutiveMisses int `json:"consecutiveMisses,omitempty"`
+
+	// ConsecutiveErrors is the number of the consecutive checks that failed, e.g. by the errors of the registry.
+	ConsecutiveErrors int `json:"consecutiveErrors,omitempty"`
+
+	// LastError is the error of the last failed check. It is kept after the image is checked successfully.
+	LastError string `json:"lastError,omitempty"`
+
+	// Aliases are the other images that resolved to the same digest in the last check.
+	Aliases []string `json:"aliases,omitempty"`
+
+	// Sizes are the total compressed sizes of the layers per platform in the last update.
+	Sizes map[string]int64 `json:"sizes,omitempty"`
+
+	// Digest is the digest of the stored manifests, which is reported as the old digest in the next update.
+	Digest string `json:"digest,omitempty"`
+}
+
+// index is the loaded statusIndexFile.
+var index *statusIndex
+
+// indexChanged reports whether index is modified since it is loaded.
+var indexChanged bool
+
+// indexMustCommit reports whether index has the changes that must be committed even if no images are updated.
+var indexMustCommit bool
+
+// loadStatusIndex loads statusIndexFile. It is empty if the file doesn't exist.
+func loadStatusIndex() error {
+	index = &statusIndex{Images: map[string]*imageStatus{}}
+	indexChanged = false
+	indexMustCommit = false
+	data, err := os.ReadFile(statusIndexFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, index); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", statusIndexFile, err)
+	}
+	if index.Images == nil {
+		index.Images = map[string]*imageStatus{}
+	}
+	return nil
+}
+
+// saveStatusIndex writes statusIndexFile if it is modified.
+// The callers write it only if it is committed, not to leave the working tree dirty between the runs.
+func saveStatusIndex() error {
+	if !indexChanged {
+		return nil
+	}
+	data, err := json.MarshalIndent(index, "", "    ")
+	if err != nil {
+		return err
+	}
+	recordChange(statusIndexFile)
+	return os.WriteFile(statusIndexFile, append(data, '\n'), 0644)
+}
+
+// imageStatusOf returns the metadata of the image, and creates it if it doesn't exist.
+func imageStatusOf(image string) *imageStatus {
+	s, ok := index.Images[image]
+	if !ok {
+		s = &imageStatus{}
+		index.Images[image] = s
+	}
+	return s
+}
+
+// markChecked records that the image was checked successfully.
+func markChecked(image string, now time.Time) {
+	s := imageStatusOf(image)
+	s.LastChecked = now.UTC().Truncate(time.Second)
+	if s.LastChanged.IsZero() {
+		s.LastChanged = s.LastChecked
+	}
+	if s.ConsecutiveMisses > 0 || s.ConsecutiveErrors > 0 {
+		s.ConsecutiveMisses = 0
+		s.ConsecutiveErrors = 0
+		indexMustCommit = true
+	}
+	indexChanged = true
+}
+
+// markFailed records that the check of the image failed with the error.
+// A new failure or a new error is committed even if no images are updated, so that the dashboards see the failing images,
+// but the count of the same error is not, not to make a commit in every run while the image keeps failing.
+func markFailed(image, message string) {
+	s := imageStatusOf(image)
+	if s.ConsecutiveErrors == 0 || s.LastError != message {
+		indexMustCommit = true
+	}
+	s.ConsecutiveErrors++
+	s.LastError = message
+	indexChanged = true
+}
+
+// markChanged records that the image was updated to the digest.
+func markChanged(image string, now time.Time, digest string) {
+	s := imageStatusOf(image)
+	s.LastChanged = now.UTC().Truncate(time.Second)
+	s.Digest = digest
+	indexChanged = true
+}
+
+// markDigest records the digest of the unchanged image, if it is not recorded yet, e.g. by the older versions of the checker.
+// The digests of the images unchanged only on the tracked platforms are not updated, because the stored manifests are not.
+func markDigest(image, digest string) {
+	s := imageStatusOf(image)
+	if s.Digest != "" || digest == "" {
+		return
+	}
+	s.Digest = digest
+	indexChanged = true
+}
+
+// storedDigest returns the digest of the stored manifests of the image, or the empty string if it is not recorded.
+func storedDigest(image string) string {
+	if index == nil {
+		return ""
+	}
+	if s, ok := index.Images[image]; ok {
+		return s.Digest
+	}
+	return ""
+}
+
+// markMissed records that the tag of the image is not found, and returns the number of the consecutive misses.
+func markMissed(image string) int {
+	s := imageStatusOf(image)
+	s.ConsecutiveMisses++
+	indexChanged = true
+	indexMustCommit = true
+	return s.ConsecutiveMisses
+}
+
+// forgetImages removes the metadata of the images, e.g. after their status files are pruned.
+// The images are compared by their status files, because the metadata are recorded under the names in the config.
+func forgetImages(images []string) {
+	paths := make(map[string]struct{}, len(images))
+	for _, image := range images {
+		if path, err := statusFile(image); err == nil {
+			paths[path] = struct{}{}
+		}
+	}
+	for image := range index.Images {
+		path, err := statusFile(image)
+		if err != nil {
+			continue
+		}
+		if _, ok := paths[path]; ok {
+			delete(index.Images, image)
+			indexChanged = true
+		}
+	}
+}
+
+// markAliases records the aliases of the image.
+func markAliases(image string, aliases []string) {
+	s := imageStatusOf(image)
+	if reflect.DeepEqual(s.Aliases, aliases) {
+		return
+	}
+	s.Aliases = aliases
+	indexChanged = true
+}
+
+// markSizes records the sizes of the image.
+func markSizes(image string, sizes map[string]int64) {
+	imageStatusOf(image).Sizes = sizes
+	indexChanged = true
+}
+
+// checkIntervalSlack is the ratio of the interval that a check may be early by,
+// so that a scheduled run slightly earlier than the previous one doesn't skip the image.
+const checkIntervalSlack = 0.1
+
+// checkInterval returns the check interval of the image, or the default one of the tenant.
+func checkInterval(image string) time.Duration {
+	if img := targetConfigs[image]; img != nil && img.CheckInterval > 0 {
+		return time.Duration(img.CheckInterval)
+	}
+	if tenant != nil {
+		return time.Duration(tenant.CheckInterval)
+	}
+	return 0
+}
+
+// isDue reports whether the check interval of the image has elapsed.
+func isDue(image string, now time.Time) bool {
+	interval := checkInterval(image)
+	if interval <= 0 {
+		return true
+	}
+	s, ok := index.Images[image]
+	if !ok || s.LastChecked.IsZero() {
+		return true
+	}
+	interval -= time.Duration(float64(interval) * checkIntervalSlack)
+	return now.Sub(s.LastChecked) >= interval
+}
+
+// hasCheckIntervals reports whether any targets have the check intervals.
+func hasCheckIntervals() bool {
+	for image := range targetConfigs {
+		if checkInterval(image) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// dueTargets removes the targets whose check intervals haven't elapsed yet.
+func dueTargets(now time.Time) []string {
+	var skipped []string
+	images := targets[:0]
+	for _, image := range targets {
+		if isDue(image, now) {
+			images = append(images, image)
+		} else {
+			skipped = append(skipped, image)
+		}
+	}
+	targets = images
+	return skipped
+}
+
+// staleAfter returns the period after which the image is stale if it hasn't changed. 0 means never.
+func staleAfter(image string) time.Duration {
+	if img := targetConfigs[image]; img != nil && img.StaleAfter > 0 {
+		return time.Duration(img.StaleAfter)
+	}
+	if cfg != nil {
+		return time.Duration(cfg.StaleAfter)
+	}
+	return 0
+}
+
+// findStale marks the results of the images that haven't changed for their stale periods,
+// e.g. the abandoned base images that should be migrated away from.
+func findStale(now time.Time) {
+	for _, r := range checkResults {
+		if r.Status != checkUnchanged && r.Status != checkDrifted && r.Status != checkPartial {
+			continue
+		}
+		period := staleAfter(r.Image)
+		s, ok := index.Images[r.Image]
+		if period <= 0 || !ok || s.LastChanged.IsZero() {
+			continue
+		}
+		if age := now.Sub(s.LastChanged); age > period {
+			r.Stale = true
+			log.Printf("WARNING: %s is stale: it hasn't changed for %d days", r.Image, int(age.Hours()/24))
+		}
+	}
+}
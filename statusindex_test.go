@@ -0,0 +1,258 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/config"
+)
+
+func TestMarkFailed(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     *imageStatus
+		message    string
+		mustCommit bool
+	}{
+		{
+			name:       "new failure",
+			status:     &imageStatus{},
+			message:    "unauthorized",
+			mustCommit: true,
+		},
+		{
+			name:       "recovered and failed again",
+			status:     &imageStatus{LastError: "unauthorized"},
+			message:    "unauthorized",
+			mustCommit: true,
+		},
+		{
+			name:       "changed error",
+			status:     &imageStatus{ConsecutiveErrors: 2, LastError: "unauthorized"},
+			message:    "manifest unknown",
+			mustCommit: true,
+		},
+		{
+			name:       "same error",
+			status:     &imageStatus{ConsecutiveErrors: 2, LastError: "unauthorized"},
+			message:    "unauthorized",
+			mustCommit: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count := tt.status.ConsecutiveErrors
+			index = &statusIndex{Images: map[string]*imageStatus{"alpine:3.17": tt.status}}
+			indexChanged, indexMustCommit = false, false
+			t.Cleanup(func() { index, indexChanged, indexMustCommit = nil, false, false })
+
+			markFailed("alpine:3.17", tt.message)
+			if indexMustCommit != tt.mustCommit {
+				t.Errorf("indexMustCommit = %v, want %v", indexMustCommit, tt.mustCommit)
+			}
+			if !indexChanged {
+				t.Error("indexChanged = false, want true")
+			}
+			if got := tt.status.ConsecutiveErrors; got != count+1 {
+				t.Errorf("ConsecutiveErrors = %d, want %d", got, count+1)
+			}
+			if got := tt.status.LastError; got != tt.message {
+				t.Errorf("LastError = %q, want %q", got, tt.message)
+			}
+		})
+	}
+}
+
+func TestIsDue(t *testing.T) {
+	now := time.Date(2023, 1, 2, 15, 0, 0, 0, time.UTC)
+	hour := config.Duration(time.Hour)
+	tests := []struct {
+		name   string
+		image  *config.Image
+		tenant *config.Tenant
+		status *imageStatus
+		want   bool
+	}{
+		{
+			name:   "no check interval",
+			image:  &config.Image{Image: "alpine:3.17"},
+			status: &imageStatus{LastChecked: now.Add(-time.Minute)},
+			want:   true,
+		},
+		{
+			name:  "never checked",
+			image: &config.Image{Image: "alpine:3.17", CheckInterval: hour},
+			want:  true,
+		},
+		{
+			name:   "interval elapsed",
+			image:  &config.Image{Image: "alpine:3.17", CheckInterval: hour},
+			status: &imageStatus{LastChecked: now.Add(-time.Hour)},
+			want:   true,
+		},
+		{
+			name:   "interval not elapsed",
+			image:  &config.Image{Image: "alpine:3.17", CheckInterval: hour},
+			status: &imageStatus{LastChecked: now.Add(-30 * time.Minute)},
+			want:   false,
+		},
+		{
+			name:   "within the slack",
+			image:  &config.Image{Image: "alpine:3.17", CheckInterval: hour},
+			status: &imageStatus{LastChecked: now.Add(-55 * time.Minute)},
+			want:   true,
+		},
+		{
+			name:   "beyond the slack",
+			image:  &config.Image{Image: "alpine:3.17", CheckInterval: hour},
+			status: &imageStatus{LastChecked: now.Add(-53 * time.Minute)},
+			want:   false,
+		},
+		{
+			name:   "interval of the tenant",
+			image:  &config.Image{Image: "alpine:3.17"},
+			tenant: &config.Tenant{CheckInterval: hour},
+			status: &imageStatus{LastChecked: now.Add(-30 * time.Minute)},
+			want:   false,
+		},
+		{
+			name:   "interval of the image overrides the tenant",
+			image:  &config.Image{Image: "alpine:3.17", CheckInterval: config.Duration(10 * time.Minute)},
+			tenant: &config.Tenant{CheckInterval: hour},
+			status: &imageStatus{LastChecked: now.Add(-30 * time.Minute)},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldTenant := tenant
+			t.Cleanup(func() { index, targetConfigs, tenant = nil, nil, oldTenant })
+			targetConfigs = map[string]*config.Image{tt.image.Image: tt.image}
+			tenant = tt.tenant
+			index = &statusIndex{Images: map[string]*imageStatus{}}
+			if tt.status != nil {
+				index.Images[tt.image.Image] = tt.status
+			}
+
+			if got := isDue(tt.image.Image, now); got != tt.want {
+				t.Errorf("isDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkMissed(t *testing.T) {
+	index = &statusIndex{Images: map[string]*imageStatus{}}
+	indexChanged, indexMustCommit = false, false
+	t.Cleanup(func() { index, indexChanged, indexMustCommit = nil, false, false })
+
+	for want := 1; want <= 3; want++ {
+		if got := markMissed("alpine:3.17"); got != want {
+			t.Errorf("markMissed() = %d, want %d", got, want)
+		}
+	}
+	if !indexChanged || !indexMustCommit {
+		t.Errorf("indexChanged = %v, indexMustCommit = %v, want true", indexChanged, indexMustCommit)
+	}
+
+	// the tag is found again.
+	indexMustCommit = false
+	markChecked("alpine:3.17", time.Now())
+	if got := index.Images["alpine:3.17"].ConsecutiveMisses; got != 0 {
+		t.Errorf("ConsecutiveMisses = %d, want 0", got)
+	}
+	if !indexMustCommit {
+		t.Error("indexMustCommit = false, want true")
+	}
+	if got := markMissed("alpine:3.17"); got != 1 {
+		t.Errorf("markMissed() = %d, want 1", got)
+	}
+}
+
+func TestFindStale(t *testing.T) {
+	now := time.Date(2023, 1, 2, 15, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	tests := []struct {
+		name       string
+		result     *checkResult
+		image      *config.Image
+		staleAfter time.Duration
+		status     *imageStatus
+		want       bool
+	}{
+		{
+			name:       "stale",
+			result:     &checkResult{Image: "alpine:3.17", Status: checkUnchanged},
+			staleAfter: 30 * day,
+			status:     &imageStatus{LastChanged: now.Add(-31 * day)},
+			want:       true,
+		},
+		{
+			name:       "changed recently",
+			result:     &checkResult{Image: "alpine:3.17", Status: checkUnchanged},
+			staleAfter: 30 * day,
+			status:     &imageStatus{LastChanged: now.Add(-29 * day)},
+			want:       false,
+		},
+		{
+			name:   "no stale period",
+			result: &checkResult{Image: "alpine:3.17", Status: checkUnchanged},
+			status: &imageStatus{LastChanged: now.Add(-365 * day)},
+			want:   false,
+		},
+		{
+			name:       "stale period of the image",
+			result:     &checkResult{Image: "alpine:3.17", Status: checkUnchanged},
+			image:      &config.Image{Image: "alpine:3.17", StaleAfter: config.Duration(7 * day)},
+			staleAfter: 30 * day,
+			status:     &imageStatus{LastChanged: now.Add(-8 * day)},
+			want:       true,
+		},
+		{
+			name:       "drifted",
+			result:     &checkResult{Image: "alpine:3.17", Status: checkDrifted},
+			staleAfter: 30 * day,
+			status:     &imageStatus{LastChanged: now.Add(-31 * day)},
+			want:       true,
+		},
+		{
+			name:       "updated",
+			result:     &checkResult{Image: "alpine:3.17", Status: checkUpdated},
+			staleAfter: 30 * day,
+			status:     &imageStatus{LastChanged: now.Add(-31 * day)},
+			want:       false,
+		},
+		{
+			name:       "failed",
+			result:     &checkResult{Image: "alpine:3.17", Status: checkFailed},
+			staleAfter: 30 * day,
+			status:     &imageStatus{LastChanged: now.Add(-31 * day)},
+			want:       false,
+		},
+		{
+			name:       "never changed",
+			result:     &checkResult{Image: "alpine:3.17", Status: checkUnchanged},
+			staleAfter: 30 * day,
+			status:     &imageStatus{},
+			want:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldCfg := cfg
+			t.Cleanup(func() { index, targetConfigs, cfg, checkResults = nil, nil, oldCfg, nil })
+			cfg = &config.Config{StaleAfter: config.Duration(tt.staleAfter)}
+			targetConfigs = map[string]*config.Image{}
+			if tt.image != nil {
+				targetConfigs[tt.image.Image] = tt.image
+			}
+			index = &statusIndex{Images: map[string]*imageStatus{tt.result.Image: tt.status}}
+			checkResults = []*checkResult{tt.result}
+
+			findStale(now)
+			if tt.result.Stale != tt.want {
+				t.Errorf("Stale = %v, want %v", tt.result.Stale, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/storage"
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+// Store is the storage of the manifests of the images.
+// The checker works on it, so that alternative backends can be added without touching the checker.
+type Store interface {
+	// Load returns the stored manifests of the image, or nil without errors if the image is not checked yet.
+	// It returns errCorruptedStatus if the stored manifests can't be parsed.
+	Load(image string) (*registry.Manifests, error)
+
+	// Save stores the manifests of the image.
+	Save(image string, m *registry.Manifests) error
+
+	// List returns the stored images, including the ones that are no longer tracked.
+	List() ([]string, error)
+
+	// Commit records the changes with the summary, e.g. "update: alpine:3.17".
+	Commit(summary string) error
+}
+
+// documentStore is a Store that stores the manifests in the envelope with the metadata of the retrieval.
+type documentStore interface {
+	// LoadDocument returns nil if the image is not stored.
+	LoadDocument(image string) (*storage.Document, error)
+	SaveDocument(image string, doc *storage.Document) error
+}
+
+// historyStore is a Store that keeps the history of the manifests by itself.
+// The history of fileStore comes from the git log.
+type historyStore interface {
+	History(image string) ([]*storage.Revision, error)
+}
+
+// store is the storage used by the commands.
+var store Store = fileStore{}
+
+// addStoreFlags adds the flag to select the storage of the manifests.
+func addStoreFlags(fs *flag.FlagSet) {
+	fs.Func("store", "store the manifests in the `url`, e.g. \"sqlite://diuc.db\" or \"dynamodb://table?region=us-east-1\" (default the files in the repository)", func(s string) error {
+		st, err := openStore(s)
+		if err != nil {
+			return err
+		}
+		store = st
+		return nil
+	})
+}
+
+// openStore returns the store of the URL.
+func openStore(s string) (Store, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "dynamodb":
+		if u.Host == "" {
+			return nil, fmt.Errorf("the table name is missing: %s", s)
+		}
+		return storage.NewDynamoDB(u.Host, u.Query().Get("region")), nil
+	case "sqlite":
+		path := strings.TrimPrefix(s, "sqlite://")
+		if path == "" || path == s {
+			return nil, fmt.Errorf("the path of the database is missing: %s", s)
+		}
+		return storage.NewSQLite(path), nil
+	case "postgres", "postgresql":
+		return storage.NewPostgres(s), nil
+	}
+	return nil, fmt.Errorf("unknown store: %s", s)
+}
+
+// fileStore stores the manifests as the JSON files in statusDir, and commits them by git.
+type fileStore struct{}
+
+// changedFiles are the files written or removed by the command. Only they are committed,
+// so that the unrelated changes in the working tree, e.g. the swap files of the editors, are not.
+var changedFiles []string
+
+// recordChange records that the file is written or removed.
+func recordChange(path string) {
+	if !contains(changedFiles, path) {
+		changedFiles = append(changedFiles, path)
+	}
+}
+
+// Load implements Store.
+func (s fileStore) Load(image string) (*registry.Manifests, error) {
+	doc, err := s.LoadDocument(image)
+	if err != nil || doc == nil {
+		return nil, err
+	}
+	return doc.Manifest, nil
+}
+
+// LoadDocument implements documentStore.
+func (fileStore) LoadDocument(image string) (*storage.Document, error) {
+	path, err := statusFile(image)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := storage.DecodeDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return doc, nil
+}
+
+// Save implements Store.
+func (s fileStore) Save(image string, m *registry.Manifests) error {
+	return s.SaveDocument(image, &storage.Document{Manifest: m})
+}
+
+// SaveDocument implements documentStore.
+// If the layout has the digest in the file name, the old file is replaced with the one of the new digest,
+// and the directories next to it are moved together.
+func (fileStore) SaveDocument(image string, doc *storage.Document) error {
+	path, err := statusFile(image)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// the canonical form, so that the semantically identical manifests are written into the same file.
+	data, err := doc.MarshalIndent("", "    ")
+	if err != nil {
+		return err
+	}
+	if err := archiveStatusFile(path, data, time.Now()); err != nil {
+		return fmt.Errorf("failed to keep the previous version of %s: %w", path, err)
+	}
+	if statusLayout.HasDigest() {
+		digest, err := documentDigest(doc)
+		if err != nil {
+			return err
+		}
+		next, err := statusFileOf(image, digest)
+		if err != nil {
+			return err
+		}
+		if next != path {
+			if err := replaceStatusFile(path, next); err != nil {
+				return err
+			}
+			path = next
+		}
+	}
+	recordChange(path)
+	return os.WriteFile(path, data, 0644)
+}
+
+// replaceStatusFile removes the status file at the old path if it exists,
+// and moves the directories next to it to the new path.
+func replaceStatusFile(old, path string) error {
+	if _, err := os.Stat(old); os.IsNotExist(err) {
+		return nil
+	}
+	recordChange(old)
+	if err := os.Remove(old); err != nil {
+		return err
+	}
+	return moveSidecars(old, path)
+}
+
+// List implements Store.
+func (fileStore) List() ([]string, error) {
+	var images []string
+	err := walkStatusFiles(func(image, path string) error {
+		images = append(images, image)
+		return nil
+	})
+	return images, err
+}
+
+// Commit implements Store.
+// It does nothing if the written files are the same as the committed ones, e.g. only the same manifests are written again.
+func (fileStore) Commit(summary string) error {
+	err := gitCommit(summary, changedFiles)
+	if errors.Is(err, errNothingToCommit) {
+		log.Printf("nothing to commit: %s", firstLine(summary))
+		return nil
+	}
+	return err
+}
+
+// walkStatusFiles calls fn for each status file with the image reference.
+func walkStatusFiles(fn func(image, path string) error) error {
+	if _, err := os.Stat(statusDir); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.Walk(statusDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".json" {
+			return err
+		}
+		rel, err := filepath.Rel(statusDir, path)
+		if err != nil {
+			return err
+		}
+		host, repository, tag, _, err := statusLayout.Parse(filepath.ToSlash(rel))
+		if err != nil {
+			// it is not a status file, e.g. a file in the other layout before the migration.
+			return nil
+		}
+		return fn(statusImage(host, repository, tag), path)
+	})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// summaryFile is the path to the JSON file that the summary of the check run is written into.
+var summaryFile string
+
+// summary is the summary of the check run.
+type summary struct {
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+
+	// Duration is the time taken by the run in seconds.
+	Duration float64 `json:"duration"`
+
+	// RunID identifies the run, as in the audit log.
+	RunID string `json:"runId"`
+
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// Tenant is the name of the selected tenant.
+	Tenant string `json:"tenant,omitempty"`
+
+	// Updated, Failed, Skipped and Removed are the numbers of the images.
+	Updated int `json:"updated"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+	Removed int `json:"removed"`
+
+	// Disabled are the images disabled in the config because their tags are removed.
+	Disabled []string `json:"disabled,omitempty"`
+
+	Images []*checkResult `json:"images"`
+}
+
+// writeSummary writes the summary of the check run into the file.
+func writeSummary(path string, startedAt time.Time) error {
+	data, err := json.MarshalIndent(newSummary(startedAt), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// newSummary returns the summary of the check run.
+func newSummary(startedAt time.Time) *summary {
+	now := time.Now()
+	s := &summary{
+		StartedAt:  startedAt.UTC(),
+		FinishedAt: now.UTC(),
+		Duration:   now.Sub(startedAt).Seconds(),
+		RunID:      runID,
+		DryRun:     dryRun,
+		Disabled:   disabledImages,
+		Tenant:     tenantName,
+		Images:     checkResults,
+	}
+	for _, r := range checkResults {
+		switch r.Status {
+		case checkUpdated:
+			s.Updated++
+		case checkFailed:
+			s.Failed++
+		case checkSkipped:
+			s.Skipped++
+		case checkRemoved:
+			s.Removed++
+		}
+	}
+	if s.Images == nil {
+		s.Images = []*checkResult{}
+	}
+	return s
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/config"
+)
+
+// tenantName is the name of the tenant given by the flag.
+var tenantName string
+
+// tenant is the selected tenant. If it is nil, only the images that belong to no tenants are targets.
+var tenant *config.Tenant
+
+// addTenantFlags adds the flag to select the tenant.
+func addTenantFlags(fs *flag.FlagSet) {
+	fs.StringVar(&tenantName, "tenant", "", "only the images of the tenant `name` in the config, with its own state and notification")
+}
+
+// selectTenant selects the tenant given by the flag, and moves the state into the directory of the tenant.
+func selectTenant() error {
+	tenant = nil
+	statusDir = cfg.StateDir()
+	statusLayout = cfg.StateLayout()
+	statusIndexFile = "status.json"
+	changelogFile = cfg.StateChangelog()
+	auditLogFile = cfg.StateAuditLog()
+	if tenantName == "" {
+		return nil
+	}
+	tenant = cfg.Tenant(tenantName)
+	if tenant == nil {
+		return fmt.Errorf("unknown tenant: %s", tenantName)
+	}
+	statusDir = filepath.Join(tenant.Dir(), cfg.StateDir())
+	statusIndexFile = filepath.Join(tenant.Dir(), "status.json")
+	if changelogFile != "" {
+		changelogFile = filepath.Join(tenant.Dir(), changelogFile)
+	}
+	if auditLogFile != "" {
+		auditLogFile = filepath.Join(tenant.Dir(), auditLogFile)
+	}
+	return nil
+}
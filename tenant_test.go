@@ -0,0 +1,86 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/config"
+)
+
+func TestSelectTenant(t *testing.T) {
+	newConfig := func() *config.Config {
+		return &config.Config{
+			State: &config.State{Dir: "images", Changelog: "CHANGELOG.md"},
+			Tenants: []*config.Tenant{
+				{Name: "team-a", Groups: []string{"a"}},
+				{Name: "team-b", Groups: []string{"b"}, StatePrefix: "state/b"},
+			},
+			Images: []*config.Image{
+				{Image: "alpine:3.17"},
+				{Image: "ubuntu:22.04", Groups: []string{"a"}},
+				{Image: "debian:12", Groups: []string{"a", "base"}},
+				{Image: "golang:1.22", Groups: []string{"b"}},
+			},
+		}
+	}
+	tests := []struct {
+		name      string
+		tenant    string
+		targets   []string
+		dir       string
+		index     string
+		changelog string
+	}{
+		{
+			name:      "no tenant",
+			targets:   []string{"alpine:3.17"},
+			dir:       "images",
+			index:     "status.json",
+			changelog: "CHANGELOG.md",
+		},
+		{
+			name:      "tenant",
+			tenant:    "team-a",
+			targets:   []string{"ubuntu:22.04", "debian:12"},
+			dir:       filepath.Join("tenants", "team-a", "images"),
+			index:     filepath.Join("tenants", "team-a", "status.json"),
+			changelog: filepath.Join("tenants", "team-a", "CHANGELOG.md"),
+		},
+		{
+			name:      "state prefix",
+			tenant:    "team-b",
+			targets:   []string{"golang:1.22"},
+			dir:       filepath.Join("state", "b", "images"),
+			index:     filepath.Join("state", "b", "status.json"),
+			changelog: filepath.Join("state", "b", "CHANGELOG.md"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenantName = tt.tenant
+			t.Cleanup(func() { tenantName = "" })
+			useConfig(t, newConfig())
+			if !reflect.DeepEqual(targets, tt.targets) {
+				t.Errorf("targets = %v, want %v", targets, tt.targets)
+			}
+			if statusDir != tt.dir {
+				t.Errorf("statusDir = %q, want %q", statusDir, tt.dir)
+			}
+			if statusIndexFile != tt.index {
+				t.Errorf("statusIndexFile = %q, want %q", statusIndexFile, tt.index)
+			}
+			if changelogFile != tt.changelog {
+				t.Errorf("changelogFile = %q, want %q", changelogFile, tt.changelog)
+			}
+		})
+	}
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		useConfig(t, newConfig())
+		tenantName = "team-c"
+		if err := selectTenant(); err == nil {
+			t.Error("want error, got nil")
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/shogo82148/docker-image-update-checker/registry"
+)
+
+var verifyCommand = &command{
+	name:    "verify",
+	usage:   "verify [options]",
+	summary: "check that the stored manifests parse and match what the registries serve, without committing anything",
+	run:     runVerify,
+}
+
+func runVerify(cmd *command, args []string) error {
+	fs := newFlagSet(cmd)
+	addConfigFlags(fs)
+	addTenantFlags(fs)
+	addStoreFlags(fs)
+	addGroupFlags(fs)
+	addClientFlags(fs)
+	offline := fs.Bool("offline", false, "only check that the stored files parse")
+	fs.Parse(args)
+
+	if err := loadConfig(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var problems int
+
+	// all stored manifests must parse, including the ones of the untracked images.
+	images, err := store.List()
+	if err != nil {
+		return err
+	}
+	for _, image := range images {
+		m, err := store.Load(image)
+		if errors.Is(err, errCorruptedStatus) {
+			log.Printf("corrupted: %v", err)
+			problems++
+		} else if err != nil {
+			return err
+		} else if m == nil {
+			log.Printf("corrupted: %s: empty manifests", image)
+			problems++
+		}
+	}
+
+	stored := make(map[string]*registry.Manifests, len(targets))
+	for _, image := range targets {
+		m, err := store.Load(image)
+		if errors.Is(err, errCorruptedStatus) {
+			// already reported
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			log.Printf("missing: %s has never been checked", image)
+			problems++
+			continue
+		}
+		stored[image] = m
+	}
+
+	if !*offline {
+		c, err := newClient()
+		if err != nil {
+			return err
+		}
+		if err := login(c); err != nil {
+			return err
+		}
+		images := make([]string, 0, len(stored))
+		for _, image := range targets {
+			if stored[image] != nil {
+				images = append(images, image)
+			}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), runTimeout(images))
+		defer cancel()
+		for _, r := range getManifests(ctx, c, images) {
+			if r.Err != nil {
+				log.Printf("failed: %s: %v", r.Image, r.Err)
+				problems++
+				continue
+			}
+			if !sameManifests(r.Image, stored[r.Image], r.Manifests) {
+				log.Printf("drift: %s differs from the registry", r.Image)
+				problems++
+			}
+		}
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("%d problems found", problems)
+	}
+	log.Printf("ok: %d images", len(targets))
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// the build metadata, embedded by the linker, e.g.
+// go build -ldflags "-X main.version=v1.0.0 -X main.commit=$(git rev-parse HEAD) -X main.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version = ""
+	commit  = ""
+	date    = ""
+)
+
+var versionCommand = &command{
+	name:    "version",
+	usage:   "version",
+	summary: "show the version and the build metadata",
+	run:     runVersion,
+}
+
+// getVersion returns the version of the binary.
+// It falls back to the module version for `go install`, and "dev" for local builds.
+func getVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// userAgent returns the User-Agent of the requests to the registries.
+func userAgent() string {
+	return progName + "/" + getVersion() + " (+https://github.com/shogo82148/docker-image-update-checker)"
+}
+
+func runVersion(cmd *command, args []string) error {
+	fs := newFlagSet(cmd)
+	fs.Parse(args)
+
+	fmt.Printf("%s %s\n", progName, getVersion())
+	if commit != "" {
+		fmt.Printf("commit: %s\n", commit)
+	}
+	if date != "" {
+		fmt.Printf("built at: %s\n", date)
+	}
+	fmt.Printf("go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/shogo82148/docker-image-update-checker/internal/config"
+)
+
+// watchInterval is the interval of the checks in the watch mode. 0 means that check runs once.
+var watchInterval time.Duration
+
+// configPollInterval is the interval to look for the changes of the config file in the watch mode.
+const configPollInterval = 5 * time.Second
+
+// configStamp identifies a version of the config file, to find its changes.
+type configStamp struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// statConfig returns the stamp of the config file. The stamp has only the path if the file doesn't exist.
+func statConfig() configStamp {
+	stamp := configStamp{path: configFile()}
+	if fi, err := os.Stat(stamp.path); err == nil {
+		stamp.modTime = fi.ModTime()
+		stamp.size = fi.Size()
+	}
+	return stamp
+}
+
+// watchChecks checks the targets every watchInterval until the process is interrupted.
+// The config is reloaded on SIGHUP or when the config file changes, and applied from the next check.
+// An interrupt stops the loop after the running check finishes, so that its updates are committed.
+func watchChecks() error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	poll := time.NewTicker(configPollInterval)
+	defer poll.Stop()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	stamp := statConfig()
+
+	log.Printf("watch: checking every %s", watchInterval)
+	for {
+		select {
+		case sig := <-stop:
+			log.Printf("watch: stopped by %v", sig)
+			return nil
+		case <-hup:
+			log.Printf("watch: reloading the config on SIGHUP")
+			stamp = statConfig()
+			reloadConfig()
+		case <-poll.C:
+			if s := statConfig(); s != stamp {
+				log.Printf("watch: reloading the config, because %s has changed", s.path)
+				stamp = s
+				reloadConfig()
+			}
+		case <-timer.C:
+			startedAt := time.Now()
+			resetRun()
+			// the targets are selected again, because the previous check has narrowed them down.
+			if err := applyConfig(cfg); err != nil {
+				log.Printf("WARNING: failed to apply the config: %v", err)
+			} else if err := checkOnce(startedAt); err != nil {
+				log.Printf("WARNING: the check failed: %v", err)
+			}
+			timer.Reset(time.Until(startedAt.Add(watchInterval)))
+			// the config may be saved by the check, e.g. with -discover.
+			if s := statConfig(); s != stamp {
+				log.Printf("watch: reloading the config, because %s has changed", s.path)
+				stamp = s
+				reloadConfig()
+			}
+		}
+	}
+}
+
+// reloadConfig reloads the config file, and logs the changes of the effective configuration.
+// The old config is kept if the new one is invalid.
+func reloadConfig() {
+	next, err := readConfig()
+	if err != nil {
+		log.Printf("WARNING: failed to reload the config, keeping the old one: %v", err)
+		return
+	}
+	old := cfg
+	if err := applyConfig(next); err != nil {
+		log.Printf("WARNING: failed to reload the config, keeping the old one: %v", err)
+		if err := applyConfig(old); err != nil {
+			log.Printf("WARNING: failed to restore the old config: %v", err)
+		}
+		return
+	}
+
+	changes := config.Diff(old, next)
+	if len(changes) == 0 {
+		log.Printf("watch: the config has no changes")
+		return
+	}
+	for _, change := range changes {
+		log.Printf("watch: config: %s", change)
+	}
+}
+
+// resetRun clears the results of the previous check in the watch mode.
+// The other states are loaded again by each check.
+func resetRun() {
+	checkResults = nil
+	disabledImages = nil
+	removedTags = nil
+	prunedImages = nil
+	releaseTag = ""
+	changedFiles = nil
+	auditLogged = false
+}